@@ -0,0 +1,159 @@
+package terrors
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/monzo/terrors/stack"
+)
+
+// Adopt converts err, and every link in its chain, into a terror chain. It is intended for codebases migrating from
+// github.com/pkg/errors or from fmt.Errorf with %w, where Propagate would flatten the whole chain into a single terror.
+//
+// The chain is followed with Unwrap, or with `Cause() error` for errors which don't implement Unwrap. The innermost
+// error becomes a terror in the same way as with Propagate, and is kept as its cause, so errors.Is and errors.As still
+// find it. Each wrapper around it becomes a terror whose message is the context added by that wrapper (e.g. "reading
+// config" for an error created with `fmt.Errorf("reading config: %w", err)`), in the same way as Augment.
+//
+// Stacks are preserved: any link with a `StackTrace()` method returning program counters (as the errors from
+// github.com/pkg/errors have) gives its stack to the terror it becomes. Wrappers which only add a stack, such as those
+// created by errors.WithStack, don't add a link of their own. If no link in the chain had a stack, one is captured at
+// the call site of Adopt.
+//
+// The conversion stops at the first terror found in the chain, which is used as-is, and Adopt returns err unchanged
+// if it is already a terror. The wrappers themselves aren't kept, so errors.As can't find them in the result.
+//
+// The opposite conversion needs no help: terrors implement Unwrap and Cause, so both errors.Unwrap and errors.Cause
+// from github.com/pkg/errors can walk a terror chain.
+func Adopt(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	if terr, ok := err.(*Error); ok {
+		return terr
+	}
+
+	// Collect the chain, outermost first, stopping at the first terror
+	chain := []error{err}
+	maxDepth := CurrentMaxCausalDepth()
+	for len(chain) < maxDepth {
+		if _, ok := chain[len(chain)-1].(*Error); ok {
+			break
+		}
+		next := unwrapLink(chain[len(chain)-1])
+		if next == nil {
+			break
+		}
+		chain = append(chain, next)
+	}
+
+	innermost := chain[len(chain)-1]
+	adopted, ok := innermost.(*Error)
+	hasStack := ok
+	if !ok {
+		adopted = adoptCause(innermost)
+		if pcs, ok := linkStackTrace(innermost); ok {
+			adopted.StackFrames = stack.BuildStackFromPCs(pcs)
+			hasStack = true
+		}
+	}
+
+	for i := len(chain) - 2; i >= 0; i-- {
+		link := chain[i]
+		pcs, linkHasStack := linkStackTrace(link)
+		message := linkMessage(link, chain[i+1])
+		if message == "" {
+			// The link only adds a stack, so it belongs to the link it wraps
+			if linkHasStack && len(adopted.StackFrames) == 0 {
+				// adopted may be a terror from the chain of err, which mustn't be modified
+				adopted = addParams(adopted, nil)
+				adopted.StackFrames = stack.BuildStackFromPCs(pcs)
+				hasStack = true
+			}
+			continue
+		}
+		adopted = Augment(adopted, message, nil).(*Error)
+		if linkHasStack {
+			adopted.StackFrames = stack.BuildStackFromPCs(pcs)
+			hasStack = true
+		}
+	}
+
+	if !hasStack {
+		base := adopted
+		for next, ok := base.cause.(*Error); ok; next, ok = base.cause.(*Error) {
+			base = next
+		}
+		// Skip BuildStack() and Adopt()
+		base.StackFrames = stack.BuildStack(2)
+	}
+	return adopted
+}
+
+// adoptCause builds the innermost terror of an adopted chain from err, which isn't a terror, in the same way as
+// Propagate but without a stack.
+func adoptCause(err error) *Error {
+	if translated, ok := translateCause(err, true); ok {
+		return translated
+	}
+	newErr := buildError(causeCode(err), err.Error(), nil)
+	newErr.attachCause(err)
+	return newErr
+}
+
+// unwrapLink returns the error wrapped by err, following Unwrap, or `Cause() error` for errors which don't implement
+// it. It returns nil for errors with several causes, which are adopted as a whole.
+func unwrapLink(err error) error {
+	if _, ok := err.(multiUnwrapper); ok {
+		return nil
+	}
+	if next := errors.Unwrap(err); next != nil {
+		return next
+	}
+	if causer, ok := err.(interface{ Cause() error }); ok {
+		if next := causer.Cause(); next != err {
+			return next
+		}
+	}
+	return nil
+}
+
+// linkMessage returns the context added by link to the message of the error it wraps. Wrappers conventionally prefix
+// the message of the wrapped error with their own and a colon; messages which don't follow the convention are returned
+// whole. It returns an empty string if link doesn't change the message.
+func linkMessage(link, wrapped error) string {
+	message, inner := link.Error(), wrapped.Error()
+	if message == inner {
+		return ""
+	}
+	if context := strings.TrimSuffix(message, ": "+inner); context != message {
+		return context
+	}
+	return message
+}
+
+// linkStackTrace returns the program counters of the stack recorded by err, if it has a `StackTrace()` method
+// returning a slice of program counters. The method of github.com/pkg/errors returns its own named types, so the
+// result is found by reflection to avoid depending on it. Like those of runtime.Callers, the program counters are
+// return addresses.
+func linkStackTrace(err error) ([]uintptr, bool) {
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return nil, false
+	}
+	typ := method.Type()
+	if typ.NumIn() != 0 || typ.NumOut() != 1 || typ.Out(0).Kind() != reflect.Slice ||
+		typ.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil, false
+	}
+	frames := method.Call(nil)[0]
+	if frames.Len() == 0 {
+		return nil, false
+	}
+	pcs := make([]uintptr, frames.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(frames.Index(i).Uint())
+	}
+	return pcs, true
+}
@@ -0,0 +1,134 @@
+package terrors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The errors below mimic those of github.com/pkg/errors, which only implement Cause.
+
+type pkgFrame uintptr
+
+type pkgStackTrace []pkgFrame
+
+func pkgCallers() pkgStackTrace {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	st := make(pkgStackTrace, n)
+	for i, pc := range pcs[:n] {
+		st[i] = pkgFrame(pc)
+	}
+	return st
+}
+
+type pkgFundamental struct {
+	msg   string
+	stack pkgStackTrace
+}
+
+func (e *pkgFundamental) Error() string             { return e.msg }
+func (e *pkgFundamental) StackTrace() pkgStackTrace { return e.stack }
+
+type pkgWithStack struct {
+	error
+	stack pkgStackTrace
+}
+
+func (e *pkgWithStack) Cause() error              { return e.error }
+func (e *pkgWithStack) StackTrace() pkgStackTrace { return e.stack }
+
+type pkgWithMessage struct {
+	cause error
+	msg   string
+}
+
+func (e *pkgWithMessage) Error() string { return e.msg + ": " + e.cause.Error() }
+func (e *pkgWithMessage) Cause() error  { return e.cause }
+
+func pkgNew(msg string) error {
+	return &pkgFundamental{msg: msg, stack: pkgCallers()}
+}
+
+func pkgWrap(err error, msg string) error {
+	return &pkgWithStack{error: &pkgWithMessage{cause: err, msg: msg}, stack: pkgCallers()}
+}
+
+func adoptFundamental() error { return pkgNew("connection refused") }
+
+func adoptWrapper(err error) error { return pkgWrap(err, "reading config") }
+
+func TestAdopt(t *testing.T) {
+	assert.Nil(t, Adopt(nil))
+
+	terr := NotFound("config", "no config", nil)
+	assert.True(t, terr == Adopt(terr))
+
+	leaf := adoptFundamental()
+	err := adoptWrapper(fmt.Errorf("dialling: %w", leaf))
+
+	adopted := Adopt(err)
+	assert.Equal(t, ErrInternalService, adopted.Code)
+	assert.Equal(t, "reading config", adopted.Message)
+	assert.Equal(t, []string{"dialling", "connection refused", "connection refused"}, adopted.MessageChain)
+	assert.True(t, errors.Is(adopted, leaf))
+	assert.True(t, adopted.Retryable())
+	if assert.NotEmpty(t, adopted.StackFrames) {
+		assert.Contains(t, adopted.StackFrames[0].Method, "adoptWrapper")
+	}
+
+	dialling, ok := adopted.Unwrap().(*Error)
+	if assert.True(t, ok) {
+		assert.Equal(t, "dialling", dialling.Message)
+		// fmt.Errorf doesn't record a stack
+		assert.Empty(t, dialling.StackFrames)
+	}
+	base, ok := dialling.Unwrap().(*Error)
+	if assert.True(t, ok) {
+		assert.Equal(t, "connection refused", base.Message)
+		assert.True(t, base.Unwrap() == leaf)
+		if assert.NotEmpty(t, base.StackFrames) {
+			assert.Contains(t, base.StackFrames[0].Method, "adoptFundamental")
+		}
+	}
+}
+
+func TestAdoptStopsAtTerror(t *testing.T) {
+	terr := NotFound("config", "no config", nil)
+	terr.SetIsRetryable(true)
+	adopted := Adopt(fmt.Errorf("loading: %w", terr))
+	assert.Equal(t, "not_found.config", adopted.Code)
+	assert.Equal(t, "loading", adopted.Message)
+	assert.True(t, adopted.Retryable())
+	assert.True(t, adopted.Unwrap() == terr)
+}
+
+func TestAdoptDoesNotModifyTerrors(t *testing.T) {
+	terr := &Error{Code: ErrNotFound, Message: "no config"}
+	adopted := Adopt(&pkgWithStack{error: terr, stack: pkgCallers()})
+	assert.Equal(t, "not_found", adopted.Code)
+	assert.NotEmpty(t, adopted.StackFrames)
+	assert.Empty(t, terr.StackFrames)
+}
+
+func TestAdoptCapturesStack(t *testing.T) {
+	adopted := Adopt(fmt.Errorf("reading body: %w", io.EOF))
+	assert.Equal(t, "reading body", adopted.Message)
+	assert.Empty(t, adopted.StackFrames)
+
+	base := adopted.Unwrap().(*Error)
+	assert.Equal(t, "EOF", base.Message)
+	if assert.NotEmpty(t, base.StackFrames) {
+		assert.Contains(t, base.StackFrames[0].Method, "TestAdoptCapturesStack")
+	}
+	assert.True(t, errors.Is(adopted, io.EOF))
+}
+
+func TestAdoptUnconventionalMessage(t *testing.T) {
+	adopted := Adopt(fmt.Errorf("failed (%w)", io.EOF))
+	assert.Equal(t, "failed (EOF)", adopted.Message)
+}
@@ -0,0 +1,126 @@
+package terrors
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/monzo/terrors/stack"
+)
+
+// Params set on the errors returned by Aggregate.
+const (
+	// AggregateFailuresParam is the number of items which failed.
+	AggregateFailuresParam = "aggregate_failures"
+	// AggregateTotalParam is the number of items in the batch.
+	AggregateTotalParam = "aggregate_total"
+	// AggregateFailurePrefix prefixes the key of each failed item in the params, whose value is the code of its error,
+	// e.g. `failure.acc_123: not_found.account`.
+	AggregateFailurePrefix = "failure."
+)
+
+// BatchFailures holds the error of each failed item of a batch, by key. It is attached to the errors returned by
+// Aggregate, and can be retrieved with As:
+//
+//	var failures terrors.BatchFailures
+//	if terrors.As(err, &failures) {
+type BatchFailures map[string]*Error
+
+// codeSeverity ranks the generic codes from worst to least bad, for choosing the code of an aggregated error. Codes
+// which aren't listed rank with internal_service.
+var codeSeverity = []string{
+	ErrInternalService,
+	ErrUnknown,
+	ErrBadResponse,
+	ErrUnavailable,
+	ErrTimeout,
+	ErrNotImplemented,
+	ErrRateLimited,
+	ErrConflict,
+	ErrPreconditionFailed,
+	ErrForbidden,
+	ErrUnauthorized,
+	ErrBadRequest,
+	ErrNotFound,
+}
+
+// severity returns the rank of code in codeSeverity, where lower is worse.
+func severity(code string) int {
+	generic := code
+	if i := strings.IndexByte(code, '.'); i >= 0 {
+		generic = code[:i]
+	}
+	for rank, c := range codeSeverity {
+		if c == generic {
+			return rank
+		}
+	}
+	return 0
+}
+
+// Aggregate returns one error summarising the failures of a batch, whose items are keyed by errs. Items with a nil
+// error succeeded. It returns nil if no items failed. Otherwise the error:
+//   - has the code of the worst of the failures, e.g. internal_service is worse than timeout, which is worse than
+//     not_found (ties are broken by key)
+//   - is retryable only if every failure is, and unexpected if any is
+//   - has a param for each failure, with the key prefixed by AggregateFailurePrefix and the code as the value, and
+//     the numbers of failures and items in the AggregateFailuresParam and AggregateTotalParam params
+//   - has each failure as a branch of its cause, in key order, and the failures attached as BatchFailures
+//
+// Errors which aren't terrors are propagated.
+func Aggregate(errs map[string]error) error {
+	keys := make([]string, 0, len(errs))
+	for key := range errs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return aggregate(keys, errs)
+}
+
+// AggregateIndexed behaves like Aggregate, for a batch whose items are identified by their index in errs. The keys
+// are the indices, e.g. `failure.3`, and the failures are in index order.
+func AggregateIndexed(errs []error) error {
+	keys := make([]string, 0, len(errs))
+	keyed := make(map[string]error, len(errs))
+	for i, err := range errs {
+		key := strconv.Itoa(i)
+		keys = append(keys, key)
+		keyed[key] = err
+	}
+	return aggregate(keys, keyed)
+}
+
+// aggregate implements Aggregate, with the failures ordered by keys. It must be called directly by a public function,
+// so that the stack starts at its caller.
+func aggregate(keys []string, errs map[string]error) error {
+	failures := make(BatchFailures, len(keys))
+	causes := make(multiError, 0, len(keys))
+	params := map[string]string{
+		AggregateTotalParam: strconv.Itoa(len(errs)),
+	}
+	var worst *Error
+	for _, key := range keys {
+		if errs[key] == nil {
+			continue
+		}
+		terr := Propagate(errs[key]).(*Error)
+		failures[key] = terr
+		causes = append(causes, terr)
+		params[AggregateFailurePrefix+key] = terr.Code
+		if worst == nil || severity(terr.Code) < severity(worst.Code) {
+			worst = terr
+		}
+	}
+	if worst == nil {
+		return nil
+	}
+	params[AggregateFailuresParam] = strconv.Itoa(len(causes))
+
+	err := buildError(worst.Code, fmt.Sprintf("%d of %d items failed", len(causes), len(errs)), params)
+	// Skip BuildStack(), aggregate() and the public function
+	err.StackFrames = stack.BuildStack(3)
+	err.attachCause(causes)
+	err.details = []interface{}{failures}
+	return err
+}
@@ -0,0 +1,65 @@
+package terrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregate(t *testing.T) {
+	assert.Nil(t, Aggregate(nil))
+	assert.Nil(t, Aggregate(map[string]error{"a": nil}))
+
+	err := Aggregate(map[string]error{
+		"acc_1": NotFound("account", "account not found", nil),
+		"acc_2": nil,
+		"acc_3": Timeout("ledger", "ledger timed out", nil),
+		"acc_4": BadRequest("invalid", "invalid account", nil),
+	}).(*Error)
+
+	assert.Equal(t, "timeout.ledger", err.Code)
+	assert.Equal(t, "3 of 4 items failed", err.Message)
+	assert.False(t, err.Retryable())
+	assert.Equal(t, map[string]string{
+		AggregateFailuresParam:           "3",
+		AggregateTotalParam:              "4",
+		AggregateFailurePrefix + "acc_1": "not_found.account",
+		AggregateFailurePrefix + "acc_3": "timeout.ledger",
+		AggregateFailurePrefix + "acc_4": "bad_request.invalid",
+	}, err.Params)
+	assert.Contains(t, err.StackFrames[0].Method, "TestAggregate")
+	assert.True(t, Is(err, ErrNotFound, "account"))
+	assert.Equal(t, "timeout.ledger: 3 of 4 items failed: 3 errors: [1] not_found.account: account not found; "+
+		"[2] timeout.ledger: ledger timed out; [3] bad_request.invalid: invalid account", err.Error())
+
+	var failures BatchFailures
+	assert.True(t, As(err, &failures))
+	assert.Len(t, failures, 3)
+	assert.Equal(t, "not_found.account", failures["acc_1"].Code)
+}
+
+func TestAggregateRetryability(t *testing.T) {
+	err := Aggregate(map[string]error{
+		"a": Timeout("", "", nil),
+		"b": errors.New("boom"),
+	}).(*Error)
+	assert.Equal(t, ErrInternalService, err.Code)
+	assert.True(t, err.Retryable())
+}
+
+func TestAggregateIndexed(t *testing.T) {
+	errs := make([]error, 12)
+	errs[2] = NotFound("a", "", nil)
+	errs[10] = NotFound("b", "", nil)
+
+	err := AggregateIndexed(errs).(*Error)
+	assert.Equal(t, "not_found.a", err.Code)
+	assert.Equal(t, "2 of 12 items failed", err.Message)
+	assert.Equal(t, "not_found.b", err.Params[AggregateFailurePrefix+"10"])
+	assert.Contains(t, err.StackFrames[0].Method, "TestAggregateIndexed")
+	// In index order rather than lexical order
+	assert.Equal(t, "not_found.a", err.Unwrap().(multiError)[0].(*Error).Code)
+
+	assert.Nil(t, AggregateIndexed(make([]error, 3)))
+}
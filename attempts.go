@@ -0,0 +1,61 @@
+package terrors
+
+import "strconv"
+
+const (
+	// AttemptParam is the param under which the number of attempts made before an error was returned is stored.
+	AttemptParam = "attempt"
+	// MaxAttemptsParam is the param under which the maximum number of attempts allowed by the retry policy is stored.
+	MaxAttemptsParam = "max_attempts"
+)
+
+// WithAttempt returns a copy of err recording that it was returned after `attempt` of `maxAttempts` attempts. This is
+// intended to be called on the final error of a retry loop, so that callers (including those on the other side of the
+// wire) can tell that retries were exhausted. If err is not a terror, it is propagated first so that the attempts can
+// be attached. A nil error returns nil.
+func WithAttempt(err error, attempt, maxAttempts int) error {
+	if err == nil {
+		return nil
+	}
+	return addParams(Propagate(err).(*Error), map[string]string{
+		AttemptParam:     strconv.Itoa(attempt),
+		MaxAttemptsParam: strconv.Itoa(maxAttempts),
+	})
+}
+
+// Attempts returns the attempt and maximum number of attempts attached to err with WithAttempt. It walks the causal
+// chain and returns the most recently attached values. The boolean is false if no valid attempts were found.
+func Attempts(err error) (attempt, maxAttempts int, ok bool) {
+	maxDepth := CurrentMaxCausalDepth()
+	for depth := 0; err != nil && depth < maxDepth; depth++ {
+		terr, isTerr := err.(*Error)
+		if !isTerr {
+			return 0, 0, false
+		}
+		if a, m, found := parseAttempts(terr.Params); found {
+			return a, m, true
+		}
+		err = terr.cause
+	}
+	return 0, 0, false
+}
+
+func parseAttempts(params map[string]string) (int, int, bool) {
+	rawAttempt, ok := params[AttemptParam]
+	if !ok {
+		return 0, 0, false
+	}
+	rawMax, ok := params[MaxAttemptsParam]
+	if !ok {
+		return 0, 0, false
+	}
+	attempt, err := strconv.Atoi(rawAttempt)
+	if err != nil {
+		return 0, 0, false
+	}
+	maxAttempts, err := strconv.Atoi(rawMax)
+	if err != nil {
+		return 0, 0, false
+	}
+	return attempt, maxAttempts, true
+}
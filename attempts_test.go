@@ -0,0 +1,67 @@
+package terrors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttempts(t *testing.T) {
+	t.Run("terror", func(t *testing.T) {
+		base := Timeout("downstream", "timed out", map[string]string{"foo": "bar"})
+		err := WithAttempt(base, 3, 3)
+
+		attempt, maxAttempts, ok := Attempts(err)
+		assert.True(t, ok)
+		assert.Equal(t, 3, attempt)
+		assert.Equal(t, 3, maxAttempts)
+		assert.Equal(t, "bar", err.(*Error).Params["foo"])
+		// The original error isn't modified
+		assert.NotContains(t, base.Params, AttemptParam)
+	})
+	t.Run("non-terror", func(t *testing.T) {
+		err := WithAttempt(assert.AnError, 2, 5)
+		assert.True(t, Is(err, ErrInternalService))
+		assert.Equal(t, assert.AnError, err.(*Error).Unwrap())
+
+		attempt, maxAttempts, ok := Attempts(err)
+		assert.True(t, ok)
+		assert.Equal(t, 2, attempt)
+		assert.Equal(t, 5, maxAttempts)
+	})
+	t.Run("non-terror keeps the code it would be propagated with", func(t *testing.T) {
+		err := WithAttempt(context.DeadlineExceeded, 3, 3)
+		assert.True(t, Is(err, ErrTimeout))
+		assert.True(t, IsRetryable(err))
+	})
+	t.Run("survives augmentation and the wire", func(t *testing.T) {
+		err := WithAttempt(NotFound("foo", "bar", nil), 4, 4)
+		err = Augment(Unmarshal(Marshal(err.(*Error))), "calling downstream", nil)
+
+		attempt, maxAttempts, ok := Attempts(err)
+		assert.True(t, ok)
+		assert.Equal(t, 4, attempt)
+		assert.Equal(t, 4, maxAttempts)
+	})
+	t.Run("most recent wins", func(t *testing.T) {
+		err := WithAttempt(WithAttempt(NotFound("foo", "bar", nil), 1, 2), 3, 3)
+		attempt, maxAttempts, ok := Attempts(err)
+		assert.True(t, ok)
+		assert.Equal(t, 3, attempt)
+		assert.Equal(t, 3, maxAttempts)
+	})
+	t.Run("missing or invalid", func(t *testing.T) {
+		_, _, ok := Attempts(NotFound("foo", "bar", nil))
+		assert.False(t, ok)
+		_, _, ok = Attempts(assert.AnError)
+		assert.False(t, ok)
+		_, _, ok = Attempts(NotFound("foo", "bar", map[string]string{AttemptParam: "x", MaxAttemptsParam: "3"}))
+		assert.False(t, ok)
+		_, _, ok = Attempts(NotFound("foo", "bar", map[string]string{AttemptParam: "1"}))
+		assert.False(t, ok)
+	})
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, WithAttempt(nil, 1, 1))
+	})
+}
@@ -0,0 +1,38 @@
+package terrors
+
+import "fmt"
+
+// Standard codes for the most common kinds of bad request, so that services don't each invent their own.
+const (
+	// ErrMissingParam is the code of errors returned by BadRequestMissingParam.
+	ErrMissingParam = ErrBadRequest + ".missing_param"
+	// ErrInvalidParam is the code of errors returned by BadRequestInvalidParam.
+	ErrInvalidParam = ErrBadRequest + ".invalid_param"
+)
+
+// Params set on errors returned by BadRequestMissingParam and BadRequestInvalidParam.
+const (
+	// ParamNameParam is the name of the request param which was missing or invalid.
+	ParamNameParam = "param"
+	// ParamReasonParam is why the request param was invalid.
+	ParamReasonParam = "reason"
+)
+
+// BadRequestMissingParam creates a new bad_request.missing_param error, for a request which is missing the required
+// param. The name of the param is kept in the params of the error rather than the code, so that all such errors can
+// be counted and matched together.
+func BadRequestMissingParam(param string) *Error {
+	return errorFactory(ErrMissingParam, fmt.Sprintf("missing required param %s", param), map[string]string{
+		ParamNameParam: param,
+	})
+}
+
+// BadRequestInvalidParam creates a new bad_request.invalid_param error, for a request in which param has an invalid
+// value, described by reason (e.g. "must be positive"). As for BadRequestMissingParam, the name of the param is kept
+// in the params of the error rather than the code.
+func BadRequestInvalidParam(param, reason string) *Error {
+	return errorFactory(ErrInvalidParam, fmt.Sprintf("invalid param %s: %s", param, reason), map[string]string{
+		ParamNameParam:   param,
+		ParamReasonParam: reason,
+	})
+}
@@ -0,0 +1,29 @@
+package terrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBadRequestMissingParam(t *testing.T) {
+	err := BadRequestMissingParam("account_id")
+	assert.Equal(t, "bad_request.missing_param", err.Code)
+	assert.Equal(t, "missing required param account_id", err.Message)
+	assert.Equal(t, map[string]string{ParamNameParam: "account_id"}, err.Params)
+	assert.False(t, err.Retryable())
+	assert.True(t, Is(err, ErrBadRequest))
+	assert.Contains(t, err.StackFrames[0].Method, "TestBadRequestMissingParam")
+}
+
+func TestBadRequestInvalidParam(t *testing.T) {
+	err := BadRequestInvalidParam("amount", "must be positive")
+	assert.Equal(t, "bad_request.invalid_param", err.Code)
+	assert.Equal(t, "invalid param amount: must be positive", err.Message)
+	assert.Equal(t, map[string]string{
+		ParamNameParam:   "amount",
+		ParamReasonParam: "must be positive",
+	}, err.Params)
+	assert.False(t, err.Retryable())
+	assert.Contains(t, err.StackFrames[0].Method, "TestBadRequestInvalidParam")
+}
@@ -0,0 +1,103 @@
+package terrors
+
+import (
+	"fmt"
+	"time"
+)
+
+// Builder builds up an error incrementally, for handlers which decide on its params, sub code and flags across
+// several branches before returning it:
+//
+//	b := terrors.Build(terrors.ErrBadRequest).Subcode("missing_param")
+//	if amount == "" {
+//		b = b.Param("field", "amount")
+//	}
+//	return b.Retryable(false).Err()
+//
+// The stack is captured when Err is called. Each call to Err returns a new error, which is unaffected by later calls
+// to the builder. A Builder is not safe for concurrent use.
+type Builder struct {
+	code    string
+	message string
+	options errorOptions
+}
+
+// Build returns a Builder for an error with the given code.
+func Build(code string) *Builder {
+	return &Builder{code: code}
+}
+
+// Subcode appends a sub code to the code of the error (see WithSubCode).
+func (b *Builder) Subcode(subCode string) *Builder {
+	WithSubCode(subCode)(&b.options)
+	return b
+}
+
+// Message sets the message of the error.
+func (b *Builder) Message(message string) *Builder {
+	b.message = message
+	return b
+}
+
+// Messagef sets the message of the error, formatted with fmt.Sprintf.
+func (b *Builder) Messagef(format string, args ...interface{}) *Builder {
+	b.message = fmt.Sprintf(format, args...)
+	return b
+}
+
+// Param sets a param on the error.
+func (b *Builder) Param(key, value string) *Builder {
+	WithParam(key, value)(&b.options)
+	return b
+}
+
+// Params sets each of the given params on the error.
+func (b *Builder) Params(params map[string]string) *Builder {
+	WithParams(params)(&b.options)
+	return b
+}
+
+// Retryable explicitly sets the retryability of the error (see WithRetryable).
+func (b *Builder) Retryable(retryable bool) *Builder {
+	WithRetryable(retryable)(&b.options)
+	return b
+}
+
+// Unexpected explicitly marks the error as unexpected or not (see WithUnexpected).
+func (b *Builder) Unexpected(unexpected bool) *Builder {
+	WithUnexpected(unexpected)(&b.options)
+	return b
+}
+
+// RetryAfter sets a hint of how long the client should wait before retrying the error (see WithRetryAfter).
+func (b *Builder) RetryAfter(retryAfter time.Duration) *Builder {
+	WithRetryAfter(retryAfter)(&b.options)
+	return b
+}
+
+// Cause sets the cause of the error (see WithCause).
+func (b *Builder) Cause(cause error) *Builder {
+	WithCause(cause)(&b.options)
+	return b
+}
+
+// Detail attaches a typed detail to the error (see WithDetail).
+func (b *Builder) Detail(detail interface{}) *Builder {
+	WithDetail(detail)(&b.options)
+	return b
+}
+
+// StackSkip skips the given number of additional frames when capturing the stack in Err (see WithStackSkip).
+func (b *Builder) StackSkip(skip int) *Builder {
+	WithStackSkip(skip)(&b.options)
+	return b
+}
+
+// Err builds the error, capturing the stack of its caller.
+func (b *Builder) Err() *Error {
+	o := b.options
+	// Copy the params, so that the error isn't modified by later calls to the builder
+	o.params = mergeParams(o.params, nil)
+	o.details = append([]interface{}(nil), o.details...)
+	return newFromOptions(b.code, b.message, o)
+}
@@ -0,0 +1,40 @@
+package terrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	cause := errors.New("boom")
+	err := Build(ErrBadRequest).
+		Subcode("missing_param").
+		Messagef("missing %s", "amount").
+		Param("field", "amount").
+		Params(map[string]string{"a": "1"}).
+		Retryable(true).
+		Unexpected(true).
+		Cause(cause).
+		Err()
+
+	assert.Equal(t, "bad_request.missing_param", err.Code)
+	assert.Equal(t, "missing amount", err.Message)
+	assert.Equal(t, map[string]string{"field": "amount", "a": "1"}, err.Params)
+	assert.True(t, err.Retryable())
+	assert.True(t, err.Unexpected())
+	assert.True(t, errors.Is(err, cause))
+	assert.Contains(t, err.StackFrames[0].Method, "TestBuilder")
+}
+
+func TestBuilderErrIsUnaffectedByLaterCalls(t *testing.T) {
+	b := Build(ErrNotFound).Message("not found").Param("a", "1")
+	first := b.Err()
+	second := b.Param("b", "2").Subcode("account").Err()
+
+	assert.Equal(t, "not_found", first.Code)
+	assert.Equal(t, map[string]string{"a": "1"}, first.Params)
+	assert.Equal(t, "not_found.account", second.Code)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, second.Params)
+}
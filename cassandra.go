@@ -0,0 +1,120 @@
+package terrors
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Error codes produced by TranslateCassandraError. Each is prefixed by the generic code which best describes it, so
+// the retryability of the generic code applies.
+const (
+	ErrCassandraReadTimeout  = ErrTimeout + ".cassandra_read_timeout"
+	ErrCassandraWriteTimeout = ErrTimeout + ".cassandra_write_timeout"
+	ErrCassandraNoResponse   = ErrTimeout + ".cassandra_no_response"
+	ErrCassandraUnavailable  = ErrUnavailable + ".cassandra_unavailable"
+	ErrCassandraOverloaded   = ErrRateLimited + ".cassandra_overloaded"
+)
+
+// Params set by TranslateCassandraError from the fields of the gocql error, where present.
+const (
+	CassandraConsistencyParam = "cassandra_consistency"
+	CassandraReceivedParam    = "cassandra_received"
+	CassandraRequiredParam    = "cassandra_required"
+	CassandraAliveParam       = "cassandra_alive"
+	CassandraWriteTypeParam   = "cassandra_write_type"
+	CassandraDataPresentParam = "cassandra_data_present"
+)
+
+// Cassandra protocol error codes, as returned by the Code() method of gocql's request errors.
+const (
+	cassandraCodeUnavailable  = 0x1000
+	cassandraCodeOverloaded   = 0x1001
+	cassandraCodeWriteTimeout = 0x1100
+	cassandraCodeReadTimeout  = 0x1200
+)
+
+// The message of gocql.ErrTimeoutNoResponse, which is a plain sentinel error.
+const cassandraNoResponseMessage = "gocql: no response received from cassandra within timeout period"
+
+// cassandraRequestError matches gocql.RequestError without depending on gocql.
+type cassandraRequestError interface {
+	Code() int
+	Message() string
+	Error() string
+}
+
+// cassandraParamFields maps the fields of gocql's request errors to the params they are recorded under.
+var cassandraParamFields = []struct {
+	field, param string
+}{
+	{"Consistency", CassandraConsistencyParam},
+	{"Received", CassandraReceivedParam},
+	{"BlockFor", CassandraRequiredParam},
+	{"Required", CassandraRequiredParam},
+	{"Alive", CassandraAliveParam},
+	{"WriteType", CassandraWriteTypeParam},
+	{"DataPresent", CassandraDataPresentParam},
+}
+
+// TranslateCassandraError is a CauseTranslator which classifies errors returned by gocql. Read and write timeouts
+// become timeouts, unavailable coordinators become unavailable errors, and overloaded coordinators become rate
+// limited errors, so that all of them are retryable. The consistency level and replica counts reported by Cassandra
+// are recorded as params. Errors which it doesn't recognise are left alone. To enable it, register it with
+//
+//	terrors.RegisterCauseTranslator(terrors.TranslateCassandraError)
+//
+// gocql is not imported; errors are recognised by the shape of their methods and fields.
+func TranslateCassandraError(err error) (*Error, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	var reqErr cassandraRequestError
+	if errors.As(err, &reqErr) {
+		var code string
+		switch reqErr.Code() {
+		case cassandraCodeReadTimeout:
+			code = ErrCassandraReadTimeout
+		case cassandraCodeWriteTimeout:
+			code = ErrCassandraWriteTimeout
+		case cassandraCodeUnavailable:
+			code = ErrCassandraUnavailable
+		case cassandraCodeOverloaded:
+			code = ErrCassandraOverloaded
+		default:
+			return nil, false
+		}
+		return buildError(code, reqErr.Error(), cassandraParams(reqErr)), true
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if e.Error() == cassandraNoResponseMessage {
+			return buildError(ErrCassandraNoResponse, e.Error(), nil), true
+		}
+	}
+	return nil, false
+}
+
+// cassandraParams reads the consistency level and replica counts from the fields of a gocql request error.
+func cassandraParams(err error) map[string]string {
+	params := map[string]string{}
+	v := reflect.ValueOf(err)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return params
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return params
+	}
+	for _, f := range cassandraParamFields {
+		field := v.FieldByName(f.field)
+		if !field.IsValid() || !field.CanInterface() {
+			continue
+		}
+		params[f.param] = fmt.Sprint(field.Interface())
+	}
+	return params
+}
@@ -0,0 +1,138 @@
+package terrors
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The types below mirror the shape of gocql's request errors.
+
+type fakeConsistency uint16
+
+func (c fakeConsistency) String() string {
+	if c == 0x0006 {
+		return "LOCAL_QUORUM"
+	}
+	return fmt.Sprintf("UNKNOWN_CONS_0x%x", uint16(c))
+}
+
+type fakeErrorFrame struct {
+	code    int
+	message string
+}
+
+func (f fakeErrorFrame) Code() int       { return f.code }
+func (f fakeErrorFrame) Message() string { return f.message }
+func (f fakeErrorFrame) Error() string   { return f.message }
+
+type fakeReadTimeout struct {
+	fakeErrorFrame
+	Consistency fakeConsistency
+	Received    int
+	BlockFor    int
+	DataPresent byte
+}
+
+type fakeWriteTimeout struct {
+	fakeErrorFrame
+	Consistency fakeConsistency
+	Received    int
+	BlockFor    int
+	WriteType   string
+}
+
+type fakeUnavailable struct {
+	fakeErrorFrame
+	Consistency fakeConsistency
+	Required    int
+	Alive       int
+}
+
+func TestTranslateCassandraError(t *testing.T) {
+	t.Run("read timeout", func(t *testing.T) {
+		err := &fakeReadTimeout{
+			fakeErrorFrame: fakeErrorFrame{code: 0x1200, message: "Operation timed out"},
+			Consistency:    0x0006,
+			Received:       1,
+			BlockFor:       2,
+		}
+		terr, ok := TranslateCassandraError(err)
+		assert.True(t, ok)
+		assert.Equal(t, ErrCassandraReadTimeout, terr.Code)
+		assert.True(t, terr.Retryable())
+		assert.Equal(t, map[string]string{
+			CassandraConsistencyParam: "LOCAL_QUORUM",
+			CassandraReceivedParam:    "1",
+			CassandraRequiredParam:    "2",
+			CassandraDataPresentParam: "0",
+		}, terr.Params)
+	})
+	t.Run("write timeout", func(t *testing.T) {
+		err := &fakeWriteTimeout{
+			fakeErrorFrame: fakeErrorFrame{code: 0x1100, message: "Operation timed out"},
+			Consistency:    0x0006,
+			Received:       0,
+			BlockFor:       2,
+			WriteType:      "SIMPLE",
+		}
+		terr, ok := TranslateCassandraError(err)
+		assert.True(t, ok)
+		assert.Equal(t, ErrCassandraWriteTimeout, terr.Code)
+		assert.True(t, terr.Retryable())
+		assert.Equal(t, "SIMPLE", terr.Params[CassandraWriteTypeParam])
+		assert.Equal(t, "2", terr.Params[CassandraRequiredParam])
+	})
+	t.Run("unavailable", func(t *testing.T) {
+		err := fakeUnavailable{
+			fakeErrorFrame: fakeErrorFrame{code: 0x1000, message: "Cannot achieve consistency level"},
+			Consistency:    0x0006,
+			Required:       2,
+			Alive:          1,
+		}
+		terr, ok := TranslateCassandraError(err)
+		assert.True(t, ok)
+		assert.Equal(t, ErrCassandraUnavailable, terr.Code)
+		assert.True(t, terr.PrefixMatches(ErrUnavailable))
+		assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(terr))
+		assert.True(t, terr.Retryable())
+		assert.Equal(t, "1", terr.Params[CassandraAliveParam])
+		assert.Equal(t, "2", terr.Params[CassandraRequiredParam])
+	})
+	t.Run("overloaded", func(t *testing.T) {
+		terr, ok := TranslateCassandraError(fakeErrorFrame{code: 0x1001, message: "overloaded"})
+		assert.True(t, ok)
+		assert.Equal(t, ErrCassandraOverloaded, terr.Code)
+		assert.True(t, terr.Retryable())
+		assert.Empty(t, terr.Params)
+	})
+	t.Run("no response", func(t *testing.T) {
+		err := fmt.Errorf("querying: %w", fmt.Errorf(cassandraNoResponseMessage))
+		terr, ok := TranslateCassandraError(err)
+		assert.True(t, ok)
+		assert.Equal(t, ErrCassandraNoResponse, terr.Code)
+	})
+	t.Run("wrapped", func(t *testing.T) {
+		err := fmt.Errorf("querying: %w", fakeErrorFrame{code: 0x1200, message: "Operation timed out"})
+		terr, ok := TranslateCassandraError(err)
+		assert.True(t, ok)
+		assert.Equal(t, ErrCassandraReadTimeout, terr.Code)
+	})
+	t.Run("unrecognised", func(t *testing.T) {
+		_, ok := TranslateCassandraError(fakeErrorFrame{code: 0x2000, message: "syntax error"})
+		assert.False(t, ok)
+		_, ok = TranslateCassandraError(assert.AnError)
+		assert.False(t, ok)
+		_, ok = TranslateCassandraError(nil)
+		assert.False(t, ok)
+	})
+	t.Run("registered", func(t *testing.T) {
+		withCauseTranslator(t, TranslateCassandraError)
+		cause := &fakeReadTimeout{fakeErrorFrame: fakeErrorFrame{code: 0x1200, message: "Operation timed out"}}
+		err := Propagate(cause)
+		assert.True(t, Is(err, ErrCassandraReadTimeout))
+		assert.Equal(t, cause, err.(*Error).Unwrap())
+	})
+}
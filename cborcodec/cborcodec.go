@@ -0,0 +1,41 @@
+// Package cborcodec registers a terrors.Codec which encodes errors as CBOR under the name terrors.CodecCBOR.
+//
+// It is a separate package so that only programs which need CBOR depend on a CBOR library. Import it for its side
+// effects:
+//
+//	import _ "github.com/monzo/terrors/cborcodec"
+package cborcodec
+
+import (
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/monzo/terrors"
+	pe "github.com/monzo/terrors/proto"
+)
+
+func init() {
+	terrors.RegisterCodec(terrors.CodecCBOR, Codec{})
+}
+
+// Codec encodes errors as the CBOR form of pe.Error. Field names are the same as those of the JSON form.
+type Codec struct{}
+
+// Encode marshals e and encodes it as CBOR.
+func (Codec) Encode(e *terrors.Error) ([]byte, error) {
+	data, err := cbor.Marshal(terrors.Marshal(e))
+	if err != nil {
+		return nil, terrors.Augment(err, "failed to encode error as cbor", nil)
+	}
+	return data, nil
+}
+
+// Decode decodes a CBOR-encoded error and unmarshals it.
+func (Codec) Decode(data []byte) (*terrors.Error, error) {
+	p := &pe.Error{}
+	if err := cbor.Unmarshal(data, p); err != nil {
+		return nil, terrors.BadRequest("invalid_error_encoding", err.Error(), map[string]string{
+			"codec": terrors.CodecCBOR,
+		})
+	}
+	return terrors.Unmarshal(p), nil
+}
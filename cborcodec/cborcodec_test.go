@@ -0,0 +1,30 @@
+package cborcodec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monzo/terrors"
+)
+
+func TestCodec(t *testing.T) {
+	codec, ok := terrors.LookupCodec(terrors.CodecCBOR)
+	assert.True(t, ok)
+
+	orig := terrors.Augment(terrors.Timeout("downstream", "timed out", map[string]string{"foo": "bar"}), "calling", nil)
+	data, err := codec.Encode(orig.(*terrors.Error))
+	assert.NoError(t, err)
+
+	decoded, err := codec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "timeout.downstream", decoded.Code)
+	assert.Equal(t, "calling", decoded.Message)
+	assert.Equal(t, []string{"timed out"}, decoded.MessageChain)
+	assert.Equal(t, "bar", decoded.Params["foo"])
+	assert.True(t, decoded.Retryable())
+	assert.Equal(t, 1, decoded.MarshalCount)
+
+	_, err = codec.Decode([]byte{0xff, 0x00})
+	assert.True(t, terrors.Is(err, terrors.ErrInvalidEncoding))
+}
@@ -0,0 +1,65 @@
+package terrors
+
+import "github.com/monzo/terrors/stack"
+
+// Clone returns a deep copy of the error, which can be modified (e.g. to add per-request params) without racing with
+// other users of the original. The params, message and context chains, stack frames, flags and the list of details
+// are copied; the cause and the detail values are shared, as they aren't modified through the copy.
+func (p *Error) Clone() *Error {
+	if p == nil {
+		return nil
+	}
+
+	c := *p
+	if p.Params != nil {
+		c.Params = mergeParams(p.Params, nil)
+	}
+	if p.MessageChain != nil {
+		c.MessageChain = append([]string{}, p.MessageChain...)
+	}
+	if p.ContextChain != nil {
+		c.ContextChain = make([]ContextEntry, len(p.ContextChain))
+		for i, entry := range p.ContextChain {
+			if entry.Params != nil {
+				entry.Params = mergeParams(entry.Params, nil)
+			}
+			c.ContextChain[i] = entry
+		}
+	}
+	if p.StackFrames != nil {
+		c.StackFrames = make(stack.Stack, len(p.StackFrames))
+		for i, frame := range p.StackFrames {
+			if frame != nil {
+				f := *frame
+				frame = &f
+			}
+			c.StackFrames[i] = frame
+		}
+	}
+	if p.details != nil {
+		c.details = append([]interface{}{}, p.details...)
+	}
+	if p.IsRetryable != nil {
+		v := *p.IsRetryable
+		c.IsRetryable = &v
+	}
+	if p.IsUnexpected != nil {
+		v := *p.IsUnexpected
+		c.IsUnexpected = &v
+	}
+	if p.RetryAfter != nil {
+		v := *p.RetryAfter
+		c.RetryAfter = &v
+	}
+	return &c
+}
+
+// snapshot returns a shallow copy of the error, with its own copy of the params, for views which mustn't be affected by
+// later changes to the original.
+func (p *Error) snapshot() *Error {
+	s := *p
+	if p.Params != nil {
+		s.Params = mergeParams(p.Params, nil)
+	}
+	return &s
+}
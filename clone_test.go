@@ -0,0 +1,40 @@
+package terrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	cause := errors.New("boom")
+	orig := NewInternalWithCause(cause, "loading foo", map[string]string{"a": "1"}, "foo")
+	orig.ContextChain[0].Params = map[string]string{"b": "2"}
+	orig.SetIsUnexpected(true)
+
+	c := orig.Clone()
+	assert.Equal(t, orig, c)
+	assert.True(t, errors.Is(c, cause))
+
+	c.Params["a"] = "changed"
+	c.MessageChain[0] = "changed"
+	c.ContextChain[0].Params["b"] = "changed"
+	c.StackFrames[0].Line = -1
+	*c.IsRetryable = false
+	*c.IsUnexpected = false
+
+	assert.Equal(t, "1", orig.Params["a"])
+	assert.Equal(t, "boom", orig.MessageChain[0])
+	assert.Equal(t, "2", orig.ContextChain[0].Params["b"])
+	assert.NotEqual(t, -1, orig.StackFrames[0].Line)
+	assert.True(t, orig.Retryable())
+	assert.True(t, orig.Unexpected())
+	// The package-level flag values aren't modified
+	assert.True(t, InternalService("", "", nil).Retryable())
+}
+
+func TestCloneNil(t *testing.T) {
+	var err *Error
+	assert.Nil(t, err.Clone())
+}
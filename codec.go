@@ -0,0 +1,146 @@
+package terrors
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+// Names of the built-in codecs.
+const (
+	// CodecProto encodes errors as the protobuf wire format of pe.Error.
+	CodecProto = "proto"
+	// CodecJSON encodes errors as the JSON form of pe.Error.
+	CodecJSON = "json"
+	// CodecHeader encodes errors as unpadded, URL-safe base64 of the protobuf wire format, with the stack and the
+	// message and context chains stripped, so that the result is small enough to be carried in a header.
+	CodecHeader = "header"
+	// CodecCBOR encodes errors as CBOR. It is registered by importing github.com/monzo/terrors/cborcodec.
+	CodecCBOR = "cbor"
+)
+
+// ErrInvalidEncoding is the code of the error returned by the built-in codecs when data can't be decoded.
+const ErrInvalidEncoding = ErrBadRequest + ".invalid_error_encoding"
+
+// Codec encodes and decodes errors for a transport. Implementations are registered by name with RegisterCodec, so
+// that frameworks can negotiate the encoding of errors per transport.
+//
+// Encode is expected to increment the marshal count in the same way as Marshal, and Decode to restore the error in
+// the same way as Unmarshal.
+type Codec interface {
+	Encode(*Error) ([]byte, error)
+	Decode([]byte) (*Error, error)
+}
+
+var codecs = map[string]Codec{
+	CodecProto:  protoCodec{},
+	CodecJSON:   jsonCodec{},
+	CodecHeader: headerCodec{},
+}
+
+// RegisterCodec registers a codec under name, replacing any codec already registered under it. Passing a nil codec
+// removes the registration.
+func RegisterCodec(name string, codec Codec) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	if codec == nil {
+		delete(codecs, name)
+		return
+	}
+	codecs[name] = codec
+}
+
+// LookupCodec returns the codec registered under name.
+func LookupCodec(name string) (Codec, bool) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	codec, ok := codecs[name]
+	return codec, ok
+}
+
+// CodecNames returns the names of the registered codecs, sorted.
+func CodecNames() []string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type protoCodec struct{}
+
+func (protoCodec) Encode(e *Error) ([]byte, error) {
+	data, err := proto.Marshal(Marshal(e))
+	if err != nil {
+		return nil, Augment(err, "failed to encode error as proto", nil)
+	}
+	return data, nil
+}
+
+func (protoCodec) Decode(data []byte) (*Error, error) {
+	p := &pe.Error{}
+	if err := proto.Unmarshal(data, p); err != nil {
+		return nil, BadRequest("invalid_error_encoding", err.Error(), map[string]string{"codec": CodecProto})
+	}
+	return Unmarshal(p), nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(e *Error) ([]byte, error) {
+	data, err := json.Marshal(Marshal(e))
+	if err != nil {
+		return nil, Augment(err, "failed to encode error as json", nil)
+	}
+	return data, nil
+}
+
+func (jsonCodec) Decode(data []byte) (*Error, error) {
+	p := &pe.Error{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, BadRequest("invalid_error_encoding", err.Error(), map[string]string{"codec": CodecJSON})
+	}
+	return Unmarshal(p), nil
+}
+
+type headerCodec struct{}
+
+func (headerCodec) Encode(e *Error) ([]byte, error) {
+	p := Marshal(e)
+	compact := &pe.Error{
+		Code:         p.Code,
+		Message:      p.Message,
+		Params:       p.Params,
+		Retryable:    p.Retryable,
+		Unexpected:   p.Unexpected,
+		MarshalCount: p.MarshalCount,
+		RetryInfo:    p.RetryInfo,
+	}
+	data, err := proto.Marshal(compact)
+	if err != nil {
+		return nil, Augment(err, "failed to encode error as header", nil)
+	}
+	encoded := make([]byte, base64.RawURLEncoding.EncodedLen(len(data)))
+	base64.RawURLEncoding.Encode(encoded, data)
+	return encoded, nil
+}
+
+func (headerCodec) Decode(data []byte) (*Error, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, BadRequest("invalid_error_encoding", err.Error(), map[string]string{"codec": CodecHeader})
+	}
+	p := &pe.Error{}
+	if err := proto.Unmarshal(raw, p); err != nil {
+		return nil, BadRequest("invalid_error_encoding", err.Error(), map[string]string{"codec": CodecHeader})
+	}
+	return Unmarshal(p), nil
+}
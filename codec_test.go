@@ -0,0 +1,70 @@
+package terrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecs(t *testing.T) {
+	for _, name := range []string{CodecProto, CodecJSON} {
+		t.Run(name, func(t *testing.T) {
+			codec, ok := LookupCodec(name)
+			assert.True(t, ok)
+
+			orig := Augment(Timeout("downstream", "timed out", map[string]string{"foo": "bar"}), "calling", nil)
+			data, err := codec.Encode(orig.(*Error))
+			assert.NoError(t, err)
+
+			decoded, err := codec.Decode(data)
+			assert.NoError(t, err)
+			assert.Equal(t, "timeout.downstream", decoded.Code)
+			assert.Equal(t, "calling", decoded.Message)
+			assert.Equal(t, []string{"timed out"}, decoded.MessageChain)
+			assert.Equal(t, "bar", decoded.Params["foo"])
+			assert.True(t, decoded.Retryable())
+			assert.Equal(t, 1, decoded.MarshalCount)
+
+			_, err = codec.Decode([]byte("\xff not an error"))
+			assert.True(t, Is(err, ErrInvalidEncoding))
+		})
+	}
+
+	t.Run(CodecHeader, func(t *testing.T) {
+		codec, ok := LookupCodec(CodecHeader)
+		assert.True(t, ok)
+
+		orig := Augment(Timeout("downstream", "timed out", map[string]string{"foo": "bar"}), "calling", nil)
+		data, err := codec.Encode(orig.(*Error))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(data), "=")
+
+		decoded, err := codec.Decode(data)
+		assert.NoError(t, err)
+		assert.Equal(t, "timeout.downstream", decoded.Code)
+		assert.Equal(t, "calling", decoded.Message)
+		assert.Equal(t, "bar", decoded.Params["foo"])
+		assert.True(t, decoded.Retryable())
+		assert.Equal(t, 1, decoded.MarshalCount)
+		assert.Empty(t, decoded.StackFrames)
+		assert.Empty(t, decoded.MessageChain)
+
+		_, err = codec.Decode([]byte("!!!"))
+		assert.True(t, Is(err, ErrInvalidEncoding))
+	})
+}
+
+func TestRegisterCodec(t *testing.T) {
+	_, ok := LookupCodec("custom")
+	assert.False(t, ok)
+
+	RegisterCodec("custom", jsonCodec{})
+	_, ok = LookupCodec("custom")
+	assert.True(t, ok)
+	assert.Contains(t, CodecNames(), "custom")
+
+	RegisterCodec("custom", nil)
+	_, ok = LookupCodec("custom")
+	assert.False(t, ok)
+	assert.Equal(t, []string{CodecHeader, CodecJSON, CodecProto}, CodecNames())
+}
@@ -0,0 +1,125 @@
+package terrors
+
+// CodeOption configures a code registered with RegisterCode.
+type CodeOption func(*codeOptions)
+
+type codeOptions struct {
+	retryable  *bool
+	unexpected *bool
+}
+
+// CodeRetryable sets whether errors with the code (or a subcode of it) are retryable by default.
+func CodeRetryable(retryable bool) CodeOption {
+	return func(o *codeOptions) {
+		o.retryable = &retryable
+	}
+}
+
+// CodeUnexpected sets whether errors with the code (or a subcode of it) are unexpected by default.
+func CodeUnexpected(unexpected bool) CodeOption {
+	return func(o *codeOptions) {
+		o.unexpected = &unexpected
+	}
+}
+
+// registeredCodes holds the options of the codes registered with RegisterCode, and registeredCodeTrie holds the same
+// codes for lookups. They are guarded by configMu.
+var (
+	registeredCodes    = map[string]codeOptions{}
+	registeredCodeTrie = &codeTrie{}
+)
+
+// RegisterCode registers an organisation specific generic code, such as "degraded" or "quota_exceeded". The code is
+// added to GenericErrorCodes (so it is accepted by RequireCodePrefix, for example), and errors created with the code
+// or a subcode of it get the retryability and unexpectedness set by the options. Registering a code again replaces
+// its options. The retryability of the built in generic codes can be changed in the same way.
+//
+// Codes should be registered during program initialisation, as GenericErrorCodes is read without synchronisation.
+// When several registered codes match an error's code, the longest wins.
+func RegisterCode(code string, opts ...CodeOption) {
+	o := codeOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+	registeredCodes[code] = o
+	rebuildRegisteredCodeTrie()
+	for _, generic := range GenericErrorCodes {
+		if generic == code {
+			return
+		}
+	}
+	// Copy rather than append in place, so that callers holding the old slice don't see it change
+	codes := make([]string, len(GenericErrorCodes), len(GenericErrorCodes)+1)
+	copy(codes, GenericErrorCodes)
+	GenericErrorCodes = append(codes, code)
+}
+
+// currentGenericErrorCodes returns GenericErrorCodes, including any registered codes.
+func currentGenericErrorCodes() []string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return GenericErrorCodes
+}
+
+// registeredRetryability returns the default retryability of code set with RegisterCode, and the registered code
+// which set it.
+func registeredRetryability(code string) (string, bool, bool) {
+	registered, o, ok := longestRegisteredCode(code, func(o codeOptions) bool { return o.retryable != nil })
+	if !ok {
+		return "", false, false
+	}
+	return registered, *o.retryable, true
+}
+
+// registeredUnexpectedness returns the default unexpectedness of code set with RegisterCode.
+func registeredUnexpectedness(code string) (bool, bool) {
+	_, o, ok := longestRegisteredCode(code, func(o codeOptions) bool { return o.unexpected != nil })
+	if !ok {
+		return false, false
+	}
+	return *o.unexpected, true
+}
+
+// longestRegisteredCode returns the longest registered code which code starts with, whose options satisfy filter.
+func longestRegisteredCode(code string, filter func(codeOptions) bool) (string, codeOptions, bool) {
+	configMu.RLock()
+	trie := registeredCodeTrie
+	configMu.RUnlock()
+	entry, ok := trie.longestPrefix(code, filter)
+	return entry.code, entry.opts, ok
+}
+
+// rebuildRegisteredCodeTrie replaces registeredCodeTrie with a trie of registeredCodes. configMu must be held.
+func rebuildRegisteredCodeTrie() {
+	trie := &codeTrie{}
+	for code, o := range registeredCodes {
+		trie.insert(code, o)
+	}
+	registeredCodeTrie = trie
+}
+
+// setDefaultUnexpectedness sets the unexpectedness of err if its code was registered with an unexpectedness.
+func setDefaultUnexpectedness(err *Error) {
+	if value, ok := registeredUnexpectedness(err.Code); ok {
+		err.setUnexpected(value)
+	}
+}
+
+// setUnexpected sets the unexpectedness of p, for use while p is being built.
+func (p *Error) setUnexpected(value bool) {
+	if value {
+		p.IsUnexpected = &unexpected
+	} else {
+		p.IsUnexpected = &notUnexpected
+	}
+}
+
+// inheritUnexpected copies the unexpectedness of cause onto p, if it has been explicitly set.
+func (p *Error) inheritUnexpected(cause *Error) {
+	if cause.IsUnexpected != nil {
+		p.IsUnexpected = cause.IsUnexpected
+	}
+}
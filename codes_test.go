@@ -0,0 +1,69 @@
+package terrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withRegisteredCodes(t *testing.T) {
+	configMu.Lock()
+	previousCodes := make(map[string]codeOptions, len(registeredCodes))
+	for k, v := range registeredCodes {
+		previousCodes[k] = v
+	}
+	previousGeneric := GenericErrorCodes
+	configMu.Unlock()
+	t.Cleanup(func() {
+		configMu.Lock()
+		registeredCodes = previousCodes
+		rebuildRegisteredCodeTrie()
+		GenericErrorCodes = previousGeneric
+		configMu.Unlock()
+	})
+}
+
+func TestRegisterCode(t *testing.T) {
+	withRegisteredCodes(t)
+	RegisterCode("degraded", CodeRetryable(true), CodeUnexpected(true))
+	RegisterCode("quota_exceeded")
+	RegisterCode("degraded", CodeRetryable(true), CodeUnexpected(true))
+
+	assert.Equal(t, []string{"degraded", "quota_exceeded"}, GenericErrorCodes[len(GenericErrorCodes)-2:])
+
+	err := New("degraded.search", "search is degraded", nil)
+	assert.True(t, err.Retryable())
+	assert.True(t, err.Unexpected())
+	assert.True(t, Is(err, "degraded"))
+	assert.Equal(t,
+		`retryable: code "degraded.search" matches the retryable code "degraded"`, RetryabilityReason(err))
+
+	err = New("quota_exceeded.storage", "", nil)
+	assert.False(t, err.Retryable())
+	assert.False(t, err.Unexpected())
+
+	// Errors which weren't created by a constructor use the registration too
+	assert.True(t, (&Error{Code: "degraded"}).Retryable())
+	assert.True(t, (&Error{Code: "degraded"}).Unexpected())
+
+	// Registered codes are accepted as generic codes
+	_, hookErr := RequireCodePrefix("service.foo.")("quota_exceeded.bar")
+	assert.NoError(t, hookErr)
+}
+
+func TestRegisterCodeOverridesGeneric(t *testing.T) {
+	withRegisteredCodes(t)
+	RegisterCode("timeout.payment_provider", CodeRetryable(false))
+
+	genericCount := len(GenericErrorCodes)
+	RegisterCode(ErrRateLimited, CodeRetryable(false))
+	assert.Len(t, GenericErrorCodes, genericCount)
+
+	assert.True(t, Timeout("database", "", nil).Retryable())
+	err := Timeout("payment_provider", "", nil)
+	assert.False(t, err.Retryable())
+	assert.Equal(t,
+		`not retryable: code "timeout.payment_provider" matches the non-retryable code "timeout.payment_provider"`,
+		RetryabilityReason(err))
+	assert.False(t, RateLimited("", "", nil).Retryable())
+}
@@ -0,0 +1,130 @@
+package terrors
+
+import "sync"
+
+// codeTrie is a prefix tree of codes. It finds the codes which are prefixes of a given code in time proportional to
+// the length of that code, however many codes it holds, so classification stays cheap as the number of registered
+// codes grows. A codeTrie is immutable once built, so it may be read without a lock; changes build a new one.
+type codeTrie struct {
+	root codeTrieNode
+}
+
+type codeTrieNode struct {
+	children map[byte]*codeTrieNode
+	// terminal is set if a code ends at this node.
+	terminal bool
+	code     string
+	opts     codeOptions
+}
+
+// codeTrieEntry is a code held in a codeTrie, with its options.
+type codeTrieEntry struct {
+	code string
+	opts codeOptions
+}
+
+// newCodeTrie returns a trie holding codes, with no options.
+func newCodeTrie(codes []string) *codeTrie {
+	t := &codeTrie{}
+	for _, code := range codes {
+		t.insert(code, codeOptions{})
+	}
+	return t
+}
+
+// insert adds code to the trie, replacing its options if it's already held.
+func (t *codeTrie) insert(code string, opts codeOptions) {
+	node := &t.root
+	for i := 0; i < len(code); i++ {
+		child, ok := node.children[code[i]]
+		if !ok {
+			if node.children == nil {
+				node.children = map[byte]*codeTrieNode{}
+			}
+			child = &codeTrieNode{}
+			node.children[code[i]] = child
+		}
+		node = child
+	}
+	node.terminal, node.code, node.opts = true, code, opts
+}
+
+// prefixes calls fn with each code held in the trie which code starts with, shortest first, until fn returns false.
+func (t *codeTrie) prefixes(code string, fn func(codeTrieEntry) bool) {
+	if t == nil {
+		return
+	}
+	node := &t.root
+	for i := 0; ; i++ {
+		if node.terminal && !fn(codeTrieEntry{code: node.code, opts: node.opts}) {
+			return
+		}
+		if i == len(code) {
+			return
+		}
+		next, ok := node.children[code[i]]
+		if !ok {
+			return
+		}
+		node = next
+	}
+}
+
+// shortestPrefix returns the shortest code held in the trie which code starts with.
+func (t *codeTrie) shortestPrefix(code string) (string, bool) {
+	var found codeTrieEntry
+	ok := false
+	t.prefixes(code, func(e codeTrieEntry) bool {
+		found, ok = e, true
+		return false
+	})
+	return found.code, ok
+}
+
+// longestPrefix returns the longest code held in the trie which code starts with, whose options satisfy filter.
+func (t *codeTrie) longestPrefix(code string, filter func(codeOptions) bool) (codeTrieEntry, bool) {
+	var found codeTrieEntry
+	ok := false
+	t.prefixes(code, func(e codeTrieEntry) bool {
+		if filter(e.opts) {
+			found, ok = e, true
+		}
+		return true
+	})
+	return found, ok
+}
+
+// hasCodePrefixOf returns whether code is one of the codes held in the trie, or a subcode of one.
+func (t *codeTrie) hasCodePrefixOf(code string) bool {
+	matched := false
+	t.prefixes(code, func(e codeTrieEntry) bool {
+		matched = len(e.code) == len(code) || code[len(e.code)] == '.'
+		return !matched
+	})
+	return matched
+}
+
+// genericCodeTrie caches the trie of GenericErrorCodes. As GenericErrorCodes is exported, it may be replaced without
+// going through RegisterCode, so the trie is rebuilt whenever the slice it was built from changes.
+var genericCodeTrie struct {
+	sync.Mutex
+	codes []string
+	trie  *codeTrie
+}
+
+// currentGenericCodeTrie returns a trie of GenericErrorCodes, including any registered codes.
+func currentGenericCodeTrie() *codeTrie {
+	codes := currentGenericErrorCodes()
+	genericCodeTrie.Lock()
+	defer genericCodeTrie.Unlock()
+	if genericCodeTrie.trie == nil || !sameCodes(genericCodeTrie.codes, codes) {
+		genericCodeTrie.codes, genericCodeTrie.trie = codes, newCodeTrie(codes)
+	}
+	return genericCodeTrie.trie
+}
+
+// sameCodes returns whether a and b are the same slice. RegisterCode replaces GenericErrorCodes rather than modifying
+// it, so this detects registrations without comparing every code.
+func sameCodes(a, b []string) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
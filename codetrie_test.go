@@ -0,0 +1,91 @@
+package terrors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeTrie(t *testing.T) {
+	retryable, notRetryable := true, false
+	trie := newCodeTrie([]string{"timeout", "internal_service"})
+	trie.insert("timeout.upstream", codeOptions{retryable: &notRetryable})
+	trie.insert("timeout.upstream.slow", codeOptions{retryable: &retryable})
+	trie.insert("timeout.upstream.slow", codeOptions{retryable: &notRetryable})
+
+	code, ok := trie.shortestPrefix("timeout.upstream.slow")
+	assert.True(t, ok)
+	assert.Equal(t, "timeout", code)
+	_, ok = trie.shortestPrefix("not_found")
+	assert.False(t, ok)
+	// Matching is by string prefix, as for PrefixMatches
+	code, ok = trie.shortestPrefix("timeouts")
+	assert.True(t, ok)
+	assert.Equal(t, "timeout", code)
+
+	hasRetryability := func(o codeOptions) bool { return o.retryable != nil }
+	entry, ok := trie.longestPrefix("timeout.upstream.slow.db", hasRetryability)
+	assert.True(t, ok)
+	assert.Equal(t, "timeout.upstream.slow", entry.code)
+	assert.False(t, *entry.opts.retryable)
+	entry, ok = trie.longestPrefix("timeout.upstream", hasRetryability)
+	assert.True(t, ok)
+	assert.Equal(t, "timeout.upstream", entry.code)
+	_, ok = trie.longestPrefix("timeout.other", hasRetryability)
+	assert.False(t, ok)
+
+	// Subcodes are matched by dotted part
+	assert.True(t, trie.hasCodePrefixOf("timeout"))
+	assert.True(t, trie.hasCodePrefixOf("timeout.foo"))
+	assert.False(t, trie.hasCodePrefixOf("timeouts"))
+	assert.False(t, trie.hasCodePrefixOf("time"))
+	// As for hasCodePrefix, an empty code only matches itself
+	assert.False(t, newCodeTrie([]string{""}).hasCodePrefixOf("anything"))
+	assert.True(t, newCodeTrie([]string{""}).hasCodePrefixOf(""))
+
+	var nilTrie *codeTrie
+	_, ok = nilTrie.shortestPrefix("timeout")
+	assert.False(t, ok)
+}
+
+func TestGenericCodeTrie(t *testing.T) {
+	withRegisteredCodes(t)
+	assert.False(t, isKnownCode("degraded.cache"))
+
+	RegisterCode("degraded")
+	assert.True(t, isKnownCode("degraded.cache"))
+
+	// Replacing GenericErrorCodes directly is noticed
+	configMu.Lock()
+	GenericErrorCodes = []string{"only"}
+	configMu.Unlock()
+	assert.True(t, isKnownCode("only.this"))
+	assert.False(t, isKnownCode(ErrNotFound))
+}
+
+func BenchmarkClassification(b *testing.B) {
+	configMu.Lock()
+	previousCodes, previousGeneric := registeredCodes, GenericErrorCodes
+	registeredCodes = map[string]codeOptions{}
+	configMu.Unlock()
+	b.Cleanup(func() {
+		configMu.Lock()
+		registeredCodes = previousCodes
+		rebuildRegisteredCodeTrie()
+		GenericErrorCodes = previousGeneric
+		configMu.Unlock()
+	})
+	for i := 0; i < 500; i++ {
+		RegisterCode(fmt.Sprintf("organisation_code_%d", i), CodeRetryable(i%2 == 0))
+	}
+	err := New("organisation_code_499.subcode", "", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err.IsRetryable = nil
+		_ = err.Retryable()
+		_ = isKnownCode(err.Code)
+	}
+}
@@ -0,0 +1,108 @@
+package terrors
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/monzo/terrors/stack"
+)
+
+// CompactedLinksParam records how many links of a causal chain were collapsed by Compact.
+const CompactedLinksParam = "terrors_compacted_links"
+
+// minCompactDepth is the smallest chain Compact can produce: the outermost link, the summary and the root.
+const minCompactDepth = 3
+
+// Compact bounds the length of the causal chain of err, for example one which has been augmented in a retry loop.
+// If the chain has more than maxDepth links, the links in the middle are collapsed into a single summary link, so
+// that the outermost context and the root cause (with its stack) are preserved. The MessageChain and ContextChain of
+// each link are bounded in the same way, which keeps the size of the error on the wire bounded too.
+// err is not modified; a compacted copy is returned if necessary. Errors which are not terrors are returned as-is.
+// A maxDepth of less than 3 is treated as 3.
+func Compact(err error, maxDepth int) error {
+	terr, ok := err.(*Error)
+	if !ok || terr == nil {
+		return err
+	}
+	if maxDepth < minCompactDepth {
+		maxDepth = minCompactDepth
+	}
+	// A link is followed by at most this many chain entries
+	chainBudget := maxDepth - 1
+
+	var links []*Error
+	maxCausalDepth := CurrentMaxCausalDepth()
+	for next := terr; next != nil && len(links) <= maxCausalDepth; {
+		links = append(links, next)
+		next, _ = next.cause.(*Error)
+	}
+	if len(links) <= maxDepth && len(terr.MessageChain) <= chainBudget && len(terr.ContextChain) <= chainBudget {
+		return err
+	}
+
+	kept := links
+	var next error = links[len(links)-1].cause
+	if len(links) > maxDepth {
+		kept = links[:maxDepth-2]
+		root := links[len(links)-1]
+		replaced := links[len(kept)]
+		elided := len(links) - len(kept) - 1
+		next = &Error{
+			Code:         root.Code,
+			Message:      compactedMessage(elided),
+			Params:       map[string]string{CompactedLinksParam: strconv.Itoa(elided)},
+			StackFrames:  stack.Stack{},
+			IsRetryable:  replaced.IsRetryable,
+			IsUnexpected: replaced.IsUnexpected,
+			RetryAfter:   replaced.RetryAfter,
+			MarshalCount: replaced.MarshalCount,
+			MessageChain: compactMessageChain(append([]string{root.Message}, root.MessageChain...), chainBudget),
+			ContextChain: compactContextChain(append([]ContextEntry{root.contextEntry()}, root.ContextChain...), chainBudget),
+			cause:        root,
+
+			retryableReason: replaced.retryableReason,
+		}
+	}
+
+	// Rebuild the kept links from the inside out, so that each points at the copy of its cause
+	for i := len(kept) - 1; i >= 0; i-- {
+		link := *kept[i]
+		link.MessageChain = compactMessageChain(link.MessageChain, chainBudget)
+		link.ContextChain = compactContextChain(link.ContextChain, chainBudget)
+		link.cause = next
+		next = &link
+	}
+	return next
+}
+
+func compactedMessage(elided int) string {
+	return fmt.Sprintf("(%d links compacted)", elided)
+}
+
+// compactMessageChain bounds chain to budget entries, keeping the outermost entries and the root.
+func compactMessageChain(chain []string, budget int) []string {
+	if len(chain) <= budget {
+		return chain
+	}
+	keep := budget - 2
+	compacted := make([]string, 0, budget)
+	compacted = append(compacted, chain[:keep]...)
+	compacted = append(compacted, compactedMessage(len(chain)-keep-1), chain[len(chain)-1])
+	return compacted
+}
+
+// compactContextChain bounds chain to budget entries, keeping the outermost entries and the root.
+func compactContextChain(chain []ContextEntry, budget int) []ContextEntry {
+	if len(chain) <= budget {
+		return chain
+	}
+	keep := budget - 2
+	elided := len(chain) - keep - 1
+	compacted := make([]ContextEntry, 0, budget)
+	compacted = append(compacted, chain[:keep]...)
+	compacted = append(compacted, ContextEntry{
+		Message: compactedMessage(elided),
+		Params:  map[string]string{CompactedLinksParam: strconv.Itoa(elided)},
+	}, chain[len(chain)-1])
+	return compacted
+}
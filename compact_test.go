@@ -0,0 +1,76 @@
+package terrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func deepError(depth int) error {
+	var err error = failyFunction()
+	for i := 1; i < depth; i++ {
+		err = Augment(err, fmt.Sprintf("attempt %d", i), nil)
+	}
+	return err
+}
+
+func TestCompact(t *testing.T) {
+	t.Run("long chain", func(t *testing.T) {
+		err := deepError(10)
+		compacted := Compact(err, 4).(*Error)
+
+		assert.Equal(t, "attempt 9: attempt 8: (7 links compacted): I'm in trouble", compacted.ErrorMessage())
+		assert.Equal(t, []string{"attempt 8", "(7 links compacted)", "I'm in trouble"}, compacted.MessageChain)
+		assert.Len(t, compacted.ContextChain, 3)
+		assert.Equal(t, "7", compacted.ContextChain[1].Params[CompactedLinksParam])
+		assert.Equal(t, "internal_service.halp", compacted.ContextChain[2].Code)
+
+		// The root keeps its stack, and can still be matched
+		assert.Contains(t, compacted.StackString(), "failyFunction")
+		assert.True(t, Is(compacted, "internal_service.halp"))
+		assert.Equal(t, err.(*Error).Retryable(), compacted.Retryable())
+
+		// The original isn't modified
+		assert.Len(t, err.(*Error).MessageChain, 9)
+	})
+	t.Run("survives the wire", func(t *testing.T) {
+		compacted := Compact(deepError(10), 4).(*Error)
+		unmarshalled := Unmarshal(Marshal(compacted))
+		assert.Equal(t, compacted.MessageChain, unmarshalled.MessageChain)
+	})
+	t.Run("long chain from another service", func(t *testing.T) {
+		remote := Unmarshal(Marshal(deepError(10).(*Error)))
+		err := Augment(remote, "calling remote", nil)
+		compacted := Compact(err, 4).(*Error)
+
+		assert.Equal(t, "calling remote", compacted.Message)
+		assert.Equal(t, []string{"attempt 9", "(8 links compacted)", "I'm in trouble"}, compacted.MessageChain)
+		assert.Len(t, compacted.ContextChain, 3)
+		// The local chain was already short enough to be kept, but the chain of the remote link is compacted too
+		cause := compacted.Unwrap().(*Error)
+		assert.Equal(t, "attempt 9", cause.Message)
+		assert.Equal(t, []string{"attempt 8", "(7 links compacted)", "I'm in trouble"}, cause.MessageChain)
+	})
+	t.Run("short chain", func(t *testing.T) {
+		err := deepError(4)
+		assert.Same(t, err, Compact(err, 4))
+	})
+	t.Run("minimum depth", func(t *testing.T) {
+		compacted := Compact(deepError(10), 0).(*Error)
+		assert.Equal(t, "attempt 9: (8 links compacted): I'm in trouble", compacted.ErrorMessage())
+	})
+	t.Run("non-terror root", func(t *testing.T) {
+		var err error = errors.New("root")
+		for i := 0; i < 10; i++ {
+			err = Augment(err, fmt.Sprintf("attempt %d", i), nil)
+		}
+		compacted := Compact(err, 3).(*Error)
+		assert.Equal(t, "attempt 9: (8 links compacted): attempt 0: root", compacted.ErrorMessage())
+	})
+	t.Run("non-terror and nil", func(t *testing.T) {
+		assert.Equal(t, assert.AnError, Compact(assert.AnError, 3))
+		assert.Nil(t, Compact(nil, 3))
+	})
+}
@@ -0,0 +1,39 @@
+package terrors
+
+import (
+	"github.com/monzo/terrors/stack"
+)
+
+// WrapCompat behaves exactly as Wrap did before it gained causal chains: if `err` is already an `Error`, the params
+// are added to it; otherwise a new internal service error is created with the message of `err`, but `err` is not
+// kept as its cause, and neither its retryability nor any CauseTranslator is consulted.
+//
+// It exists so that call sites which depend on the old behaviour can be mechanically rewritten to use it before being
+// migrated to Augment or Propagate. Each call emits an EventWrapCompat event with the call site, so the remaining
+// call sites can be found and counted.
+func WrapCompat(err error, params map[string]string) error {
+	return wrapCompat(err, params, ErrInternalService)
+}
+
+// WrapWithCodeCompat behaves exactly as WrapWithCode did before it gained causal chains. See WrapCompat.
+func WrapWithCodeCompat(err error, params map[string]string, code string) error {
+	return wrapCompat(err, params, code)
+}
+
+func wrapCompat(err error, params map[string]string, code string) error {
+	if err == nil {
+		return nil
+	}
+	var result *Error
+	switch err := err.(type) {
+	case *Error:
+		result = addParams(err, params)
+	default:
+		result = buildError(code, err.Error(), params)
+		// Skip BuildStack(), wrapCompat() and the public function
+		result.StackFrames = stack.BuildStack(3)
+	}
+	// Skip the public function
+	emitEvent(EventWrapCompat, result.Code, 1)
+	return result
+}
@@ -0,0 +1,51 @@
+package terrors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapCompat(t *testing.T) {
+	t.Run("non-terror", func(t *testing.T) {
+		events := withEventHook(t)
+		// Translators and the retryability of the cause are ignored, as they were by the old Wrap
+		withCauseTranslator(t, func(err error) (*Error, bool) {
+			return NotFound("translated", "", nil), true
+		})
+		cause := &testRetryableError{false}
+
+		err := WrapCompat(cause, map[string]string{"foo": "bar"})
+		terr := err.(*Error)
+		assert.Equal(t, ErrInternalService, terr.Code)
+		assert.Equal(t, cause.Error(), terr.Message)
+		assert.Equal(t, "bar", terr.Params["foo"])
+		assert.True(t, terr.Retryable())
+		assert.Nil(t, terr.Unwrap())
+		assert.Contains(t, terr.StackFrames[0].Method, "TestWrapCompat")
+
+		assert.Len(t, *events, 1)
+		assert.Equal(t, EventWrapCompat, (*events)[0].Kind)
+		assert.Equal(t, ErrInternalService, (*events)[0].Code)
+		assert.Contains(t, (*events)[0].Function, "TestWrapCompat")
+	})
+	t.Run("terror", func(t *testing.T) {
+		events := withEventHook(t)
+		base := NotFound("foo", "bar", nil)
+		err := WrapWithCodeCompat(base, map[string]string{"foo": "bar"}, ErrBadRequest)
+		assert.Equal(t, "not_found.foo", err.(*Error).Code)
+		assert.Equal(t, "bar", err.(*Error).Params["foo"])
+		assert.Len(t, *events, 1)
+	})
+	t.Run("with code", func(t *testing.T) {
+		err := WrapWithCodeCompat(fmt.Errorf("boom"), nil, "bad_request.boom")
+		assert.Equal(t, "bad_request.boom", err.(*Error).Code)
+		assert.False(t, err.(*Error).Retryable())
+	})
+	t.Run("nil", func(t *testing.T) {
+		events := withEventHook(t)
+		assert.Nil(t, WrapCompat(nil, nil))
+		assert.Empty(t, *events)
+	})
+}
@@ -0,0 +1,266 @@
+package terrors
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// configMu guards the package level configuration below. Configuration is expected to be set once during program
+// initialisation, but may safely be changed at any time.
+var configMu sync.RWMutex
+
+// ParamLimits bounds the size of the params attached to errors. Limits are enforced whenever params are attached
+// to an error by this package, so that services can't accidentally attach very large values (e.g. whole request
+// bodies) which would overwhelm logging pipelines. A zero value for any limit means that it is not enforced.
+type ParamLimits struct {
+	// MaxParams is the maximum number of params on an error. When exceeded, params are kept in key order and the
+	// number of dropped params is recorded under ParamsDroppedParam.
+	MaxParams int `json:"max_params"`
+	// MaxKeyLength is the maximum length in bytes of a param key. Longer keys are truncated.
+	MaxKeyLength int `json:"max_key_length"`
+	// MaxValueLength is the maximum length in bytes of a param value. Longer values are truncated.
+	MaxValueLength int `json:"max_value_length"`
+}
+
+var paramLimits ParamLimits
+
+// SetParamLimits sets the limits enforced on params attached to errors. By default no limits are enforced.
+func SetParamLimits(limits ParamLimits) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	paramLimits = limits
+}
+
+// CurrentParamLimits returns the limits enforced on params attached to errors.
+func CurrentParamLimits() ParamLimits {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return paramLimits
+}
+
+// ErrorFormat selects how Error() renders an error.
+type ErrorFormat int
+
+const (
+	// ErrorFormatChained renders the code followed by the message of every error in the causal chain, e.g.
+	// `not_found.foo: looking up foo: failed to find foo`. This is the default.
+	ErrorFormatChained ErrorFormat = iota
+	// ErrorFormatLegacy renders only the code and the message of the outermost error, e.g.
+	// `not_found.foo: looking up foo`, as Error() did before causal chains were introduced. Services which
+	// match on Error() strings can opt into this while they migrate.
+	ErrorFormatLegacy
+)
+
+var errorFormat = ErrorFormatChained
+
+// SetErrorFormat sets the format used by Error() for every error in the process.
+func SetErrorFormat(format ErrorFormat) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	errorFormat = format
+}
+
+func currentErrorFormat() ErrorFormat {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return errorFormat
+}
+
+// LogMetadataMode selects which params LogMetadata returns.
+type LogMetadataMode int
+
+const (
+	// LogMetadataOuter returns only the params of the error itself. This is the default.
+	LogMetadataOuter LogMetadataMode = iota
+	// LogMetadataChain returns the params of every link in the context chain of the error, merged so that those of
+	// outer links win, so that params added at lower layers still reach the logs once the error has been augmented
+	// or wrapped.
+	LogMetadataChain
+)
+
+var logMetadataMode = LogMetadataOuter
+
+// SetLogMetadataMode sets which params LogMetadata returns for every error in the process.
+func SetLogMetadataMode(mode LogMetadataMode) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	logMetadataMode = mode
+}
+
+func currentLogMetadataMode() LogMetadataMode {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return logMetadataMode
+}
+
+const (
+	// DefaultMaxCausalDepth is the default for SetMaxCausalDepth.
+	DefaultMaxCausalDepth = 1024
+	// DefaultStackSizeLimit is the default for SetStackSizeLimit. 32,000 seems like a reasonable limit for a stack
+	// trace. Otherwise, we risk overwhelming downstream systems.
+	DefaultStackSizeLimit = 32000
+	// DefaultErrorMessageLimit is the default for SetErrorMessageLimit. It's well above the length of any sensible
+	// message, but keeps pathological chains from producing log lines which sinks truncate mid-character.
+	DefaultErrorMessageLimit = 8192
+)
+
+var (
+	maxCausalDepth    = DefaultMaxCausalDepth
+	stackSizeLimit    = DefaultStackSizeLimit
+	errorMessageLimit = DefaultErrorMessageLimit
+)
+
+// SetMaxCausalDepth sets the maximum number of links of a causal chain which are followed when rendering or
+// inspecting an error. If we run into this many causes, we've likely run into something absurd, like a self causing
+// error, so the limit guards against unbounded work. Values less than one restore the default.
+func SetMaxCausalDepth(depth int) {
+	if depth < 1 {
+		depth = DefaultMaxCausalDepth
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	maxCausalDepth = depth
+}
+
+// CurrentMaxCausalDepth returns the maximum number of links of a causal chain which are followed.
+func CurrentMaxCausalDepth() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return maxCausalDepth
+}
+
+// SetStackSizeLimit sets the maximum size in bytes of the output of StackString. Values less than one restore the
+// default.
+func SetStackSizeLimit(limit int) {
+	if limit < 1 {
+		limit = DefaultStackSizeLimit
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	stackSizeLimit = limit
+}
+
+// CurrentStackSizeLimit returns the maximum size in bytes of the output of StackString.
+func CurrentStackSizeLimit() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return stackSizeLimit
+}
+
+// SetErrorMessageLimit sets the maximum size in bytes of the message rendered by Error(), not counting the code and
+// the truncation marker. Values less than one restore the default.
+func SetErrorMessageLimit(limit int) {
+	if limit < 1 {
+		limit = DefaultErrorMessageLimit
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	errorMessageLimit = limit
+}
+
+// CurrentErrorMessageLimit returns the maximum size in bytes of the message rendered by Error().
+func CurrentErrorMessageLimit() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return errorMessageLimit
+}
+
+// DefaultRetryableCodes returns the codes which are retryable by default: internal_service, timeout, unknown,
+// rate_limited and unavailable.
+func DefaultRetryableCodes() []string {
+	return append([]string(nil), defaultRetryableCodes...)
+}
+
+// SetRetryableCodes sets the code prefixes which are retryable by default, replacing the current set. It changes the
+// retryability of errors created afterwards, and of errors whose retryability wasn't set when they were created (see
+// Retryable). Codes registered with RegisterCode take precedence. Passing nil restores the defaults.
+func SetRetryableCodes(codes []string) {
+	if codes == nil {
+		codes = defaultRetryableCodes
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	retryableCodes = append([]string(nil), codes...)
+	retryableCodeTrie = newCodeTrie(retryableCodes)
+}
+
+// AddRetryableCode adds a code prefix to the set which is retryable by default. See SetRetryableCodes.
+func AddRetryableCode(code string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	for _, c := range retryableCodes {
+		if c == code {
+			return
+		}
+	}
+	codes := make([]string, len(retryableCodes), len(retryableCodes)+1)
+	copy(codes, retryableCodes)
+	retryableCodes = append(codes, code)
+	retryableCodeTrie = newCodeTrie(retryableCodes)
+}
+
+// CurrentRetryableCodes returns a copy of the code prefixes which are retryable by default.
+func CurrentRetryableCodes() []string {
+	return append([]string(nil), currentRetryableCodes()...)
+}
+
+func currentRetryableCodes() []string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return retryableCodes
+}
+
+func currentRetryableCodeTrie() *codeTrie {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return retryableCodeTrie
+}
+
+// DefaultMaxRetryMarshalCount is the default for SetMaxRetryMarshalCount.
+const DefaultMaxRetryMarshalCount = 1
+
+var maxRetryMarshalCount = DefaultMaxRetryMarshalCount
+
+// SetMaxRetryMarshalCount sets the greatest MarshalCount of an error which ShouldRetryDownstream allows to be retried.
+// Negative values restore the default.
+func SetMaxRetryMarshalCount(count int) {
+	if count < 0 {
+		count = DefaultMaxRetryMarshalCount
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	maxRetryMarshalCount = count
+}
+
+// CurrentMaxRetryMarshalCount returns the greatest MarshalCount of an error which ShouldRetryDownstream allows to be
+// retried.
+func CurrentMaxRetryMarshalCount() int {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return maxRetryMarshalCount
+}
+
+var buildID string
+
+// SetBuildID sets the ID of the running binary, which is sent alongside program counters by WithProgramCounters so
+// that they can be symbolicated against the right binary. It is typically set from a value injected at build time,
+// e.g. a commit hash. If it is not set, the main module's path and version are used, if known.
+func SetBuildID(id string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	buildID = id
+}
+
+// CurrentBuildID returns the ID of the running binary. See SetBuildID.
+func CurrentBuildID() string {
+	configMu.RLock()
+	id := buildID
+	configMu.RUnlock()
+	if id != "" {
+		return id
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path != "" {
+		return info.Main.Path + "@" + info.Main.Version
+	}
+	return ""
+}
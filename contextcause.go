@@ -0,0 +1,58 @@
+package terrors
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// ContextCauseParam holds the message of the cause of a context's cancellation, as returned by context.Cause.
+	ContextCauseParam = "context_cause"
+	// ContextCauseCodeParam holds the code of the cause of a context's cancellation, if the cause is or wraps a
+	// terror.
+	ContextCauseCodeParam = "context_cause_code"
+	// ContextDeadlineParam holds the deadline of a context whose deadline was exceeded, in RFC 3339 format.
+	ContextDeadlineParam = "context_deadline"
+)
+
+// PropagateContext behaves like Propagate, but if err is (or wraps) context.Canceled or context.DeadlineExceeded, the
+// cause of ctx's cancellation is recorded under ContextCauseParam and ContextCauseCodeParam, so that "context
+// canceled" errors explain who canceled the context and why. Causes are only available from Go 1.20, and only for
+// contexts canceled with a cause (e.g. via context.WithCancelCause). If the deadline of ctx was exceeded, the
+// deadline is recorded under ContextDeadlineParam.
+func PropagateContext(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	terr := Propagate(err).(*Error)
+	if ctx == nil || !(errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return terr
+	}
+	params := contextCauseParams(ctx)
+	if deadline, ok := ctx.Deadline(); ok && errors.Is(err, context.DeadlineExceeded) {
+		if params == nil {
+			params = map[string]string{}
+		}
+		params[ContextDeadlineParam] = deadline.Format(time.RFC3339Nano)
+	}
+	if len(params) == 0 {
+		return terr
+	}
+	return addParams(terr, params)
+}
+
+// contextCauseParams returns params describing the cause of ctx's cancellation, or nil if it has no cause other than
+// the error returned by ctx.Err().
+func contextCauseParams(ctx context.Context) map[string]string {
+	cause := contextCause(ctx)
+	if cause == nil || cause == ctx.Err() {
+		return nil
+	}
+	params := map[string]string{ContextCauseParam: cause.Error()}
+	var terr *Error
+	if errors.As(cause, &terr) {
+		params[ContextCauseCodeParam] = terr.Code
+	}
+	return params
+}
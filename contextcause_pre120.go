@@ -0,0 +1,11 @@
+//go:build !go1.20
+// +build !go1.20
+
+package terrors
+
+import "context"
+
+// contextCause falls back to ctx.Err(), as context.Cause is not available before Go 1.20.
+func contextCause(ctx context.Context) error {
+	return ctx.Err()
+}
@@ -0,0 +1,62 @@
+//go:build go1.20
+// +build go1.20
+
+package terrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPropagateContext(t *testing.T) {
+	t.Run("terror cause", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(Unauthorized("session_expired", "session expired", nil))
+
+		err := PropagateContext(ctx, fmt.Errorf("calling foo: %w", ctx.Err()))
+		terr := err.(*Error)
+		assert.True(t, Is(terr, ErrInternalService))
+		assert.Equal(t, "unauthorized.session_expired: session expired", terr.Params[ContextCauseParam])
+		assert.Equal(t, "unauthorized.session_expired", terr.Params[ContextCauseCodeParam])
+		assert.True(t, errors.Is(terr, context.Canceled))
+	})
+	t.Run("plain cause", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(errors.New("shutting down"))
+
+		terr := PropagateContext(ctx, ctx.Err()).(*Error)
+		assert.Equal(t, "shutting down", terr.Params[ContextCauseParam])
+		assert.NotContains(t, terr.Params, ContextCauseCodeParam)
+	})
+	t.Run("no cause", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		terr := PropagateContext(ctx, ctx.Err()).(*Error)
+		assert.NotContains(t, terr.Params, ContextCauseParam)
+	})
+	t.Run("not a context error", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(errors.New("shutting down"))
+
+		terr := PropagateContext(ctx, errors.New("boom")).(*Error)
+		assert.NotContains(t, terr.Params, ContextCauseParam)
+	})
+	t.Run("deadline", func(t *testing.T) {
+		deadline := time.Now().Add(-time.Second)
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+
+		terr := PropagateContext(ctx, ctx.Err()).(*Error)
+		assert.Equal(t, ErrTimeout, terr.Code)
+		assert.Equal(t, deadline.Format(time.RFC3339Nano), terr.Params[ContextDeadlineParam])
+	})
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, PropagateContext(context.Background(), nil))
+	})
+}
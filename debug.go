@@ -0,0 +1,126 @@
+package terrors
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+var processTally *Tally
+
+// SetProcessTally installs a Tally which every error created by this package is recorded into, so that the errors a
+// process creates can be inspected with DebugHandler. Passing nil stops recording, which is the default.
+func SetProcessTally(t *Tally) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	processTally = t
+}
+
+// CurrentProcessTally returns the Tally installed with SetProcessTally, or nil if there isn't one.
+func CurrentProcessTally() *Tally {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return processTally
+}
+
+// DebugState is the document served by DebugHandler.
+type DebugState struct {
+	// Counts is the number of errors created for each code, if a Tally has been installed with SetProcessTally.
+	Counts map[string]int `json:"counts"`
+	// Total is the number of errors created, if a Tally has been installed with SetProcessTally.
+	Total int `json:"total"`
+	// GenericCodes are the well known generic error codes.
+	GenericCodes []string `json:"generic_codes"`
+	// Codecs are the names of the registered codecs.
+	Codecs []string `json:"codecs"`
+	// HookSamplingDrops is the number of hook calls dropped by sampling for each code, if SetHookSampling is in use.
+	HookSamplingDrops map[string]int `json:"hook_sampling_drops,omitempty"`
+	// Config is the current package configuration.
+	Config DebugConfig `json:"config"`
+}
+
+// DebugConfig describes the current package configuration.
+type DebugConfig struct {
+	ErrorFormat          string       `json:"error_format"`
+	LogMetadata          string       `json:"log_metadata"`
+	MaxCausalDepth       int          `json:"max_causal_depth"`
+	StackSizeLimit       int          `json:"stack_size_limit"`
+	ErrorMessageLimit    int          `json:"error_message_limit"`
+	ParamLimits          ParamLimits  `json:"param_limits"`
+	MaxRetryMarshalCount int          `json:"max_retry_marshal_count"`
+	RetryableCodes       []string     `json:"retryable_codes"`
+	BuildID              string       `json:"build_id"`
+	CauseTranslators     int          `json:"cause_translators"`
+	UnknownCodePolicy    bool         `json:"unknown_code_policy"`
+	CodeHook             bool         `json:"code_hook"`
+	EventHook            bool         `json:"event_hook"`
+	MatchHook            bool         `json:"match_hook"`
+	PanicHook            bool         `json:"panic_hook"`
+	HookSampling         HookSampling `json:"hook_sampling"`
+	SecretScanner        bool         `json:"secret_scanner"`
+	ProcessTally         bool         `json:"process_tally"`
+}
+
+// CurrentDebugState returns the error counters, registered codes and codecs, and configuration of the process.
+func CurrentDebugState() DebugState {
+	tally := CurrentProcessTally()
+	format := "chained"
+	if currentErrorFormat() == ErrorFormatLegacy {
+		format = "legacy"
+	}
+	logMetadata := "outer"
+	if currentLogMetadataMode() == LogMetadataChain {
+		logMetadata = "chain"
+	}
+
+	configMu.RLock()
+	translators := len(causeTranslators)
+	configMu.RUnlock()
+
+	return DebugState{
+		Counts:            tally.Counts(),
+		Total:             tally.Total(),
+		GenericCodes:      append([]string(nil), currentGenericErrorCodes()...),
+		Codecs:            CodecNames(),
+		HookSamplingDrops: HookSamplingDrops(),
+		Config: DebugConfig{
+			ErrorFormat:          format,
+			LogMetadata:          logMetadata,
+			MaxCausalDepth:       CurrentMaxCausalDepth(),
+			StackSizeLimit:       CurrentStackSizeLimit(),
+			ErrorMessageLimit:    CurrentErrorMessageLimit(),
+			ParamLimits:          CurrentParamLimits(),
+			MaxRetryMarshalCount: CurrentMaxRetryMarshalCount(),
+			RetryableCodes:       CurrentRetryableCodes(),
+			BuildID:              CurrentBuildID(),
+			CauseTranslators:     translators,
+			UnknownCodePolicy:    currentUnknownCodePolicy() != nil,
+			CodeHook:             currentCodeHook() != nil,
+			EventHook:            currentEventHook() != nil,
+			MatchHook:            currentMatchHook() != nil,
+			PanicHook:            currentPanicHook() != nil,
+			HookSampling:         CurrentHookSampling(),
+			SecretScanner:        currentSecretScanner() != nil,
+			ProcessTally:         tally != nil,
+		},
+	}
+}
+
+// DebugHandler returns an http.Handler which serves CurrentDebugState as JSON. It is intended to be mounted on a
+// service's debug port, so that its error behaviour can be inspected during incidents.
+func DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := json.MarshalIndent(CurrentDebugState(), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(body)
+	})
+}
@@ -0,0 +1,51 @@
+package terrors
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessTally(t *testing.T) {
+	tally := NewTally()
+	SetProcessTally(tally)
+	defer SetProcessTally(nil)
+
+	NotFound("foo", "foo not found", nil)
+	NotFound("foo", "foo not found", nil)
+	Propagate(errors.New("boom"))
+	Summary(errors.New("not recorded"))
+
+	assert.Equal(t, map[string]int{"not_found.foo": 2, ErrInternalService: 1}, tally.Counts())
+}
+
+func TestDebugHandler(t *testing.T) {
+	tally := NewTally()
+	SetProcessTally(tally)
+	defer SetProcessTally(nil)
+	Timeout("downstream", "timed out", nil)
+
+	rec := httptest.NewRecorder()
+	DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/terrors", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	state := DebugState{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.Equal(t, map[string]int{"timeout.downstream": 1}, state.Counts)
+	assert.Equal(t, 1, state.Total)
+	assert.Equal(t, GenericErrorCodes, state.GenericCodes)
+	assert.Contains(t, state.Codecs, CodecProto)
+	assert.Equal(t, "chained", state.Config.ErrorFormat)
+	assert.Equal(t, "outer", state.Config.LogMetadata)
+	assert.Equal(t, DefaultMaxCausalDepth, state.Config.MaxCausalDepth)
+	assert.True(t, state.Config.ProcessTally)
+
+	rec = httptest.NewRecorder()
+	DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/terrors", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
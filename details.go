@@ -0,0 +1,75 @@
+package terrors
+
+import (
+	"errors"
+	"reflect"
+)
+
+// AttachDetail returns a copy of err with a typed detail attached, such as the *pq.Error which caused it or a struct
+// describing a validation failure, so that callers further up can retrieve it with As. Details stay in the process:
+// like the cause, they are not marshalled. If err is not a terror, it is propagated first. It returns nil if err is
+// nil.
+func AttachDetail(err error, detail interface{}) error {
+	if err == nil {
+		return nil
+	}
+	terr := Propagate(err).(*Error)
+	withDetail := addParams(terr, nil)
+	withDetail.details = append(append([]interface{}{}, withDetail.details...), detail)
+	return withDetail
+}
+
+// Details returns the details attached to the error with AttachDetail, oldest first. It doesn't include the details
+// of its causes.
+func (p *Error) Details() []interface{} {
+	return append([]interface{}(nil), p.details...)
+}
+
+// As finds the first value in the chain of err which can be assigned to the value pointed to by target, and if one is
+// found, sets target to it and returns true. It behaves like errors.As, but also considers the details attached to
+// each terror in the chain (see AttachDetail), so target may be a pointer to any type, not only an error. The details
+// of a terror are considered after the terror itself, most recently attached first. The branches of errors with
+// several causes are searched in order.
+//
+// As panics if target is not a non-nil pointer.
+func As(err error, target interface{}) bool {
+	if target == nil {
+		panic("terrors: target cannot be nil")
+	}
+	val := reflect.ValueOf(target)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		panic("terrors: target must be a non-nil pointer")
+	}
+	return as(err, val.Elem(), CurrentMaxCausalDepth())
+}
+
+func as(err error, target reflect.Value, maxDepth int) bool {
+	for depth := 0; err != nil && depth < maxDepth; depth++ {
+		if reflect.TypeOf(err).AssignableTo(target.Type()) {
+			target.Set(reflect.ValueOf(err))
+			return true
+		}
+		if x, ok := err.(interface{ As(interface{}) bool }); ok && x.As(target.Addr().Interface()) {
+			return true
+		}
+		if terr, ok := err.(*Error); ok {
+			details := terr.Details()
+			for i := len(details) - 1; i >= 0; i-- {
+				if details[i] != nil && reflect.TypeOf(details[i]).AssignableTo(target.Type()) {
+					target.Set(reflect.ValueOf(details[i]))
+					return true
+				}
+			}
+		}
+		if multi, ok := err.(multiUnwrapper); ok {
+			for _, branch := range multi.Unwrap() {
+				if as(branch, target, maxDepth-depth-1) {
+					return true
+				}
+			}
+			return false
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}
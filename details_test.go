@@ -0,0 +1,87 @@
+package terrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type driverError struct {
+	Code string
+}
+
+func (e *driverError) Error() string {
+	return "driver error " + e.Code
+}
+
+type validationFailure struct {
+	Field  string
+	Reason string
+}
+
+func TestAttachDetail(t *testing.T) {
+	assert.Nil(t, AttachDetail(nil, validationFailure{}))
+
+	orig := BadRequest("invalid", "invalid request", nil)
+	err := AttachDetail(orig, validationFailure{Field: "amount", Reason: "negative"}).(*Error)
+	assert.Equal(t, []interface{}{validationFailure{Field: "amount", Reason: "negative"}}, err.Details())
+	assert.Equal(t, orig.Code, err.Code)
+	// The original is untouched
+	assert.Empty(t, orig.Details())
+
+	err = AttachDetail(errors.New("boom"), 42).(*Error)
+	assert.Equal(t, ErrInternalService, err.Code)
+	assert.Equal(t, []interface{}{42}, err.Details())
+}
+
+func TestAs(t *testing.T) {
+	cause := &driverError{Code: "23505"}
+	err := AttachDetail(Augment(cause, "inserting account", nil), validationFailure{Field: "email"})
+	err = Augment(fmt.Errorf("creating account: %w", err), "handling request", nil)
+
+	// Errors in the chain, as with errors.As
+	var driverErr *driverError
+	assert.True(t, As(err, &driverErr))
+	assert.Equal(t, cause, driverErr)
+
+	// Details attached to terrors in the chain
+	var failure validationFailure
+	assert.True(t, As(err, &failure))
+	assert.Equal(t, "email", failure.Field)
+
+	var missing *int
+	assert.False(t, As(err, &missing))
+	assert.False(t, As(nil, &failure))
+}
+
+func TestAsMostRecentDetailFirst(t *testing.T) {
+	err := AttachDetail(AttachDetail(NotFound("", "", nil), 1), 2)
+	var n int
+	assert.True(t, As(err, &n))
+	assert.Equal(t, 2, n)
+}
+
+func TestAsBranches(t *testing.T) {
+	err := NewInternalWithCause(multiError{errors.New("boom"), AttachDetail(NotFound("", "", nil), 7)}, "", nil, "")
+	var n int
+	assert.True(t, As(err, &n))
+	assert.Equal(t, 7, n)
+}
+
+func TestAsPanicsOnInvalidTarget(t *testing.T) {
+	assert.Panics(t, func() { As(assert.AnError, nil) })
+	assert.Panics(t, func() { As(assert.AnError, 1) })
+}
+
+func TestDetailsFromConstructors(t *testing.T) {
+	err := NewE(ErrBadRequest, "invalid", WithDetail(validationFailure{Field: "a"}))
+	assert.Equal(t, []interface{}{validationFailure{Field: "a"}}, err.Details())
+
+	b := Build(ErrBadRequest).Detail(1)
+	first := b.Err()
+	b.Detail(2)
+	assert.Equal(t, []interface{}{1}, first.Details())
+	assert.Equal(t, []interface{}{1, 2}, b.Err().Details())
+}
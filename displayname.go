@@ -0,0 +1,126 @@
+package terrors
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// displayNames maps language tags to the display names of codes in that language, and of codes below them. The empty
+// tag holds the names used when no language matches. Tags are lower case. It is guarded by configMu.
+var displayNames = map[string]map[string]string{}
+
+// RegisterDisplayName sets the human-readable name of errors whose code is code, or starts with code followed by a
+// dot, for use in responses to external clients (see UserFacingMessage and MarshalProblem), so that they can describe
+// the error without exposing the code. Where several codes match, the longest wins:
+//
+//	terrors.RegisterDisplayName(terrors.ErrNotFound, "Resource not found")
+//	terrors.RegisterDisplayName(terrors.ErrNotFound+".subscription", "Subscription not found")
+//
+// The name is used whatever the language of the client; use RegisterLocalizedDisplayName for names in a particular
+// language. Passing an empty name removes the registration.
+func RegisterDisplayName(code, name string) {
+	RegisterLocalizedDisplayName("", code, name)
+}
+
+// RegisterLocalizedDisplayName sets the display name of errors whose code is code, or starts with code followed by a
+// dot, for clients which accept the language with the given BCP 47 tag, e.g. "fr" or "pt-BR". A name registered for a
+// language (e.g. "pt") is also used for its regional variants (e.g. "pt-BR") which have no name of their own. Passing
+// an empty name removes the registration.
+func RegisterLocalizedDisplayName(lang, code, name string) {
+	lang = strings.ToLower(lang)
+	configMu.Lock()
+	defer configMu.Unlock()
+	if name == "" {
+		delete(displayNames[lang], code)
+		return
+	}
+	if displayNames[lang] == nil {
+		displayNames[lang] = map[string]string{}
+	}
+	displayNames[lang][code] = name
+}
+
+// DisplayName returns the display name of code registered with RegisterDisplayName or RegisterLocalizedDisplayName.
+// The languages are those accepted by the client, most preferred first; each is tried in turn, then the names which
+// aren't specific to a language. If no name is registered for the code, the text of its HTTP status is returned (e.g.
+// "Not Found"; see HTTPStatus).
+func DisplayName(code string, langs ...string) string {
+	if name, ok := registeredDisplayName(code, langs); ok {
+		return name
+	}
+	return http.StatusText(httpStatus(code))
+}
+
+// registeredDisplayName returns the display name registered for code in the first of langs which has one, or which
+// isn't specific to a language.
+func registeredDisplayName(code string, langs []string) (string, bool) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	for _, lang := range langs {
+		lang = strings.ToLower(lang)
+		if name, ok := displayNameIn(lang, code); ok {
+			return name, true
+		}
+		if i := strings.IndexByte(lang, '-'); i > 0 {
+			if name, ok := displayNameIn(lang[:i], code); ok {
+				return name, true
+			}
+		}
+	}
+	return displayNameIn("", code)
+}
+
+// displayNameIn returns the display name of code in lang. configMu must be held.
+func displayNameIn(lang, code string) (string, bool) {
+	name, matched := "", ""
+	for prefix, n := range displayNames[lang] {
+		if hasCodePrefix(code, prefix) && len(prefix) >= len(matched) {
+			name, matched = n, prefix
+		}
+	}
+	return name, name != ""
+}
+
+// UserFacingMessage returns a description of the error which is safe to show to external clients: the display name
+// of its code (see DisplayName) in the first of the given languages which has one. Unlike the message of the error, it
+// never describes the internals of the service.
+func (p *Error) UserFacingMessage(langs ...string) string {
+	return DisplayName(p.Code, langs...)
+}
+
+// acceptedLanguages returns the language tags of an Accept-Language header, most preferred first, honouring quality
+// values. Ties are broken by the order in the header, and the wildcard is left out.
+func acceptedLanguages(header string) []string {
+	type accepted struct {
+		lang string
+		q    float64
+	}
+	var langs []accepted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		lang := strings.TrimSpace(fields[0])
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			langs = append(langs, accepted{lang: lang, q: q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+	tags := make([]string, len(langs))
+	for i, l := range langs {
+		tags[i] = l.lang
+	}
+	return tags
+}
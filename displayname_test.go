@@ -0,0 +1,92 @@
+package terrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withDisplayNames clears the display names for the duration of the test.
+func withDisplayNames(t *testing.T) {
+	configMu.Lock()
+	previous := displayNames
+	displayNames = map[string]map[string]string{}
+	configMu.Unlock()
+	t.Cleanup(func() {
+		configMu.Lock()
+		displayNames = previous
+		configMu.Unlock()
+	})
+}
+
+func TestDisplayName(t *testing.T) {
+	withDisplayNames(t)
+	code := "not_found.subscription.missing"
+
+	// The status text is used by default
+	assert.Equal(t, "Not Found", DisplayName(code))
+
+	RegisterDisplayName(ErrNotFound, "Resource not found")
+	assert.Equal(t, "Resource not found", DisplayName(code))
+	assert.Equal(t, "Resource not found", NotFound("subscription.missing", "no rows", nil).UserFacingMessage())
+
+	// The longest code wins
+	RegisterDisplayName(ErrNotFound+".subscription", "Subscription not found")
+	assert.Equal(t, "Subscription not found", DisplayName(code))
+	assert.Equal(t, "Resource not found", DisplayName(ErrNotFound+".subscriptions"))
+
+	RegisterDisplayName(ErrNotFound+".subscription", "")
+	assert.Equal(t, "Resource not found", DisplayName(code))
+}
+
+func TestLocalizedDisplayName(t *testing.T) {
+	withDisplayNames(t)
+	RegisterDisplayName(ErrNotFound, "Resource not found")
+	RegisterLocalizedDisplayName("fr", ErrNotFound, "Ressource introuvable")
+	RegisterLocalizedDisplayName("pt-BR", ErrNotFound, "Recurso não encontrado")
+
+	testCases := []struct {
+		langs    []string
+		expected string
+	}{
+		{nil, "Resource not found"},
+		{[]string{"fr"}, "Ressource introuvable"},
+		{[]string{"fr-CA"}, "Ressource introuvable"},
+		{[]string{"PT-br"}, "Recurso não encontrado"},
+		{[]string{"pt"}, "Resource not found"},
+		{[]string{"de", "fr"}, "Ressource introuvable"},
+		{[]string{"de"}, "Resource not found"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, DisplayName(ErrNotFound+".account", tc.langs...), "%v", tc.langs)
+	}
+}
+
+func TestAcceptedLanguages(t *testing.T) {
+	assert.Equal(t, []string{"fr-CH", "fr", "en", "de"}, acceptedLanguages("fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5"))
+	assert.Equal(t, []string{"en", "de"}, acceptedLanguages("de;q=0.5, en, it;q=0"))
+	assert.Empty(t, acceptedLanguages(""))
+}
+
+func TestProblemTitle(t *testing.T) {
+	withDisplayNames(t)
+	RegisterDisplayName(ErrNotFound, "Resource not found")
+	RegisterLocalizedDisplayName("fr", ErrNotFound, "Ressource introuvable")
+	err := NotFound("subscription.missing", "no rows", nil)
+
+	assert.Equal(t, "Resource not found", MarshalProblem(err).Title)
+	assert.Equal(t, "Ressource introuvable", MarshalProblem(err, WithProblemLanguages("fr")).Title)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", ContentTypeProblem)
+	req.Header.Set("Accept-Language", "de, fr;q=0.8")
+	rec := httptest.NewRecorder()
+	WriteHTTPError(rec, req, err)
+
+	var p ProblemDetails
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, "Ressource introuvable", p.Title)
+}
@@ -0,0 +1,62 @@
+package terrors
+
+import (
+	"encoding/json"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+// ErrInvalidEnvelope is the code of the error returned by DecodeEnvelope when the payload can't be decoded.
+const ErrInvalidEnvelope = ErrBadRequest + ".invalid_envelope"
+
+// Envelope pairs a marshalled terror with the event which caused it, and is intended to be published to dead-letter
+// topics by asynchronous consumers, so that failures can be inspected and replayed in a consistent way.
+type Envelope struct {
+	// EventID is the ID of the event which failed to be processed.
+	EventID string `json:"event_id"`
+	// Attempt is the number of attempts which were made to process the event.
+	Attempt int `json:"attempt"`
+	// Error is the marshalled error returned by the final attempt.
+	Error *pe.Error `json:"error"`
+}
+
+// NewEnvelope returns an Envelope recording that the event with the given ID failed with err after `attempt`
+// attempts. If err is not a terror, it is propagated first.
+func NewEnvelope(eventID string, attempt int, err error) *Envelope {
+	var terr *Error
+	if err != nil {
+		terr = Propagate(err).(*Error)
+	}
+	return &Envelope{
+		EventID: eventID,
+		Attempt: attempt,
+		Error:   Marshal(terr),
+	}
+}
+
+// Terror returns the error in the envelope, unmarshalled.
+func (e *Envelope) Terror() *Error {
+	return Unmarshal(e.Error)
+}
+
+// EncodeEnvelope encodes env as JSON.
+func EncodeEnvelope(env *Envelope) ([]byte, error) {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return nil, Augment(err, "failed to encode error envelope", nil)
+	}
+	return data, nil
+}
+
+// DecodeEnvelope decodes an Envelope from JSON produced by EncodeEnvelope. If data is not a valid envelope, a
+// bad_request.invalid_envelope error is returned.
+func DecodeEnvelope(data []byte) (*Envelope, error) {
+	env := &Envelope{}
+	if err := json.Unmarshal(data, env); err != nil {
+		return nil, BadRequest("invalid_envelope", err.Error(), nil)
+	}
+	if env.Error == nil {
+		return nil, BadRequest("invalid_envelope", "envelope has no error", nil)
+	}
+	return env, nil
+}
@@ -0,0 +1,45 @@
+package terrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		err := Augment(Timeout("downstream", "timed out", map[string]string{"foo": "bar"}), "processing event", nil)
+		data, encErr := EncodeEnvelope(NewEnvelope("evt_123", 3, err))
+		assert.NoError(t, encErr)
+
+		env, decErr := DecodeEnvelope(data)
+		assert.NoError(t, decErr)
+		assert.Equal(t, "evt_123", env.EventID)
+		assert.Equal(t, 3, env.Attempt)
+
+		terr := env.Terror()
+		assert.Equal(t, "timeout.downstream", terr.Code)
+		assert.Equal(t, "bar", terr.Params["foo"])
+		assert.Equal(t, "processing event", terr.Message)
+		assert.Equal(t, []string{"timed out"}, terr.MessageChain)
+		assert.True(t, terr.Retryable())
+		assert.Equal(t, 1, terr.MarshalCount)
+	})
+	t.Run("non-terror", func(t *testing.T) {
+		env := NewEnvelope("evt_123", 1, assert.AnError)
+		terr := env.Terror()
+		assert.True(t, Is(terr, ErrInternalService))
+		assert.Equal(t, assert.AnError.Error(), terr.Message)
+	})
+	t.Run("nil", func(t *testing.T) {
+		env := NewEnvelope("evt_123", 1, nil)
+		assert.Equal(t, ErrUnknown, env.Terror().Code)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := DecodeEnvelope([]byte("not json"))
+		assert.True(t, Is(err, ErrInvalidEnvelope))
+
+		_, err = DecodeEnvelope([]byte(`{"event_id": "evt_123"}`))
+		assert.True(t, Is(err, ErrInvalidEnvelope))
+	})
+}
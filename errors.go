@@ -21,15 +21,20 @@
 package terrors
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/monzo/terrors/stack"
 )
 
 // Generic error codes. Each of these has their own constructor for convenience.
 // You can use any string as a code, just use the `New` method.
-// Warning: any new generic error code must be added to GenericErrorCodes.
+// Warning: any new generic error code must be added to GenericErrorCodes, and its constructor to ErrorNamespace and
+// kv.go.
 const (
 	ErrBadRequest         = "bad_request"
 	ErrBadResponse        = "bad_response"
@@ -41,9 +46,12 @@ const (
 	ErrUnauthorized       = "unauthorized"
 	ErrUnknown            = "unknown"
 	ErrRateLimited        = "rate_limited"
+	ErrConflict           = "conflict"
+	ErrNotImplemented     = "not_implemented"
+	ErrUnavailable        = "unavailable"
 )
 
-// GenericErrorCodes is a list of all well known generic error codes.
+// GenericErrorCodes is a list of all well known generic error codes. Further codes can be added with RegisterCode.
 var GenericErrorCodes = []string{
 	ErrBadRequest,
 	ErrBadResponse,
@@ -55,16 +63,33 @@ var GenericErrorCodes = []string{
 	ErrUnauthorized,
 	ErrUnknown,
 	ErrRateLimited,
+	ErrConflict,
+	ErrNotImplemented,
+	ErrUnavailable,
 }
 
-var retryableCodes = []string{
+// defaultRetryableCodes are the codes which are retryable by default. See SetRetryableCodes.
+var defaultRetryableCodes = []string{
 	ErrInternalService,
 	ErrTimeout,
 	ErrUnknown,
 	ErrRateLimited,
+	ErrUnavailable,
 }
 
+// retryableCodes and retryableCodeTrie, which holds the same codes, are guarded by configMu. They are replaced rather
+// than modified, so they may be read after the lock is released.
+var (
+	retryableCodes    = defaultRetryableCodes
+	retryableCodeTrie = newCodeTrie(defaultRetryableCodes)
+)
+
 // Error is terror's error. It implements Go's error interface.
+//
+// Errors should be treated as immutable once they have been shared, e.g. returned or handed to another goroutine: the
+// functions of this package never modify an error they are given, but return a modified copy (see Augment and Clone),
+// so shared errors can be read, marshalled and augmented concurrently. SetIsRetryable and SetIsUnexpected are only for
+// use while an error is being built.
 type Error struct {
 	Code        string            `json:"code"`
 	Message     string            `json:"message"`
@@ -77,16 +102,38 @@ type Error struct {
 	// Exported for serialization, but you should use Unexpected to read the value.
 	IsUnexpected *bool `json:"is_unexpected"`
 
+	// RetryAfter is how long the client should wait before retrying, if the server knows (e.g. for rate limited
+	// errors). Use the RetryAfter function to read it, as it also considers the causes of the error and the
+	// RetryAfterParam param. It is sent across process boundaries in the structured retry info.
+	RetryAfter *time.Duration `json:"retry_after,omitempty"`
+
 	// Incremented each time the error is marshalled so that we can tell (approximately) how many services the error
 	// has propagated through.  Higher level code can use this to influence decisions, for example it may only be
 	// desirable to retry on an error that's only been marshalled once to avoid retries on top of retries... ad nauseam
+	// ShouldRetryDownstream implements this check.
 	MarshalCount int `json:"marshal_count"`
 
 	// When errors are marshalled certain information is lost (e.g. the 'cause').  This means if an error travels through
 	// a number of services (and it's potentially augmented at each hop) that the core error message may be lost. The
 	// history of an error is often a helpful debugging aid, so MessageChain is used to track this.
+	// It is superseded by ContextChain, which also records the code and params of each link, but is still populated
+	// for compatibility with services which only understand MessageChain.
 	MessageChain []string `json:"message_chain"`
 
+	// ContextChain records the code, message and params of each link in the causal chain of this error, outermost
+	// first, in the same order as MessageChain. Unlike the cause, it is sent across process boundaries, so the
+	// params added by each Augment aren't lost when an error travels through several services.
+	ContextChain []ContextEntry `json:"context_chain"`
+
+	// StackBuildID identifies the binary which produced the program counters in StackFrames, for errors which were
+	// unmarshalled from another service which sent them (see WithProgramCounters). It is empty for stacks which were
+	// captured in this process.
+	StackBuildID string `json:"stack_build_id,omitempty"`
+
+	// StackOmitted is set on errors which were unmarshalled from another service which omitted the stack (see
+	// OmitStack), so that an empty stack isn't mistaken for a missing capture.
+	StackOmitted bool `json:"stack_omitted,omitempty"`
+
 	// Cause is the initial cause of this error, and will be populated
 	// when using the Propagate function. This is intentionally not exported
 	// so that we don't serialize causes and send them across process boundaries.
@@ -94,42 +141,60 @@ type Error struct {
 	// should not expect it to contain information about terrors from other downstream
 	// processes.
 	cause error
+
+	// createdAt is when the error was constructed in this process. It is not serialized, and is zero for errors
+	// which were unmarshalled or built directly as struct literals.
+	createdAt time.Time
+
+	// retryableReason records why IsRetryable was set, for RetryabilityReason.
+	retryableReason retryabilityReason
+
+	// details are typed values attached with AttachDetail, for retrieval with As. Like the cause, they are not
+	// serialized.
+	details []interface{}
 }
 
 // Error returns a string message of the error.
 // It will contain the code and error message. If there is a causal chain, the
 // message from each error in the chain will be added to the output.
+// The format can be changed for the whole process with SetErrorFormat. If you need a rendering which is guaranteed
+// not to change, use ShortString instead.
 func (p *Error) Error() string {
-	if p.cause == nil {
+	if p.cause == nil || currentErrorFormat() == ErrorFormatLegacy {
 		// Not sure if the empty code/message cases actually happen, but to be safe, defer to
 		// the 'old' error message if there is no cause present (i.e. we're not using
 		// new wrapping functionality)
 		return p.legacyErrString()
 	}
-	return fmt.Sprintf("%s: %s", p.Code, p.ErrorMessage())
+	return fmt.Sprintf("%s: %s", p.Code, p.ErrorMessageWithLimit(CurrentErrorMessageLimit()))
 }
 
 // ErrorMessage returns a string message of the error.
 // It will contain the error message, but not the code. If there is a causal
 // chain, the message from each error in the chain will be added to the output.
+// If a cause has several causes of its own (e.g. it was created with errors.Join),
+// each branch is numbered, in the form `2 errors: [1] first; [2] second`.
 func (p *Error) ErrorMessage() string {
 	output := strings.Builder{}
-	output.WriteString(p.Message)
-	var next error = p.cause
-	for next != nil {
-		output.WriteString(": ")
-		switch typed := next.(type) {
-		case *Error:
-			output.WriteString(typed.Message)
-			next = typed.cause
-		case error:
-			output.WriteString(typed.Error())
-			next = nil
-		}
-	}
+	writeMessage(&output, p, CurrentMaxCausalDepth(), 0)
 	return output.String()
 }
 
+// ErrorMessageWithLimit behaves like ErrorMessage, but the output is cut to at most max bytes, followed by a marker,
+// if it's longer. The cut never splits a UTF-8 sequence. A max of zero or less means no limit.
+// Error() uses this with the limit set by SetErrorMessageLimit.
+func (p *Error) ErrorMessageWithLimit(max int) string {
+	return truncate(p.ErrorMessage(), max)
+}
+
+// ShortString returns the code and message of the error, without the messages from the causal chain, in the form
+// `code: message`. If either is empty, only the other is returned.
+// Unlike Error(), the output of ShortString is guaranteed not to change across versions of this package or with
+// configuration, so it is safe to match against in monitoring and tests.
+func (p *Error) ShortString() string {
+	return p.legacyErrString()
+}
+
 func (p *Error) legacyErrString() string {
 	if p == nil {
 		return ""
@@ -148,6 +213,38 @@ func (p *Error) Unwrap() error {
 	return p.cause
 }
 
+// Is reports whether the error matches target for errors.Is: it does if target is a terror with a code, and the code
+// of this error starts with it, as with PrefixMatches. This lets package level sentinel terrors be matched with the
+// standard library idiom, along the whole chain:
+//
+//	var ErrConfigNotFound = terrors.NotFound("config", "config not found", nil)
+//
+//	if errors.Is(err, ErrConfigNotFound) {
+//
+// The message and params of target are ignored.
+func (p *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t == nil || t.Code == "" {
+		return false
+	}
+	return p.PrefixMatches(t.Code)
+}
+
+// Cause returns the error which caused this one in this process, as set by Augment, Propagate or
+// NewInternalWithCause. It may be nil. It returns the same value as Unwrap, and is provided for libraries which
+// follow the `Cause() error` convention (e.g. github.com/pkg/errors) rather than errors.Unwrap.
+//
+// errors.Cause from github.com/pkg/errors follows Cause until it reaches an error without one, so it returns the
+// innermost error which isn't a terror. If the innermost error is a terror, its Cause is nil, and so is the result
+// of errors.Cause; walk the chain with errors.Unwrap instead if that matters.
+//
+// The cause is never sent across process boundaries: an error which was unmarshalled has no cause, even if it had one
+// in the service which sent it. What remains of the causal chain of such an error is recorded in its ContextChain and
+// MessageChain instead.
+func (p *Error) Cause() error {
+	return p.cause
+}
+
 // StackTrace returns a slice of program counters taken from the stack frames.
 // This adapts the terrors package to allow stacks to be reported to Sentry correctly.
 func (p *Error) StackTrace() []uintptr {
@@ -160,43 +257,74 @@ func (p *Error) StackTrace() []uintptr {
 
 // StackString formats the stacks from the terror chain as a string. If we
 // encounter more than one terror in the chain with a stack frame, we'll print
-// each one, separated by three hyphens on their own line.
+// each one, separated by three hyphens on their own line. The stacks of terrors
+// in each branch of a multi-cause error are printed after a numbered, indented
+// header.
+// The size of the output is limited by SetStackSizeLimit.
 func (p *Error) StackString() string {
-	// 32,000 seems like a reasonable limit for a stack trace. Otherwise, we risk
-	// overwhelming downstream systems.
-	return StackStringWithMaxSize(p, 32000)
+	return StackStringWithMaxSize(p, CurrentStackSizeLimit())
 }
 
+// StackStringWithMaxSize behaves like StackString, but limits the output to sizeLimit bytes.
 func StackStringWithMaxSize(p *Error, sizeLimit int) string {
-	// if we run into this many causes, we've likely run into something absurd. Like
-	// a self causing error.
-	const maxCausalDepth = 1024
 	var buffer strings.Builder
-	terr := p
+	writeStacks(&buffer, p, sizeLimit, CurrentMaxCausalDepth(), "", 0)
+	return buffer.String()
+}
+
+// writeStacks writes the stacks of the terror chain starting at terr to buffer, with each line indented by indent.
+// It returns false if the size limit was reached.
+// At most maxCausalDepth causes are followed.
+func writeStacks(buffer *strings.Builder, terr *Error, sizeLimit, maxCausalDepth int, indent string, branchDepth int) bool {
 	var causalDepth int
-outer:
+	start := buffer.Len()
 	for terr != nil {
-		if buffer.Len() != 0 && len(terr.StackFrames) > 0 {
-			fmt.Fprintf(&buffer, "\n---")
+		if buffer.Len() != start && len(terr.StackFrames) > 0 {
+			fmt.Fprintf(buffer, "\n%s---", indent)
 		}
 		for _, frame := range terr.StackFrames {
 			// 10 seems like a reasonable estimate of how large the rest of the line would be.
-			estimatedLineLen := len(frame.Filename) + len(frame.Method) + 16
+			estimatedLineLen := len(indent) + len(frame.Filename) + len(frame.Method) + 16
 			if estimatedLineLen+buffer.Len() > sizeLimit {
-				break outer
+				return false
 			}
-			fmt.Fprintf(&buffer, "\n  %s:%d in %s", frame.Filename, frame.Line, frame.Method)
+			fmt.Fprintf(buffer, "\n%s  %s:%d in %s", indent, frame.Filename, frame.Line, frame.Method)
 		}
 
-		if tcause, ok := terr.cause.(*Error); ok && causalDepth < maxCausalDepth {
-			terr = tcause
+		switch cause := terr.cause.(type) {
+		case *Error:
+			if causalDepth >= maxCausalDepth {
+				return true
+			}
+			terr = cause
 			causalDepth += 1
-		} else {
-			break outer
+		case multiUnwrapper:
+			if branchDepth >= maxBranchDepth {
+				return true
+			}
+			for i, branch := range nonNilErrors(cause.Unwrap()) {
+				if i == maxRenderedBranches {
+					break
+				}
+				bterr, ok := branch.(*Error)
+				if !ok {
+					continue
+				}
+				header := fmt.Sprintf("\n%s--- [%d]", indent, i+1)
+				if len(header)+buffer.Len() > sizeLimit {
+					return false
+				}
+				buffer.WriteString(header)
+				if !writeStacks(buffer, bterr, sizeLimit, maxCausalDepth, indent+"  ", branchDepth+1) {
+					return false
+				}
+			}
+			return true
+		default:
+			return true
 		}
 	}
-
-	return buffer.String()
+	return true
 }
 
 // VerboseString returns the error message, stack trace and params
@@ -209,12 +337,8 @@ func (p *Error) Retryable() bool {
 	if p.IsRetryable != nil {
 		return *p.IsRetryable
 	}
-	for _, c := range retryableCodes {
-		if PrefixMatches(p, c) {
-			return true
-		}
-	}
-	return false
+	_, ok := retryableCodePrefix(p)
+	return ok
 }
 
 // Unexpected states whether an error is not expected to occur. In many cases this will be due to a bug, e.g. due to a
@@ -224,22 +348,21 @@ func (p *Error) Unexpected() bool {
 	if p.IsUnexpected != nil {
 		return *p.IsUnexpected
 	}
-
-	return false
+	unexpected, _ := registeredUnexpectedness(p.Code)
+	return unexpected
 }
 
+// SetIsRetryable explicitly marks an error as retryable or not. It must not be called once the error has been shared
+// with other goroutines; use a Clone instead.
 func (p *Error) SetIsRetryable(value bool) {
-	if value {
-		p.IsRetryable = &retryable
-	} else {
-		p.IsRetryable = &notRetryable
-	}
+	p.setRetryable(value, retryabilityReason{kind: retryabilityExplicit})
 }
 
 // SetIsUnexpected can be used to explicitly mark an error as unexpected or not. In practice the vast majority of
 // code should not need to use this. An example use case might be when returning a validation error that must
 // mean there is a coding mistake somewhere (e.g. default statement in a switch that is never expected to be
 // taken). By marking the error as unexpected there is a greater chance that an alert will be sent.
+// Like SetIsRetryable, it must not be called once the error has been shared with other goroutines.
 func (p *Error) SetIsUnexpected(value bool) {
 	if value {
 		p.IsUnexpected = &unexpected
@@ -251,8 +374,26 @@ func (p *Error) SetIsUnexpected(value bool) {
 // LogMetadata implements the logMetadataProvider interface in the slog library which means that
 // the error params will automatically be merged with the slog metadata.
 // Additionally we put stack data in here for slog use.
+// If a SecretScanner is installed, the params are returned with secrets masked.
+// With SetLogMetadataMode(LogMetadataChain), the params of the whole context chain are returned, with those of outer
+// links taking precedence.
 func (p *Error) LogMetadata() map[string]string {
-	return p.Params
+	scanner := currentSecretScanner()
+	if currentLogMetadataMode() != LogMetadataChain {
+		return scanner.maskParams(p.Code, p.Params)
+	}
+
+	merged := map[string]string{}
+	for i := len(p.ContextChain) - 1; i >= 0; i-- {
+		entry := p.ContextChain[i]
+		for k, v := range scanner.maskParamsField(entry.Code, "context_chain.params.", entry.Params) {
+			merged[k] = v
+		}
+	}
+	for k, v := range scanner.maskParams(p.Code, p.Params) {
+		merged[k] = v
+	}
+	return merged
 }
 
 // New creates a new error for you. Use this if you want to pass along a custom error code.
@@ -261,67 +402,237 @@ func New(code string, message string, params map[string]string) *Error {
 	return errorFactory(code, message, params)
 }
 
+// NewFromPCs creates a new error with a stack built from the given program counters, rather than the current call
+// stack. Use this when a stack has already been captured (e.g. by runtime.Callers in a panic handler), so that the
+// error points at where the problem happened rather than where the error was constructed.
+func NewFromPCs(code string, message string, pcs []uintptr, params map[string]string) *Error {
+	err := buildError(code, message, params)
+	err.StackFrames = stack.BuildStackFromPCs(pcs)
+	return err
+}
+
 // NewInternalWithCause creates a new Terror from an existing error.
 // The new error will always have the code `ErrInternalService`. The original
 // error is attached as the `cause`, and can be tested with the `Is` function.
+// The retryability and unexpectedness of the cause are inherited, if they were
+// set (or, for errors which aren't terrors, if they implement `Retryable() bool`
+// or `Unexpected() bool`).
 // You probably want to use the `Augment` func instead;
 // only use this if you need to set a subcode on an error.
 func NewInternalWithCause(err error, message string, params map[string]string, subCode string) *Error {
 	newErr := errorFactory(errCode(ErrInternalService, subCode), message, params)
-	newErr.cause = err
+	newErr.attachCause(err)
+	return newErr
+}
+
+// newWithCause behaves like NewInternalWithCause, but gives the new error the code err would be given by Propagate
+// (see causeCode).
+func newWithCause(err error, message string, params map[string]string) *Error {
+	newErr := errorFactory(causeCode(err), message, params)
+	newErr.attachCause(err)
+	return newErr
+}
+
+// causeCode returns the code given to a new error created from err, which isn't a terror: errors with several causes
+// get the code shared by their branches (see Join), errors with a code of their own (see codedError) keep it, errors
+// from contexts whose deadline was exceeded become timeouts, so that they can be distinguished upstream, and all
+// others become internal service errors.
+func causeCode(err error) string {
+	if multi, ok := err.(multiUnwrapper); ok {
+		if branches := nonNilErrors(multi.Unwrap()); len(branches) > 0 {
+			code, _, _ := classifyBranches(branches)
+			return code
+		}
+	}
+	var coded codedError
+	if errors.As(err, &coded) {
+		if code := coded.Code(); code != "" {
+			return code
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return ErrInternalService
+}
+
+// codeOf returns the code of err if it's a terror, and otherwise the code given by causeCode. Unlike Propagate, it
+// doesn't consult the registered translators, as they build new errors. It returns an empty string for a nil error.
+func codeOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	if terr, ok := err.(*Error); ok {
+		return terr.Code
+	}
+	return causeCode(err)
+}
+
+// inspect returns err if it is a terror, or otherwise the terror Propagate would create from it, for read-only helpers
+// which only need its code and flags. Unlike Propagate, it doesn't record the error in the process tally or capture a
+// stack, so inspecting an error doesn't count it again. A nil error returns nil.
+func inspect(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	if terr, ok := err.(*Error); ok {
+		return terr
+	}
+	if translated, ok := translateCause(err, false); ok {
+		return translated
+	}
+	terr := untrackedError(causeCode(err), err.Error(), nil)
+	terr.attachCause(err)
+	return terr
+}
+
+// attachCause sets err as the cause of p, recording it in the message and context chains, and inheriting its
+// retryability and marshal count.
+func (p *Error) attachCause(err error) {
+	p.cause = err
 
 	switch v := err.(type) {
 	case *Error:
-		newErr.MessageChain = append([]string{v.Message}, v.MessageChain...)
+		p.MessageChain = append([]string{v.Message}, v.MessageChain...)
+		p.ContextChain = append([]ContextEntry{v.contextEntry()}, v.ContextChain...)
 	default:
-		newErr.MessageChain = []string{err.Error()}
+		p.MessageChain = []string{err.Error()}
+		p.ContextChain = []ContextEntry{{Message: err.Error()}}
 	}
 
 	switch v := err.(type) {
 	// If the causal error is a terror with retryability set, inherit that value.
-	// Otherwise, we'll default to retryable based on the ErrInternalService code above.
+	// Otherwise, we'll default to retryable based on the code of the new error.
 	// This allows us to have an non-retryable InternalService error if the cause was not-retryable,
 	// which allows the retryability of errors to propagate through the system by default, even
 	// if an error handling case is missed in an upstream.
 	case *Error:
-		newErr.MarshalCount = v.MarshalCount
-		if v.IsRetryable != nil {
-			newErr.IsRetryable = v.IsRetryable
+		p.MarshalCount = v.MarshalCount
+		p.inheritRetryable(v)
+	// If the causal error has several causes, it is only retryable if all of them are.
+	case multiUnwrapper:
+		if branches := nonNilErrors(v.Unwrap()); len(branches) > 0 {
+			code, allRetryable, anyUnexpected := classifyBranches(branches)
+			p.setRetryable(allRetryable, retryabilityReason{kind: retryabilityInheritedTerror, detail: code})
+			if anyUnexpected {
+				p.IsUnexpected = &unexpected
+			}
 		}
 	// Test if the causal error is anything else that implements the same interface and is retryable.
 	case retryableError:
-		r := v.Retryable()
-		newErr.IsRetryable = &r
+		p.setRetryable(v.Retryable(), retryabilityReason{
+			kind:   retryabilityInheritedInterface,
+			detail: fmt.Sprintf("%T", v),
+		})
 	}
 
-	return newErr
+	// Unexpectedness is inherited in the same way, so that an unexpected error keeps alerting once wrapped.
+	switch v := err.(type) {
+	case *Error:
+		p.inheritUnexpected(v)
+	case multiUnwrapper:
+		// Handled with retryability above
+	case unexpectedError:
+		p.setUnexpected(v.Unexpected())
+	}
+}
+
+// ContextEntry is a single link in the causal chain of an error, as recorded in Error.ContextChain.
+type ContextEntry struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Params  map[string]string `json:"params"`
+}
+
+// contextEntry returns the entry recording this error in the ContextChain of an error it causes.
+func (p *Error) contextEntry() ContextEntry {
+	return ContextEntry{
+		Code:    p.Code,
+		Message: p.Message,
+		Params:  p.Params,
+	}
 }
 
 type retryableError interface {
 	Retryable() bool
 }
 
-// addParams returns a new error with new params merged into the original error's
-func addParams(err *Error, params map[string]string) *Error {
-	copiedParams := make(map[string]string, len(err.Params)+len(params))
-	for k, v := range err.Params {
-		copiedParams[k] = v
+// unexpectedError is implemented by errors from other libraries which know whether they were expected, in the same
+// way as Error.Unexpected.
+type unexpectedError interface {
+	Unexpected() bool
+}
+
+// codedError is implemented by errors from other libraries which carry a code in the same dotted form as terrors. The
+// code is adopted when such an error is wrapped, so that its classification isn't lost.
+type codedError interface {
+	Code() string
+}
+
+// ExtendParams returns a copy of err with params merged into its params, taking precedence over those it already
+// has. Unlike Augment, it doesn't add a link to the chain, and unlike Wrap, the original error is never modified or
+// returned with the new params missing. If err is not a terror, it is propagated first. It returns nil if err is nil.
+func ExtendParams(err error, params map[string]string) error {
+	if err == nil {
+		return nil
 	}
-	for k, v := range params {
-		copiedParams[k] = v
+	switch err := err.(type) {
+	case *Error:
+		return addParams(err, params)
+	default:
+		if translated, ok := translateCause(err, true); ok {
+			// Skip BuildStack() and ExtendParams()
+			translated.StackFrames = stack.BuildStack(2)
+			return addParams(translated, params)
+		}
+		return newWithCause(err, err.Error(), params)
 	}
+}
 
+// addParams returns a new error with new params merged into the original error's
+func addParams(err *Error, params map[string]string) *Error {
 	return &Error{
 		Code:         err.Code,
 		Message:      err.Message,
 		MessageChain: err.MessageChain,
-		Params:       copiedParams,
+		ContextChain: err.ContextChain,
+		Params:       mergeParams(err.Params, params),
 		StackFrames:  err.StackFrames,
+		StackBuildID: err.StackBuildID,
+		StackOmitted: err.StackOmitted,
+		details:      err.details,
 		IsRetryable:  err.IsRetryable,
 		IsUnexpected: err.IsUnexpected,
+		RetryAfter:   err.RetryAfter,
 		MarshalCount: err.MarshalCount,
 		cause:        err.cause,
+		createdAt:    err.createdAt,
+
+		retryableReason: err.retryableReason,
+	}
+}
+
+// augmentRepeats returns the number of times an error with the given params was augmented with the same context.
+func augmentRepeats(params map[string]string) int {
+	if repeats, err := strconv.Atoi(params[AugmentRepeatsParam]); err == nil && repeats > 0 {
+		return repeats
+	}
+	return 1
+}
+
+// mergeParams returns a new map containing the params of both maps, with those in `params` taking precedence.
+func mergeParams(existing, params map[string]string) map[string]string {
+	merged := make(map[string]string, len(existing)+len(params))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, v := range params {
+		merged[k] = v
 	}
+	if len(params) > 0 {
+		merged = limitParams(merged)
+	}
+	return merged
 }
 
 // Matches returns whether the string returned from error.Error() contains the given param string. This means you can
@@ -355,7 +666,7 @@ func (p *Error) PrefixMatches(prefixParts ...string) bool {
 //
 // But we consider this bad practice and is part of the motivation for deprecating Matches in the first place.
 func Matches(err error, match string) bool {
-	if terr, ok := Wrap(err, nil).(*Error); ok {
+	if terr := inspect(err); terr != nil {
 		return terr.Matches(match)
 	}
 
@@ -369,7 +680,12 @@ func Matches(err error, match string) bool {
 // terrors.PrefixMatches(terr, "bad_request.missing_param")`
 // Deprecated: Please use `Is` instead.
 func PrefixMatches(err error, prefixParts ...string) bool {
-	if terr, ok := Wrap(err, nil).(*Error); ok {
+	if hook := currentMatchHook(); hook != nil && sampleHook(codeOf(err)) {
+		decision := ExplainPrefixMatches(err, prefixParts...)
+		hook(decision)
+		return decision.Matched
+	}
+	if terr := inspect(err); terr != nil {
 		return terr.PrefixMatches(prefixParts...)
 	}
 
@@ -379,41 +695,83 @@ func PrefixMatches(err error, prefixParts ...string) bool {
 // IsRetryable returns true if the error is a terror and whether the error was caused by an action which can be
 // retried.
 func IsRetryable(err error) bool {
-	if r, ok := Propagate(err).(*Error); ok {
+	if r := inspect(err); r != nil {
 		return r.Retryable()
 	}
 	return false
 }
 
+// ShouldRetryDownstream reports whether a call which failed with err should be retried: the error must be retryable,
+// and must not have been marshalled more than CurrentMaxRetryMarshalCount times. An error which has been marshalled
+// several times has already crossed several services, any of which may have retried it, so retrying again risks
+// retries on top of retries.
+func ShouldRetryDownstream(err error) bool {
+	terr := inspect(err)
+	if terr == nil || !terr.Retryable() {
+		return false
+	}
+	return terr.MarshalCount <= CurrentMaxRetryMarshalCount()
+}
+
+// AugmentRepeatsParam counts how many times in a row an error was augmented with the same context (see Augment).
+const AugmentRepeatsParam = "augment_repeats"
+
 // Augment adds context to an existing error.
-// If the error given is not already a terror, a new terror is created.
+// If the error given is not already a terror, a new terror is created in the same way as Propagate.
+// If the context is the same as the message of err, as happens when an error is augmented in a retry loop, the two are
+// collapsed into a single link rather than growing the chain with repeated messages, and the number of repeats is
+// recorded in the AugmentRepeatsParam param of that link. New links don't inherit the count from their cause.
 func Augment(err error, context string, params map[string]string) error {
 	if err == nil {
 		return nil
 	}
 	switch err := err.(type) {
 	case *Error:
-		withMergedParams := addParams(err, params)
+		if context != "" && context == err.Message {
+			repeated := *err
+			repeated.Params = mergeParams(err.Params, params)
+			repeated.Params[AugmentRepeatsParam] = strconv.Itoa(augmentRepeats(err.Params) + 1)
+			return &repeated
+		}
+		merged := mergeParams(err.Params, params)
+		if _, ok := params[AugmentRepeatsParam]; !ok {
+			// The count belongs to the link whose context was repeated, which stays in the context chain
+			delete(merged, AugmentRepeatsParam)
+		}
 		// The underlying terror will already have a stack, so we don't take a new trace here.
 		return &Error{
 			Code:         err.Code,
 			Message:      context,
 			MessageChain: append([]string{err.Message}, err.MessageChain...),
-			Params:       withMergedParams.Params,
+			ContextChain: append([]ContextEntry{err.contextEntry()}, err.ContextChain...),
+			Params:       merged,
 			StackFrames:  stack.Stack{},
 			IsRetryable:  err.IsRetryable,
 			IsUnexpected: err.IsUnexpected,
+			RetryAfter:   err.RetryAfter,
 			MarshalCount: err.MarshalCount,
 			cause:        err,
+
+			retryableReason: err.retryableReason,
 		}
 	default:
-		return NewInternalWithCause(err, context, params, "")
+		if translated, ok := translateCause(err, true); ok {
+			augmented := Augment(translated, context, params).(*Error)
+			// The head of the chain is the only link created here, so it carries the stack. Skip BuildStack() and
+			// Augment()
+			augmented.StackFrames = stack.BuildStack(2)
+			return augmented
+		}
+		return newWithCause(err, context, params)
 	}
 }
 
 // Propagate an error without changing it. This is equivalent to `return err`
 // if the error is already a terror. If it is not a terror, this function will
-// create one, and set the given error as the cause.
+// create one, and set the given error as the cause. Any registered CauseTranslator
+// which recognises the error is used to create it. Otherwise, errors with a
+// `Code() string` method keep that code, errors from contexts whose deadline was
+// exceeded become timeouts, and all others internal service errors.
 // This is a drop-in replacement for `terrors.Wrap(err, nil)` which adds causal
 // chain functionality.
 func Propagate(err error) error {
@@ -424,19 +782,76 @@ func Propagate(err error) error {
 	case *Error:
 		return err
 	default:
-		return NewInternalWithCause(err, err.Error(), nil, "")
+		if translated, ok := translateCause(err, true); ok {
+			// Skip BuildStack() and Propagate()
+			translated.StackFrames = stack.BuildStack(2)
+			return translated
+		}
+		return newWithCause(err, err.Error(), nil)
 	}
 }
 
 // Is checks whether an error is a given code. Similarly to `errors.Is`,
 // this unwinds the error stack and checks each underlying error for the code.
-// If any match, this returns true.
+// If any match, this returns true. Every branch of a cause with several causes (see Join) is checked.
 // Note that Is only behaves differently to PrefixMatches when errors in the stack have different codes.
 // For example, this is the case when errors are initialized with NewInternalWithCause, but not with Augment.
 // We prefer this over using a method receiver on the terrors Error, as the function
 // signature requires an error to test against, and checking against terrors would
 // requite creating a new terror with the specific code.
 func Is(err error, code ...string) bool {
+	if hook := currentMatchHook(); hook != nil && sampleHook(codeOf(err)) {
+		decision := ExplainIs(err, code...)
+		hook(decision)
+		return decision.Matched
+	}
+	return is(err, code...)
+}
+
+// IsError reports whether err matches target, so that terror codes and sentinel errors can be checked with one idiom:
+//
+//	if terrors.IsError(err, context.Canceled) || terrors.IsError(err, terrors.NotFound("account", "", nil)) {
+//
+// It returns true if errors.Is does, or if target is a terror and any terror in the chain of err has a code which is
+// prefixed by the code of target (as with Is). Unlike Is, the chain is followed through errors which aren't terrors,
+// such as those created by fmt.Errorf with %w, and through each branch of errors with several causes.
+func IsError(err, target error) bool {
+	if err == nil || target == nil {
+		return err == target
+	}
+	if errors.Is(err, target) {
+		return true
+	}
+	terr, ok := target.(*Error)
+	if !ok || terr.Code == "" {
+		return false
+	}
+	return chainHasCode(err, terr.Code, CurrentMaxCausalDepth())
+}
+
+// chainHasCode returns whether any terror in the chain of err, including the branches of errors with several causes,
+// has a code prefixed by code. At most maxDepth links are followed.
+func chainHasCode(err error, code string, maxDepth int) bool {
+	for depth := 0; err != nil && depth < maxDepth; depth++ {
+		switch typed := err.(type) {
+		case *Error:
+			if typed.PrefixMatches(code) {
+				return true
+			}
+		case multiUnwrapper:
+			for _, branch := range typed.Unwrap() {
+				if chainHasCode(branch, code, maxDepth-depth-1) {
+					return true
+				}
+			}
+			return false
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}
+
+func is(err error, code ...string) bool {
 	switch err := err.(type) {
 	case *Error:
 		if err.PrefixMatches(code...) {
@@ -446,7 +861,14 @@ func Is(err error, code ...string) bool {
 		if next == nil {
 			return false
 		}
-		return Is(next, code...)
+		return is(next, code...)
+	case multiUnwrapper:
+		for _, branch := range err.Unwrap() {
+			if is(branch, code...) {
+				return true
+			}
+		}
+		return false
 	default:
 		return false
 	}
@@ -1,10 +1,15 @@
 package terrors
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 
@@ -19,6 +24,20 @@ func TestLogParams(t *testing.T) {
 	assert.Equal(t, "value", err.LogMetadata()["public"])
 }
 
+func TestLogMetadataChain(t *testing.T) {
+	cause := NotFound("foo", "foo not found", map[string]string{"foo_id": "123", "shared": "inner"})
+	err := NewInternalWithCause(cause, "loading foo", map[string]string{"shared": "outer"}, "")
+
+	assert.Equal(t, map[string]string{"shared": "outer"}, err.LogMetadata())
+
+	SetLogMetadataMode(LogMetadataChain)
+	t.Cleanup(func() { SetLogMetadataMode(LogMetadataOuter) })
+	assert.Equal(t, map[string]string{"foo_id": "123", "shared": "outer"}, err.LogMetadata())
+
+	// The chain survives marshalling
+	assert.Equal(t, map[string]string{"foo_id": "123", "shared": "outer"}, Unmarshal(Marshal(err)).LogMetadata())
+}
+
 func TestErrorConstructors(t *testing.T) {
 
 	testCases := []struct {
@@ -58,6 +77,15 @@ func TestErrorConstructors(t *testing.T) {
 		{
 			RateLimited, "service.foo", "rate_limited.service.foo", nil, ErrRateLimited,
 		},
+		{
+			Conflict, "service.foo", "conflict.service.foo", nil, ErrConflict,
+		},
+		{
+			NotImplemented, "service.foo", "not_implemented.service.foo", nil, ErrNotImplemented,
+		},
+		{
+			Unavailable, "service.foo", "unavailable.service.foo", nil, ErrUnavailable,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -196,6 +224,9 @@ func TestIsRetryable(t *testing.T) {
 	assert.False(t, IsRetryable(NonRetryableInternalService("", "", nil)))
 	assert.True(t, IsRetryable(InternalService("", "", nil)))
 	assert.True(t, IsRetryable(RateLimited("", "", nil)))
+	assert.False(t, IsRetryable(Conflict("", "", nil)))
+	assert.False(t, IsRetryable(NotImplemented("", "", nil)))
+	assert.True(t, IsRetryable(Unavailable("", "", nil)))
 	assert.True(t, IsRetryable(errors.New("")))
 	assert.True(t, IsRetryable(Augment(errors.New(""), "", nil)))
 	assert.True(t, IsRetryable(Wrap(errors.New(""), nil)))
@@ -216,6 +247,52 @@ func TestIsRetryable(t *testing.T) {
 	assert.True(t, IsRetryable(&testRetryableError{true}))
 }
 
+func TestWrapWithCodeInheritsFlags(t *testing.T) {
+	// A terror hidden behind a stdlib wrapper keeps its flags when the wrapper is given a new code
+	base := NonRetryableInternalService("", "", nil)
+	base.SetIsUnexpected(true)
+	terr := WrapWithCode(fmt.Errorf("context: %w", base), nil, ErrTimeout).(*Error)
+	assert.Equal(t, ErrTimeout, terr.Code)
+	assert.False(t, terr.Retryable())
+	assert.True(t, terr.Unexpected())
+
+	// Flags that were never set on the wrapped terror fall back to the new code's defaults
+	terr = WrapWithCode(fmt.Errorf("context: %w", &Error{Code: ErrBadRequest}), nil, ErrTimeout).(*Error)
+	assert.True(t, terr.Retryable())
+	assert.False(t, terr.Unexpected())
+
+	assert.False(t, IsRetryable(Wrap(&testRetryableError{false}, nil)))
+	assert.True(t, IsRetryable(WrapWithCode(&testRetryableError{true}, nil, ErrBadRequest)))
+}
+
+func TestNewInternalWithCauseInheritsUnexpected(t *testing.T) {
+	base := NotFound("foo", "", nil)
+	base.SetIsUnexpected(true)
+	assert.True(t, NewInternalWithCause(base, "context", nil, "").Unexpected())
+	assert.True(t, Augment(base, "context", nil).(*Error).Unexpected())
+
+	base.SetIsUnexpected(false)
+	terr := NewInternalWithCause(base, "context", nil, "")
+	assert.NotNil(t, terr.IsUnexpected)
+	assert.False(t, terr.Unexpected())
+
+	// Unset flags stay unset
+	assert.Nil(t, NewInternalWithCause(NotFound("foo", "", nil), "context", nil, "").IsUnexpected)
+
+	// Foreign errors implementing Unexpected() are honoured
+	assert.True(t, NewInternalWithCause(&testUnexpectedError{true}, "context", nil, "").Unexpected())
+	assert.True(t, Propagate(&testUnexpectedError{true}).(*Error).Unexpected())
+	assert.True(t, Wrap(&testUnexpectedError{true}, nil).(*Error).Unexpected())
+	assert.False(t, Augment(&testUnexpectedError{false}, "context", nil).(*Error).Unexpected())
+}
+
+type testUnexpectedError struct {
+	unexpected bool
+}
+
+func (e *testUnexpectedError) Error() string    { return "unexpected error" }
+func (e *testUnexpectedError) Unexpected() bool { return e.unexpected }
+
 type testRetryableError struct {
 	retryable bool
 }
@@ -441,6 +518,63 @@ func TestPropagate(t *testing.T) {
 	})
 }
 
+func TestDeadlineExceeded(t *testing.T) {
+	deadlineErr := fmt.Errorf("calling foo: %w", context.DeadlineExceeded)
+	testCases := []struct {
+		name     string
+		err      error
+		hasCause bool
+	}{
+		{"Propagate", Propagate(deadlineErr), true},
+		{"Augment", Augment(deadlineErr, "context", nil), true},
+		{"Wrap", Wrap(deadlineErr, nil), false},
+		{"WrapOpt", WrapOpt(deadlineErr, nil, WithStack(false)), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			terr := tc.err.(*Error)
+			assert.Equal(t, ErrTimeout, terr.Code)
+			assert.True(t, terr.Retryable())
+			assert.Equal(t, tc.hasCause, errors.Is(terr, context.DeadlineExceeded))
+		})
+	}
+
+	assert.Equal(t, ErrInternalService, Propagate(context.Canceled).(*Error).Code)
+	assert.Equal(t, ErrTimeout, Summary(deadlineErr).Code)
+}
+
+type testCodedError struct {
+	code string
+}
+
+func (e testCodedError) Error() string { return "coded: " + e.code }
+func (e testCodedError) Code() string  { return e.code }
+
+func TestCodedErrorCause(t *testing.T) {
+	codedErr := fmt.Errorf("calling foo: %w", testCodedError{code: "rate_limited.quota"})
+	testCases := []struct {
+		name string
+		err  error
+	}{
+		{"Propagate", Propagate(codedErr)},
+		{"Augment", Augment(codedErr, "context", nil)},
+		{"Wrap", Wrap(codedErr, nil)},
+		{"WrapOpt", WrapOpt(codedErr, nil, WithStack(false))},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			terr := tc.err.(*Error)
+			assert.Equal(t, "rate_limited.quota", terr.Code)
+			assert.True(t, terr.Retryable())
+		})
+	}
+
+	// An empty code is ignored
+	assert.Equal(t, ErrInternalService, Propagate(testCodedError{}).(*Error).Code)
+	// Explicitly given codes win
+	assert.Equal(t, ErrForbidden, WrapWithCode(codedErr, nil, ErrForbidden).(*Error).Code)
+}
+
 func TestStackTrace(t *testing.T) {
 	t.Run("nil stack", func(t *testing.T) {
 		terr := &Error{}
@@ -483,6 +617,30 @@ func TestErrorMessage(t *testing.T) {
 	}
 }
 
+func TestShouldRetryDownstream(t *testing.T) {
+	assert.False(t, ShouldRetryDownstream(nil))
+	assert.False(t, ShouldRetryDownstream(BadRequest("", "", nil)))
+	assert.True(t, ShouldRetryDownstream(errors.New("")))
+
+	err := InternalService("", "", nil)
+	assert.True(t, ShouldRetryDownstream(err))
+
+	once := Unmarshal(Marshal(err))
+	assert.True(t, ShouldRetryDownstream(once))
+	assert.True(t, ShouldRetryDownstream(Augment(once, "calling", nil)))
+
+	twice := Unmarshal(Marshal(once))
+	assert.False(t, ShouldRetryDownstream(twice))
+	assert.False(t, ShouldRetryDownstream(Augment(twice, "calling", nil)))
+
+	SetMaxRetryMarshalCount(2)
+	defer SetMaxRetryMarshalCount(-1)
+	assert.True(t, ShouldRetryDownstream(twice))
+
+	SetMaxRetryMarshalCount(-1)
+	assert.Equal(t, DefaultMaxRetryMarshalCount, CurrentMaxRetryMarshalCount())
+}
+
 func TestRetryable(t *testing.T) {
 	cases := []struct {
 		desc     string
@@ -616,3 +774,357 @@ func TestCircularErrorProducesFiniteOutputWithoutStackFrames(t *testing.T) {
 	// There's no actual stack in the causal cycle, so we don't render anything here.
 	assert.Empty(t, ss)
 }
+
+func TestCircularErrorProducesFiniteErrorMessage(t *testing.T) {
+	err := Augment(failyFunction(), "something may be up", nil)
+	terr := err.(*Error)
+	terr.cause = terr
+
+	msg := terr.ErrorMessage()
+	assert.True(t, strings.HasPrefix(msg, "something may be up: something may be up: "))
+	assert.True(t, strings.HasSuffix(msg, ": ..."))
+	assert.Equal(t, DefaultMaxCausalDepth+1, strings.Count(msg, "something may be up"))
+}
+
+func TestMaxCausalDepth(t *testing.T) {
+	SetMaxCausalDepth(2)
+	defer SetMaxCausalDepth(0)
+	assert.Equal(t, 2, CurrentMaxCausalDepth())
+
+	err := failyFunction()
+	for i := 0; i < 5; i++ {
+		err = Augment(err, fmt.Sprintf("context %d", i), nil)
+	}
+	terr := err.(*Error)
+	assert.Equal(t, "context 4: context 3: context 2: ...", terr.ErrorMessage())
+	// The stack is on the innermost error, which is too deep to reach
+	assert.Empty(t, terr.StackString())
+
+	SetMaxCausalDepth(0)
+	assert.Equal(t, DefaultMaxCausalDepth, CurrentMaxCausalDepth())
+	assert.Contains(t, terr.StackString(), "failyFunction")
+}
+
+func TestStackSizeLimit(t *testing.T) {
+	SetStackSizeLimit(100)
+	defer SetStackSizeLimit(0)
+	assert.Equal(t, 100, CurrentStackSizeLimit())
+
+	terr := failyFunction().(*Error)
+	assert.LessOrEqual(t, len(terr.StackString()), 100)
+
+	SetStackSizeLimit(0)
+	assert.Equal(t, DefaultStackSizeLimit, CurrentStackSizeLimit())
+}
+
+func TestErrorMessageWithLimit(t *testing.T) {
+	err := Augment(errors.New("ünïcödé root"), "outer", nil).(*Error)
+	assert.Equal(t, "outer: ünïcödé root", err.ErrorMessageWithLimit(0))
+	assert.Equal(t, err.ErrorMessage(), err.ErrorMessageWithLimit(len(err.ErrorMessage())))
+
+	// "ü" is two bytes, so a cut after its first byte backs off to before it
+	limited := err.ErrorMessageWithLimit(len("outer: ") + 1)
+	assert.Equal(t, "outer: "+truncationMarker, limited)
+	assert.True(t, utf8.ValidString(limited))
+	assert.Equal(t, "outer: ü"+truncationMarker, err.ErrorMessageWithLimit(len("outer: ü")))
+}
+
+func TestErrorMessageLimit(t *testing.T) {
+	SetErrorMessageLimit(50)
+	defer SetErrorMessageLimit(0)
+	assert.Equal(t, 50, CurrentErrorMessageLimit())
+
+	var err error = errors.New("root")
+	for i := 0; i < 100; i++ {
+		err = Augment(err, fmt.Sprintf("context %d", i), nil)
+	}
+	terr := err.(*Error)
+	assert.Equal(t, ErrInternalService+": "+terr.ErrorMessageWithLimit(50), terr.Error())
+	assert.LessOrEqual(t, len(terr.Error()), len(ErrInternalService+": ")+50+len(truncationMarker))
+	assert.Greater(t, len(terr.ErrorMessage()), 50)
+
+	SetErrorMessageLimit(0)
+	assert.Equal(t, DefaultErrorMessageLimit, CurrentErrorMessageLimit())
+}
+
+func capturePCs() []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(1, pcs)]
+}
+
+func TestNewFromPCs(t *testing.T) {
+	pcs := capturePCs()
+	err := NewFromPCs("bad_request.foo", "bar", pcs, map[string]string{"k": "v"})
+
+	assert.Equal(t, "bad_request.foo", err.Code)
+	assert.Equal(t, "bar", err.Message)
+	assert.Equal(t, "v", err.Params["k"])
+	assert.False(t, err.Retryable())
+	// The stack starts where the PCs were captured, not where the error was constructed
+	assert.Contains(t, err.StackFrames[0].Method, "capturePCs")
+	assert.Contains(t, err.StackFrames[1].Method, "TestNewFromPCs")
+
+	assert.Empty(t, NewFromPCs("foo", "bar", nil, nil).StackFrames)
+}
+
+func TestWrapOpt(t *testing.T) {
+	t.Run("default captures a stack", func(t *testing.T) {
+		terr := WrapOpt(assert.AnError, map[string]string{"k": "v"}).(*Error)
+		assert.Equal(t, ErrInternalService, terr.Code)
+		assert.Equal(t, "v", terr.Params["k"])
+		assert.Contains(t, terr.StackFrames[0].Method, "TestWrapOpt")
+	})
+	t.Run("suppressed stack", func(t *testing.T) {
+		terr := WrapOpt(assert.AnError, nil, WithStack(false)).(*Error)
+		assert.Equal(t, ErrInternalService, terr.Code)
+		assert.Equal(t, assert.AnError.Error(), terr.Message)
+		assert.Empty(t, terr.StackFrames)
+		assert.True(t, terr.Retryable())
+	})
+	t.Run("forced stack on a terror without one", func(t *testing.T) {
+		base := &Error{Code: ErrNotFound, Message: "foo"}
+		terr := WrapOpt(base, nil, WithStack(true)).(*Error)
+		assert.Contains(t, terr.StackFrames[0].Method, "TestWrapOpt")
+		assert.Empty(t, base.StackFrames)
+	})
+	t.Run("forced stack keeps an existing one", func(t *testing.T) {
+		base := NotFound("foo", "bar", nil)
+		terr := WrapOpt(base, nil, WithStack(true)).(*Error)
+		assert.Equal(t, base.StackFrames, terr.StackFrames)
+	})
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, WrapOpt(nil, nil, WithStack(false)))
+	})
+}
+
+func TestErrorFormat(t *testing.T) {
+	err := Augment(NotFound("foo", "failed to find foo", nil), "looking up foo", nil).(*Error)
+	assert.Equal(t, "not_found.foo: looking up foo: failed to find foo", err.Error())
+	assert.Equal(t, "not_found.foo: looking up foo", err.ShortString())
+
+	SetErrorFormat(ErrorFormatLegacy)
+	defer SetErrorFormat(ErrorFormatChained)
+	assert.Equal(t, "not_found.foo: looking up foo", err.Error())
+	assert.Equal(t, "not_found.foo: looking up foo", err.ShortString())
+}
+
+func TestShortString(t *testing.T) {
+	assert.Equal(t, "code: message", (&Error{Code: "code", Message: "message"}).ShortString())
+	assert.Equal(t, "code", (&Error{Code: "code"}).ShortString())
+	assert.Equal(t, "message", (&Error{Message: "message"}).ShortString())
+}
+
+func TestFastPathsDoNotAllocate(t *testing.T) {
+	terr := NotFound("foo", "bar", map[string]string{"k": "v"})
+	var err error = terr
+
+	cases := map[string]func(){
+		"Propagate nil":        func() { _ = Propagate(nil) },
+		"Propagate terror":     func() { _ = Propagate(err) },
+		"Augment nil":          func() { _ = Augment(nil, "context", nil) },
+		"Wrap nil":             func() { _ = Wrap(nil, nil) },
+		"Is terror":            func() { _ = Is(err, ErrNotFound) },
+		"PrefixMatches terror": func() { _ = PrefixMatches(err, ErrNotFound) },
+		"IsRetryable terror":   func() { _ = IsRetryable(err) },
+	}
+	for name, fn := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Zero(t, testing.AllocsPerRun(100, fn))
+		})
+	}
+
+	// Wrap always returns a copy, even when there's nothing to add, as callers may modify it
+	assert.NotSame(t, terr, Wrap(err, nil))
+	assert.NotSame(t, terr, WrapWithCode(err, nil, ErrBadRequest))
+	assert.NotSame(t, terr, WrapOpt(err, nil))
+}
+
+func BenchmarkPropagate(b *testing.B) {
+	var terr error = NotFound("foo", "bar", nil)
+	b.Run("nil", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Propagate(nil)
+		}
+	})
+	b.Run("terror", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Propagate(terr)
+		}
+	})
+	b.Run("non-terror", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Propagate(assert.AnError)
+		}
+	})
+}
+
+func BenchmarkAugment(b *testing.B) {
+	var terr error = NotFound("foo", "bar", map[string]string{"k": "v"})
+	b.Run("nil", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Augment(nil, "context", nil)
+		}
+	})
+	b.Run("terror", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Augment(terr, "context", nil)
+		}
+	})
+}
+
+func BenchmarkWrap(b *testing.B) {
+	var terr error = NotFound("foo", "bar", map[string]string{"k": "v"})
+	b.Run("terror without params", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Wrap(terr, nil)
+		}
+	})
+	b.Run("terror with params", func(b *testing.B) {
+		params := map[string]string{"new": "param"}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Wrap(terr, params)
+		}
+	})
+}
+
+func TestCause(t *testing.T) {
+	root := errors.New("root")
+	err := Augment(root, "context", nil).(*Error)
+	assert.Equal(t, root, err.Cause())
+	assert.Equal(t, err.Unwrap(), err.Cause())
+
+	// Satisfies the interface used by github.com/pkg/errors
+	var causer interface{ Cause() error } = err
+	assert.Equal(t, root, causer.Cause())
+
+	// The cause doesn't survive the wire, but the chain does
+	unmarshalled := Unmarshal(Marshal(err))
+	assert.Nil(t, unmarshalled.Cause())
+	assert.Equal(t, []string{"root"}, unmarshalled.MessageChain)
+
+	assert.Nil(t, NotFound("", "", nil).Cause())
+}
+
+func TestCauseWalk(t *testing.T) {
+	// The loop used by errors.Cause in github.com/pkg/errors
+	pkgErrorsCause := func(err error) error {
+		type causer interface {
+			Cause() error
+		}
+		for err != nil {
+			cause, ok := err.(causer)
+			if !ok {
+				break
+			}
+			err = cause.Cause()
+		}
+		return err
+	}
+
+	root := errors.New("root")
+	err := Augment(Augment(root, "inner", nil), "outer", nil)
+	assert.Equal(t, root, pkgErrorsCause(err))
+
+	// Errors wrapped with fmt.Errorf don't implement Cause, so the walk stops there
+	wrapped := fmt.Errorf("wrapped: %w", root)
+	assert.Equal(t, wrapped, pkgErrorsCause(Augment(wrapped, "outer", nil)))
+}
+
+func TestAugmentCollapsesRepeatedContext(t *testing.T) {
+	var err error = NotFound("foo", "foo not found", nil)
+	for i := 0; i < 3; i++ {
+		err = Augment(err, "calling downstream", map[string]string{"attempt": strconv.Itoa(i)})
+	}
+	terr := err.(*Error)
+	assert.Equal(t, "not_found.foo: calling downstream: foo not found", terr.Error())
+	assert.Equal(t, []string{"foo not found"}, terr.MessageChain)
+	assert.Len(t, terr.ContextChain, 1)
+	assert.Equal(t, "3", terr.Params[AugmentRepeatsParam])
+	assert.Equal(t, "2", terr.Params["attempt"])
+
+	// Different context isn't collapsed, and the repeats are kept in the chain
+	err = Augment(err, "handling request", nil)
+	terr = err.(*Error)
+	assert.Equal(t, []string{"calling downstream", "foo not found"}, terr.MessageChain)
+	assert.Equal(t, "3", terr.ContextChain[0].Params[AugmentRepeatsParam])
+	// The outer context wasn't repeated, so it has no count of its own
+	assert.NotContains(t, terr.Params, AugmentRepeatsParam)
+
+	// Repeating the outer context counts from one, whatever the count of the inner context
+	terr = Augment(terr, "handling request", nil).(*Error)
+	assert.Equal(t, "2", terr.Params[AugmentRepeatsParam])
+	assert.Equal(t, "3", terr.ContextChain[0].Params[AugmentRepeatsParam])
+}
+
+func TestExtendParams(t *testing.T) {
+	assert.Nil(t, ExtendParams(nil, map[string]string{"a": "1"}))
+
+	orig := Augment(NotFound("foo", "foo not found", map[string]string{"a": "1"}), "loading foo", nil).(*Error)
+	err := ExtendParams(orig, map[string]string{"a": "2", "b": "3"}).(*Error)
+	assert.Equal(t, map[string]string{"a": "2", "b": "3"}, err.Params)
+	assert.Equal(t, orig.Message, err.Message)
+	assert.Equal(t, orig.MessageChain, err.MessageChain)
+	assert.True(t, errors.Is(err, orig.cause))
+	// The original is untouched
+	assert.Equal(t, map[string]string{"a": "1"}, orig.Params)
+
+	cause := errors.New("boom")
+	err = ExtendParams(cause, map[string]string{"a": "1"}).(*Error)
+	assert.Equal(t, ErrInternalService, err.Code)
+	assert.Equal(t, map[string]string{"a": "1"}, err.Params)
+	assert.True(t, errors.Is(err, cause))
+}
+
+func TestIsErrorTarget(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	notFound := NotFound("account", "account not found", nil)
+
+	assert.True(t, IsError(nil, nil))
+	assert.False(t, IsError(nil, sentinel))
+	assert.False(t, IsError(notFound, nil))
+
+	// Sentinels
+	err := Augment(fmt.Errorf("loading: %w", sentinel), "handling request", nil)
+	assert.True(t, IsError(err, sentinel))
+	assert.True(t, IsError(Augment(context.Canceled, "waiting", nil), context.Canceled))
+	assert.False(t, IsError(err, context.Canceled))
+
+	// Codes, through errors which aren't terrors
+	err = fmt.Errorf("loading: %w", Augment(notFound, "loading account", nil))
+	assert.True(t, IsError(err, notFound))
+	assert.True(t, IsError(err, NotFound("", "", nil)))
+	assert.False(t, IsError(err, NotFound("user", "", nil)))
+	assert.False(t, IsError(err, BadRequest("", "", nil)))
+
+	// Codes in branches
+	err = NewInternalWithCause(multiError{sentinel, notFound}, "several failures", nil, "")
+	assert.True(t, IsError(err, notFound))
+	assert.False(t, IsError(err, Forbidden("", "", nil)))
+}
+
+func TestErrorsIsMatchesTerrorTargets(t *testing.T) {
+	sentinel := NotFound("config", "config not found", nil)
+
+	err := Augment(NotFound("config.flags", "flags not found", nil), "loading config", nil)
+	assert.True(t, errors.Is(err, sentinel))
+	assert.True(t, errors.Is(fmt.Errorf("starting: %w", err), sentinel))
+	assert.True(t, errors.Is(err, NotFound("", "", nil)))
+	assert.False(t, errors.Is(err, NotFound("user", "", nil)))
+	assert.False(t, errors.Is(err, BadRequest("", "", nil)))
+
+	// Found further down the chain
+	err = NewInternalWithCause(NotFound("config", "", nil), "loading", nil, "")
+	assert.True(t, errors.Is(err, sentinel))
+
+	// Targets without a code only match themselves
+	empty := &Error{}
+	assert.False(t, errors.Is(err, empty))
+	assert.True(t, errors.Is(empty, empty))
+}
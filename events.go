@@ -0,0 +1,63 @@
+package terrors
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// EventKind identifies what happened in an Event.
+type EventKind string
+
+const (
+	// EventWrapCompat is emitted each time WrapCompat or WrapWithCodeCompat is called with a non-nil error.
+	EventWrapCompat EventKind = "wrap_compat"
+)
+
+// Event describes a notable use of this package, for example a call to a deprecated function which is being
+// migrated away from. Events are intended to be counted, so that the progress of a migration can be measured.
+type Event struct {
+	Kind EventKind
+	// Code is the code of the resulting error.
+	Code string
+	// Caller is the file and line of the call site, e.g. `handler.go:42`.
+	Caller string
+	// Function is the fully qualified name of the calling function.
+	Function string
+}
+
+// EventHook is called with every Event. It is called synchronously, so it should be cheap.
+type EventHook func(Event)
+
+var eventHook EventHook
+
+// SetEventHook installs a hook which is called with every Event. Passing nil removes the hook, which is the default.
+// When no hook is installed, events cost nothing. Events may be sampled (see SetHookSampling).
+func SetEventHook(hook EventHook) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	eventHook = hook
+}
+
+func currentEventHook() EventHook {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return eventHook
+}
+
+// emitEvent sends an event to the installed hook, if any. The call site recorded is that of the caller of
+// emitEvent's caller, after skipping a further `skip` frames.
+func emitEvent(kind EventKind, code string, skip int) {
+	hook := currentEventHook()
+	if hook == nil || !sampleHook(code) {
+		return
+	}
+	event := Event{Kind: kind, Code: code}
+	// Skip runtime.Callers(), emitEvent() and its caller
+	pcs := make([]uintptr, 1)
+	if runtime.Callers(skip+3, pcs) > 0 {
+		frame, _ := runtime.CallersFrames(pcs).Next()
+		event.Caller = fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		event.Function = frame.Function
+	}
+	hook(event)
+}
@@ -0,0 +1,32 @@
+package terrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withEventHook installs a hook recording events for the duration of the test.
+func withEventHook(t *testing.T) *[]Event {
+	var events []Event
+	SetEventHook(func(e Event) {
+		events = append(events, e)
+	})
+	t.Cleanup(func() { SetEventHook(nil) })
+	return &events
+}
+
+func TestEventHook(t *testing.T) {
+	events := withEventHook(t)
+	emitEvent(EventWrapCompat, "foo", -1)
+	assert.Len(t, *events, 1)
+	assert.Equal(t, EventWrapCompat, (*events)[0].Kind)
+	assert.Equal(t, "foo", (*events)[0].Code)
+	assert.Contains(t, (*events)[0].Function, "TestEventHook")
+	assert.Contains(t, (*events)[0].Caller, "events_test.go:")
+
+	// No hook, no events
+	SetEventHook(nil)
+	emitEvent(EventWrapCompat, "foo", -1)
+	assert.Len(t, *events, 1)
+}
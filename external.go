@@ -0,0 +1,86 @@
+package terrors
+
+import pe "github.com/monzo/terrors/proto"
+
+// externalParams maps code prefixes to the param keys MarshalExternal passes through for errors with those codes. It
+// is guarded by configMu.
+var externalParams = map[string][]string{}
+
+// AllowExternalParams allows MarshalExternal to pass the given param keys through to clients for errors whose code is
+// codePrefix, or starts with codePrefix followed by a dot, for example
+//
+//	terrors.AllowExternalParams(terrors.ErrRateLimited, "retry_after")
+//	terrors.AllowExternalParams(terrors.ErrBadRequest, "field")
+//
+// An empty prefix allows the keys for every error. Calls for the same prefix add to the keys already allowed.
+func AllowExternalParams(codePrefix string, keys ...string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	allowed := append([]string(nil), externalParams[codePrefix]...)
+	externalParams[codePrefix] = append(allowed, keys...)
+}
+
+// allowedExternalParams returns the set of param keys allowed for errors with the given code.
+func allowedExternalParams(code string) map[string]bool {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	allowed := map[string]bool{}
+	for prefix, keys := range externalParams {
+		if prefix != "" && !hasCodePrefix(code, prefix) {
+			continue
+		}
+		for _, k := range keys {
+			allowed[k] = true
+		}
+	}
+	return allowed
+}
+
+// MarshalExternal marshals an error for clients outside our infrastructure (e.g. the public API), which mustn't see
+// its internals. Only the code, message, retryability and unexpectedness are included, along with the params allowed
+// with AllowExternalParams; the stack, the message and context chains, and every other param are stripped.
+//
+// Allowed params are looked for in the params of the error and then in its context chain, so that params added to a
+// cause are found even if the error was created from it with NewInternalWithCause. Where a key appears more than once,
+// the outermost value wins. Secrets are masked, as they are by Marshal.
+func MarshalExternal(e *Error) *pe.Error {
+	full := Marshal(e)
+	external := &pe.Error{
+		Code:         full.Code,
+		Message:      full.Message,
+		Retryable:    full.Retryable,
+		Unexpected:   full.Unexpected,
+		MarshalCount: full.MarshalCount,
+	}
+
+	allowed := allowedExternalParams(full.Code)
+	if len(allowed) == 0 {
+		return external
+	}
+	params := map[string]string{}
+	collect := func(from map[string]string) {
+		for k, v := range from {
+			if _, seen := params[k]; !seen && allowed[k] {
+				params[k] = v
+			}
+		}
+	}
+	collect(full.Params)
+	for _, entry := range full.ContextChain {
+		collect(entry.Params)
+	}
+	if len(params) > 0 {
+		external.Params = params
+	}
+	return external
+}
+
+// maskedMessageChain returns up to max messages from the message chain of e, outermost first, masked as they are by
+// Marshal.
+func maskedMessageChain(e *Error, max int) []string {
+	chain := Marshal(e).MessageChain
+	if len(chain) > max {
+		chain = chain[:max]
+	}
+	return chain
+}
@@ -0,0 +1,64 @@
+package terrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withExternalParams(t *testing.T) {
+	configMu.Lock()
+	previous := externalParams
+	externalParams = map[string][]string{}
+	configMu.Unlock()
+	t.Cleanup(func() {
+		configMu.Lock()
+		externalParams = previous
+		configMu.Unlock()
+	})
+}
+
+func TestMarshalExternal(t *testing.T) {
+	withExternalParams(t)
+	AllowExternalParams(ErrRateLimited, "retry_after")
+	AllowExternalParams(ErrBadRequest, "field")
+	AllowExternalParams("", "request_id")
+	AllowExternalParams(ErrBadRequest, "reason")
+
+	t.Run("stripped", func(t *testing.T) {
+		err := Augment(BadRequest("invalid_amount", "amount must be positive", map[string]string{
+			"field":      "amount",
+			"account_id": "acc_123",
+		}), "validating payment", map[string]string{"request_id": "req_1"}).(*Error)
+		p := MarshalExternal(err)
+		assert.Equal(t, "bad_request.invalid_amount", p.Code)
+		assert.Equal(t, "validating payment", p.Message)
+		assert.Equal(t, map[string]string{"field": "amount", "request_id": "req_1"}, p.Params)
+		assert.False(t, p.Retryable.Value)
+		assert.Equal(t, int32(1), p.MarshalCount)
+		assert.Empty(t, p.Stack)
+		assert.Empty(t, p.MessageChain)
+		assert.Empty(t, p.ContextChain)
+	})
+	t.Run("chained params", func(t *testing.T) {
+		cause := RateLimited("too_many_requests", "slow down", map[string]string{"retry_after": "30"})
+		err := NewInternalWithCause(cause, "calling ledger", map[string]string{"reason": "x"}, "")
+		err.Code = "rate_limited.ledger"
+		p := MarshalExternal(err)
+		assert.Equal(t, map[string]string{"retry_after": "30"}, p.Params)
+	})
+	t.Run("outermost wins", func(t *testing.T) {
+		cause := BadRequest("foo", "", map[string]string{"field": "inner"})
+		err := NewInternalWithCause(cause, "", map[string]string{"field": "outer"}, "")
+		err.Code = "bad_request.foo"
+		assert.Equal(t, "outer", MarshalExternal(err).Params["field"])
+	})
+	t.Run("prefixes match whole code parts", func(t *testing.T) {
+		err := New("rate_limitedfoo", "", map[string]string{"retry_after": "30", "request_id": "req_1"})
+		assert.Equal(t, map[string]string{"request_id": "req_1"}, MarshalExternal(err).Params)
+	})
+	t.Run("nothing allowed", func(t *testing.T) {
+		p := MarshalExternal(NotFound("foo", "", map[string]string{"field": "x"}))
+		assert.Nil(t, p.Params)
+	})
+}
@@ -1,7 +1,10 @@
 package terrors
 
 import (
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/monzo/terrors/stack"
 )
@@ -17,10 +20,23 @@ var (
 
 // Wrap takes any error interface and wraps it into an Error.
 // This is useful because an Error contains lots of useful goodies, like the stacktrace of the error.
-// NOTE: If `err` is already an `Error`, it will add the params passed in to the params of the Error
+// NOTE: If `err` is already an `Error`, a copy of it is returned with the params passed in added to its params.
+// If `err` is not an `Error`, any registered CauseTranslator is given the chance to convert it before falling back
+// to the code of the error (for errors with a `Code() string` method), a timeout (for errors from contexts whose
+// deadline was exceeded) or an internal service error.
 // Deprecated: Use Augment instead.
 func Wrap(err error, params map[string]string) error {
-	return WrapWithCode(err, params, ErrInternalService)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*Error); !ok {
+		if translated, ok := translateCause(err, true); ok {
+			// Skip BuildStack() and Wrap()
+			translated.StackFrames = stack.BuildStack(2)
+			return addParams(translated, params)
+		}
+	}
+	return WrapWithCode(err, params, causeCode(err))
 }
 
 // WrapWithCode wraps an error with a custom error code. If `err` is already
@@ -33,6 +49,10 @@ func Wrap(err error, params map[string]string) error {
 // would become
 //
 //	terrors.BadRequest("failed", err.Error(), map[string]string{"foo": "bar"})
+//
+// If `err` is not an `Error` but wraps one (e.g. with `fmt.Errorf("...: %w", terr)`), the new error
+// inherits the retryability and unexpectedness of the wrapped terror, in the same way as
+// NewInternalWithCause. Errors implementing `Retryable() bool` have their retryability honoured too.
 func WrapWithCode(err error, params map[string]string, code string) error {
 	if err == nil {
 		return nil
@@ -41,7 +61,90 @@ func WrapWithCode(err error, params map[string]string, code string) error {
 	case *Error:
 		return addParams(err, params)
 	default:
-		return errorFactory(code, err.Error(), params)
+		newErr := errorFactory(code, err.Error(), params)
+		inheritFlags(newErr, err)
+		return newErr
+	}
+}
+
+// WrapOption configures the behaviour of WrapOpt.
+type WrapOption func(*wrapOptions)
+
+type wrapOptions struct {
+	// nil means the default behaviour of Wrap
+	captureStack *bool
+}
+
+// WithStack controls whether WrapOpt captures a stack trace.
+// Passing false skips the capture when wrapping an error which isn't already a terror. This is useful when the error
+// is known to be wrapped again further up, where the capture cost would be wasted.
+// Passing true captures a stack at the call site for terrors which don't already have one (e.g. those created without
+// a stack), in addition to the usual capture for errors which aren't terrors.
+func WithStack(capture bool) WrapOption {
+	return func(o *wrapOptions) {
+		o.captureStack = &capture
+	}
+}
+
+// WrapOpt behaves like Wrap, but accepts options which control how the error is wrapped.
+// Deprecated: Use Augment instead.
+func WrapOpt(err error, params map[string]string, opts ...WrapOption) error {
+	if err == nil {
+		return nil
+	}
+	o := wrapOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	switch err := err.(type) {
+	case *Error:
+		withParams := addParams(err, params)
+		if o.captureStack != nil && *o.captureStack && len(withParams.StackFrames) == 0 {
+			// Skip BuildStack() and WrapOpt()
+			withParams.StackFrames = stack.BuildStack(2)
+			withParams.StackBuildID = ""
+			withParams.StackOmitted = false
+		}
+		return withParams
+	default:
+		if translated, ok := translateCause(err, true); ok {
+			if o.captureStack == nil || *o.captureStack {
+				// Skip BuildStack() and WrapOpt()
+				translated.StackFrames = stack.BuildStack(2)
+			}
+			return addParams(translated, params)
+		}
+		if o.captureStack != nil && !*o.captureStack {
+			newErr := buildError(causeCode(err), err.Error(), params)
+			inheritFlags(newErr, err)
+			return newErr
+		}
+		newErr := errorFactory(causeCode(err), err.Error(), params)
+		inheritFlags(newErr, err)
+		return newErr
+	}
+}
+
+// inheritFlags copies the retryability and unexpectedness of the first terror found in the chain of
+// `cause` onto `err`, if they've been explicitly set. If there is no terror in the chain, the
+// retryability and unexpectedness of a cause implementing `retryableError` or `unexpectedError`
+// are used instead.
+func inheritFlags(err *Error, cause error) {
+	var terr *Error
+	if errors.As(cause, &terr) {
+		err.inheritRetryable(terr)
+		err.inheritUnexpected(terr)
+		return
+	}
+	if r, ok := cause.(retryableError); ok {
+		err.setRetryable(r.Retryable(), retryabilityReason{
+			kind:   retryabilityInheritedInterface,
+			detail: fmt.Sprintf("%T", r),
+		})
+	}
+	if u, ok := cause.(unexpectedError); ok {
+		err.setUnexpected(u.Unexpected())
 	}
 }
 
@@ -113,27 +216,38 @@ func RateLimited(code, message string, params map[string]string) *Error {
 	return errorFactory(errCode(ErrRateLimited, code), message, params)
 }
 
+// RateLimitedWithRetryAfter creates a new error indicating that the request has been rate-limited, in the same way as
+// RateLimited, with a hint of how long the caller should wait before retrying (see RetryAfter).
+func RateLimitedWithRetryAfter(code, message string, retryAfter time.Duration, params map[string]string) *Error {
+	err := errorFactory(errCode(ErrRateLimited, code), message, params)
+	err.RetryAfter = &retryAfter
+	return err
+}
+
+// Conflict creates a new error indicating that the request conflicts with the current state of the resource, for
+// example an optimistic locking failure or an attempt to create a resource which already exists. It is not retryable
+// by default, as retrying the same request will conflict again unless the state is re-read first.
+func Conflict(code, message string, params map[string]string) *Error {
+	return errorFactory(errCode(ErrConflict, code), message, params)
+}
+
+// NotImplemented creates a new error indicating that the endpoint or feature exists, but is not implemented. It is
+// not retryable by default.
+func NotImplemented(code, message string, params map[string]string) *Error {
+	return errorFactory(errCode(ErrNotImplemented, code), message, params)
+}
+
+// Unavailable creates a new error indicating that a dependency is temporarily unavailable (e.g. a downstream outage),
+// as distinct from a bug in our own code, which is better represented by InternalService. It is retryable by
+// default.
+func Unavailable(code, message string, params map[string]string) *Error {
+	return errorFactory(errCode(ErrUnavailable, code), message, params)
+}
+
 // errorConstructor returns a `*Error` with the specified code, message and params.
 // Builds a stack based on the current call stack
 func errorFactory(code string, message string, params map[string]string) *Error {
-	err := &Error{
-		Code:    ErrUnknown,
-		Message: message,
-		Params:  map[string]string{},
-	}
-	if len(code) > 0 {
-		err.Code = code
-
-		err.IsRetryable = &notRetryable
-		for _, c := range retryableCodes {
-			if PrefixMatches(err, c) {
-				err.IsRetryable = &retryable
-			}
-		}
-	}
-	if params != nil {
-		err.Params = params
-	}
+	err := buildError(code, message, params)
 
 	// TODO pass in context.Context
 
@@ -146,6 +260,41 @@ func errorFactory(code string, message string, params map[string]string) *Error
 	return err
 }
 
+// buildError returns a `*Error` with the specified code, message and params, but without a stack. The error is
+// recorded in the process Tally, if one is installed.
+func buildError(code string, message string, params map[string]string) *Error {
+	err := untrackedError(code, message, params)
+	CurrentProcessTally().Record(err)
+	return err
+}
+
+// untrackedError behaves like buildError, but doesn't record the error. It is used for transient errors which are
+// only built to describe an error which isn't a terror.
+func untrackedError(code string, message string, params map[string]string) *Error {
+	err := &Error{
+		Code:      ErrUnknown,
+		Message:   message,
+		Params:    map[string]string{},
+		createdAt: time.Now(),
+	}
+	if len(code) > 0 {
+		err.Code = code
+		setDefaultRetryability(err)
+		setDefaultUnexpectedness(err)
+	}
+	if params != nil {
+		err.Params = limitParams(params)
+	}
+	applyCodeHook(err)
+	return err
+}
+
+// setDefaultRetryability sets the retryability of err based on its code.
+func setDefaultRetryability(err *Error) {
+	prefix, ok := retryableCodePrefix(err)
+	err.setRetryable(ok, retryabilityReason{kind: retryabilityCodeDefault, detail: prefix})
+}
+
 func errCode(prefix, code string) string {
 	if code == "" {
 		return prefix
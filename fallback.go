@@ -0,0 +1,52 @@
+package terrors
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// FallbackAttemptsParam is the param under which Fallback records how many fallbacks were tried.
+const FallbackAttemptsParam = "fallback_attempts"
+
+// Fallback runs fallbacks in order after an operation failed with err, for graceful degradation, e.g. reading from a
+// cache when the primary store is unavailable. A fallback is only run if the error before it allows falling back,
+// i.e. it is retryable or unavailable: there's no point in falling back from a bad request, which will fail in the
+// same way. Fallback returns nil as soon as a fallback succeeds, and err as is if no fallback was run.
+//
+// Otherwise, the returned error has the code and flags of the last failure, so that callers act on what happened
+// last, and every attempt, starting with err, as its causes.
+func Fallback(err error, fallbacks ...func() error) error {
+	if err == nil {
+		return nil
+	}
+	attempts := multiError{err}
+	last := inspect(err)
+	for _, fallback := range fallbacks {
+		if !canFallBack(last) {
+			break
+		}
+		next := fallback()
+		if next == nil {
+			return nil
+		}
+		attempts = append(attempts, next)
+		last = inspect(next)
+	}
+	if len(attempts) == 1 {
+		return err
+	}
+
+	newErr := errorFactory(last.Code, fmt.Sprintf("%d fallbacks failed", len(attempts)-1), map[string]string{
+		FallbackAttemptsParam: strconv.Itoa(len(attempts) - 1),
+	})
+	newErr.attachCause(attempts)
+	// The flags of the attempts as a whole don't matter, only those of the last
+	newErr.setRetryable(last.Retryable(), retryabilityReason{kind: retryabilityInheritedTerror, detail: last.Code})
+	newErr.IsUnexpected = last.IsUnexpected
+	return newErr
+}
+
+// canFallBack reports whether it's worth running a fallback after err.
+func canFallBack(err *Error) bool {
+	return err.Retryable() || hasCodePrefix(err.Code, ErrUnavailable)
+}
@@ -0,0 +1,68 @@
+package terrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallback(t *testing.T) {
+	primary := Unavailable("store", "store is down", nil)
+	primary.SetIsRetryable(false)
+
+	t.Run("first fallback succeeds", func(t *testing.T) {
+		calls := 0
+		err := Fallback(primary,
+			func() error { calls++; return nil },
+			func() error { calls++; return nil },
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("later fallback succeeds", func(t *testing.T) {
+		err := Fallback(primary,
+			func() error { return Timeout("cache", "cache timed out", nil) },
+			func() error { return nil },
+		)
+		assert.NoError(t, err)
+	})
+
+	t.Run("all fallbacks fail", func(t *testing.T) {
+		err := Fallback(primary,
+			func() error { return Timeout("cache", "cache timed out", nil) },
+			func() error { return errors.New("defaults missing") },
+		)
+		terr := err.(*Error)
+		assert.Equal(t, ErrInternalService, terr.Code)
+		assert.Equal(t, "2", terr.Params[FallbackAttemptsParam])
+		assert.True(t, terr.Retryable())
+		assert.Equal(t, "2 fallbacks failed: 3 errors: [1] unavailable.store: store is down; "+
+			"[2] timeout.cache: cache timed out; [3] defaults missing", terr.ErrorMessage())
+
+		attempts := terr.Unwrap().(multiError)
+		assert.Equal(t, primary, attempts[0])
+		assert.Len(t, attempts, 3)
+	})
+
+	t.Run("error doesn't allow falling back", func(t *testing.T) {
+		calls := 0
+		notFound := NotFound("account", "account not found", nil)
+		assert.Equal(t, notFound, Fallback(notFound, func() error { calls++; return nil }))
+		assert.Equal(t, 0, calls)
+
+		// Nor does the error from a fallback
+		err := Fallback(primary,
+			func() error { calls++; return BadRequest("cache_key", "invalid cache key", nil) },
+			func() error { calls++; return nil },
+		)
+		assert.Equal(t, 1, calls)
+		assert.True(t, Is(err, "bad_request.cache_key"))
+		assert.False(t, IsRetryable(err))
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		assert.NoError(t, Fallback(nil, func() error { return errors.New("not run") }))
+	})
+}
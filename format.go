@@ -0,0 +1,133 @@
+package terrors
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// FormatError implements xerrors.Formatter, so that terrors print rich detail under `%+v` when they are nested
+// inside errors from other libraries which use the xerrors formatting protocol.
+//
+// The terror links of the chain are printed together, in the same form as Error(). In detail mode the params and the
+// stacks from the chain are printed too. The first non-terror cause is returned, so that the caller can continue down
+// the chain.
+func (p *Error) FormatError(printer xerrors.Printer) error {
+	printer.Print(p.legacyErrString())
+	var next error = p.cause
+	maxDepth := CurrentMaxCausalDepth()
+	for depth := 0; next != nil && depth < maxDepth; depth++ {
+		terr, ok := next.(*Error)
+		if !ok {
+			break
+		}
+		printer.Print(": ", terr.Message)
+		next = terr.cause
+	}
+
+	if printer.Detail() {
+		if len(p.Params) > 0 {
+			printer.Print("params:")
+			for _, k := range sortedKeys(p.Params) {
+				printer.Printf("\n  %s=%s", k, p.Params[k])
+			}
+			printer.Print("\n")
+		}
+		printer.Print("stack:", p.StackString())
+	}
+
+	return next
+}
+
+var _ xerrors.Formatter = (*Error)(nil)
+
+// Format implements fmt.Formatter, in the style of github.com/pkg/errors:
+//   - %s and %v print the same as Error()
+//   - %+v prints Error(), followed by the params, message chain, flags and the stack traces of the chain
+//   - %#v prints a Go-like dump of the fields of the error
+//   - %q prints Error() quoted
+func (p *Error) Format(s fmt.State, verb rune) {
+	if p == nil {
+		io.WriteString(s, "<nil>")
+		return
+	}
+	switch verb {
+	case 'v':
+		switch {
+		case s.Flag('+'):
+			io.WriteString(s, p.detailString())
+		case s.Flag('#'):
+			io.WriteString(s, p.goString())
+		default:
+			io.WriteString(s, p.Error())
+		}
+	case 's':
+		io.WriteString(s, p.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", p.Error())
+	default:
+		fmt.Fprintf(s, "%%!%c(*terrors.Error=%s)", verb, p.Error())
+	}
+}
+
+// detailString renders the error for %+v.
+func (p *Error) detailString() string {
+	var b strings.Builder
+	b.WriteString(p.Error())
+	if len(p.Params) > 0 {
+		b.WriteString("\nparams:")
+		for _, k := range sortedKeys(p.Params) {
+			fmt.Fprintf(&b, "\n  %s=%s", k, p.Params[k])
+		}
+	}
+	if len(p.MessageChain) > 0 {
+		b.WriteString("\nmessage chain:")
+		for _, m := range p.MessageChain {
+			fmt.Fprintf(&b, "\n  %s", m)
+		}
+	}
+	fmt.Fprintf(&b, "\nretryable=%t unexpected=%t marshal_count=%d", p.Retryable(), p.Unexpected(), p.MarshalCount)
+	if stack := p.StackString(); stack != "" {
+		b.WriteString("\nstack:")
+		b.WriteString(stack)
+	}
+	return b.String()
+}
+
+// goString renders the error for %#v.
+func (p *Error) goString() string {
+	flag := func(v *bool) string {
+		if v == nil {
+			return "nil"
+		}
+		return fmt.Sprintf("&%t", *v)
+	}
+	var params strings.Builder
+	params.WriteString("map[string]string{")
+	for i, k := range sortedKeys(p.Params) {
+		if i > 0 {
+			params.WriteString(", ")
+		}
+		fmt.Fprintf(&params, "%q:%q", k, p.Params[k])
+	}
+	params.WriteString("}")
+	return fmt.Sprintf(
+		"&terrors.Error{Code:%q, Message:%q, Params:%s, IsRetryable:%s, IsUnexpected:%s, MarshalCount:%d, "+
+			"MessageChain:%#v, StackFrames:%d frames}",
+		p.Code, p.Message, params.String(), flag(p.IsRetryable), flag(p.IsUnexpected), p.MarshalCount,
+		p.MessageChain, len(p.StackFrames))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var _ fmt.Formatter = (*Error)(nil)
@@ -0,0 +1,55 @@
+package terrors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+)
+
+func TestFormatErrorNestedInXerrors(t *testing.T) {
+	base := NotFound("foo", "failed to find foo", map[string]string{"id": "123"})
+	err := xerrors.Errorf("handling request: %w", Augment(base, "looking up foo", nil))
+
+	assert.Equal(t, "handling request: not_found.foo: looking up foo: failed to find foo", fmt.Sprintf("%v", err))
+
+	verbose := fmt.Sprintf("%+v", err)
+	t.Log(verbose)
+	assert.Contains(t, verbose, "not_found.foo: looking up foo")
+	assert.Contains(t, verbose, "id=123")
+	assert.Contains(t, verbose, "TestFormatErrorNestedInXerrors")
+}
+
+func TestFormatErrorForeignCause(t *testing.T) {
+	err := xerrors.Errorf("outer: %w", Augment(assert.AnError, "context", nil))
+	assert.Equal(t, "outer: internal_service: context: "+assert.AnError.Error(), fmt.Sprintf("%v", err))
+}
+
+func TestFormat(t *testing.T) {
+	base := NotFound("foo", "failed to find foo", map[string]string{"id": "123", "a": "b"})
+	err := Augment(base, "looking up foo", nil).(*Error)
+
+	assert.Equal(t, err.Error(), fmt.Sprintf("%v", err))
+	assert.Equal(t, err.Error(), fmt.Sprintf("%s", err))
+	assert.Equal(t, fmt.Sprintf("%q", err.Error()), fmt.Sprintf("%q", err))
+	assert.Equal(t, "%!d(*terrors.Error=not_found.foo: looking up foo: failed to find foo)", fmt.Sprintf("%d", err))
+
+	verbose := fmt.Sprintf("%+v", err)
+	expected := "not_found.foo: looking up foo: failed to find foo\n" +
+		"params:\n  a=b\n  id=123\n" +
+		"message chain:\n  failed to find foo\n" +
+		"retryable=false unexpected=false marshal_count=0\n" +
+		"stack:"
+	assert.True(t, strings.HasPrefix(verbose, expected), verbose)
+	assert.Contains(t, verbose, "TestFormat")
+
+	assert.Equal(t, `&terrors.Error{Code:"not_found.foo", Message:"looking up foo", `+
+		`Params:map[string]string{"a":"b", "id":"123"}, IsRetryable:&false, IsUnexpected:nil, MarshalCount:0, `+
+		`MessageChain:[]string{"failed to find foo"}, StackFrames:0 frames}`,
+		fmt.Sprintf("%#v", err))
+
+	var nilErr *Error
+	assert.Equal(t, "<nil>", fmt.Sprintf("%+v", nilErr))
+}
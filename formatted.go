@@ -0,0 +1,116 @@
+package terrors
+
+import "fmt"
+
+// Params may be passed as the final argument to the formatted constructors (e.g. Newf), to give the error params:
+//
+//	return terrors.NotFoundf("account", "account %s not found", id, terrors.Params{"account_id": id})
+//
+// It is a distinct type, rather than a plain map, so that a map which is meant to be formatted isn't mistaken for
+// params.
+type Params map[string]string
+
+// formatMessage formats the message of a formatted constructor, taking a trailing Params argument as the params of the
+// error rather than an operand.
+func formatMessage(format string, args []interface{}) (string, map[string]string) {
+	var params map[string]string
+	if n := len(args); n > 0 {
+		if p, ok := args[n-1].(Params); ok {
+			params = p
+			args = args[:n-1]
+		}
+	}
+	return fmt.Sprintf(format, args...), params
+}
+
+// Augmentf behaves like Augment, but formats the context message with fmt.Sprintf:
+//
+//	return terrors.Augmentf(err, "processing user %s", id)
+//
+// As with Newf, a final Params argument gives params instead of being formatted. It returns nil if err is nil.
+func Augmentf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	context, params := formatMessage(format, args)
+	return Augment(err, context, params)
+}
+
+// Newf behaves like New, but formats the message with fmt.Sprintf. If the final argument is Params, it gives the
+// params of the error instead of being formatted.
+func Newf(code string, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(code, message, params)
+}
+
+// InternalServicef behaves like InternalService, but formats the message (see Newf).
+func InternalServicef(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrInternalService, code), message, params)
+}
+
+// BadRequestf behaves like BadRequest, but formats the message (see Newf).
+func BadRequestf(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrBadRequest, code), message, params)
+}
+
+// BadResponsef behaves like BadResponse, but formats the message (see Newf).
+func BadResponsef(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrBadResponse, code), message, params)
+}
+
+// Timeoutf behaves like Timeout, but formats the message (see Newf).
+func Timeoutf(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrTimeout, code), message, params)
+}
+
+// NotFoundf behaves like NotFound, but formats the message (see Newf).
+func NotFoundf(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrNotFound, code), message, params)
+}
+
+// Forbiddenf behaves like Forbidden, but formats the message (see Newf).
+func Forbiddenf(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrForbidden, code), message, params)
+}
+
+// Unauthorizedf behaves like Unauthorized, but formats the message (see Newf).
+func Unauthorizedf(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrUnauthorized, code), message, params)
+}
+
+// PreconditionFailedf behaves like PreconditionFailed, but formats the message (see Newf).
+func PreconditionFailedf(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrPreconditionFailed, code), message, params)
+}
+
+// RateLimitedf behaves like RateLimited, but formats the message (see Newf).
+func RateLimitedf(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrRateLimited, code), message, params)
+}
+
+// Conflictf behaves like Conflict, but formats the message (see Newf).
+func Conflictf(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrConflict, code), message, params)
+}
+
+// NotImplementedf behaves like NotImplemented, but formats the message (see Newf).
+func NotImplementedf(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrNotImplemented, code), message, params)
+}
+
+// Unavailablef behaves like Unavailable, but formats the message (see Newf).
+func Unavailablef(code, format string, args ...interface{}) *Error {
+	message, params := formatMessage(format, args)
+	return errorFactory(errCode(ErrUnavailable, code), message, params)
+}
@@ -0,0 +1,64 @@
+package terrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormattedConstructors(t *testing.T) {
+	testCases := []struct {
+		constructor  func(code, format string, args ...interface{}) *Error
+		expectedCode string
+	}{
+		{Newf, "service.foo"},
+		{InternalServicef, "internal_service.service.foo"},
+		{BadRequestf, "bad_request.service.foo"},
+		{BadResponsef, "bad_response.service.foo"},
+		{Timeoutf, "timeout.service.foo"},
+		{NotFoundf, "not_found.service.foo"},
+		{Forbiddenf, "forbidden.service.foo"},
+		{Unauthorizedf, "unauthorized.service.foo"},
+		{PreconditionFailedf, "precondition_failed.service.foo"},
+		{RateLimitedf, "rate_limited.service.foo"},
+		{Conflictf, "conflict.service.foo"},
+		{NotImplementedf, "not_implemented.service.foo"},
+		{Unavailablef, "unavailable.service.foo"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expectedCode, func(t *testing.T) {
+			err := tc.constructor("service.foo", "foo %s missing", "abc", Params{"a": "1"})
+			assert.Equal(t, tc.expectedCode, err.Code)
+			assert.Equal(t, "foo abc missing", err.Message)
+			assert.Equal(t, map[string]string{"a": "1"}, err.Params)
+			assert.Contains(t, err.StackFrames[0].Method, "TestFormattedConstructors")
+		})
+	}
+}
+
+func TestNewfWithoutParams(t *testing.T) {
+	err := NotFoundf("foo", "foo %d of %d missing", 1, 2)
+	assert.Equal(t, "foo 1 of 2 missing", err.Message)
+	assert.Equal(t, map[string]string{}, err.Params)
+
+	// A plain map is an operand, not params
+	err = Newf("foo", "got %v", map[string]string{"a": "1"})
+	assert.Equal(t, "got map[a:1]", err.Message)
+	assert.Equal(t, map[string]string{}, err.Params)
+}
+
+func TestAugmentf(t *testing.T) {
+	assert.Nil(t, Augmentf(nil, "processing user %s", "abc"))
+
+	err := Augmentf(NotFound("foo", "foo not found", nil), "processing user %s", "abc", Params{"user_id": "abc"})
+	terr := err.(*Error)
+	assert.Equal(t, "not_found.foo", terr.Code)
+	assert.Equal(t, "processing user abc", terr.Message)
+	assert.Equal(t, []string{"foo not found"}, terr.MessageChain)
+	assert.Equal(t, map[string]string{"user_id": "abc"}, terr.Params)
+
+	err = Augmentf(assert.AnError, "processing user %s", "abc")
+	assert.True(t, Is(err, ErrInternalService))
+	assert.Equal(t, "processing user abc", err.(*Error).Message)
+}
@@ -3,13 +3,16 @@ module github.com/monzo/terrors
 go 1.17
 
 require (
+	github.com/fxamacker/cbor/v2 v2.4.0
 	github.com/golang/protobuf v1.4.2
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2
+	google.golang.org/protobuf v1.25.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	google.golang.org/protobuf v1.25.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776 // indirect
 )
@@ -0,0 +1,72 @@
+package terrors
+
+// Keys of the extensions of a GraphQLError.
+const (
+	GraphQLCodeExtension         = "code"
+	GraphQLRetryableExtension    = "retryable"
+	GraphQLParamsExtension       = "params"
+	GraphQLMessageChainExtension = "message_chain"
+)
+
+// GraphQLError is an error in the format of the errors of a GraphQL response, as described by the GraphQL
+// specification. It has the same shape as the errors of GraphQL servers such as gqlgen, so it can be returned from a
+// resolver by converting it, e.g. for gqlgen:
+//
+//	g := terrors.MarshalGraphQL(err)
+//	return &gqlerror.Error{Message: g.Message, Extensions: g.Extensions}
+//
+// The location and path of the error are left to the server, which knows where in the query the error happened.
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Error returns the message of the error, so that a GraphQLError can be returned as an error by itself.
+func (e GraphQLError) Error() string {
+	return e.Message
+}
+
+// GraphQLOption configures MarshalGraphQL.
+type GraphQLOption func(*graphQLOptions)
+
+type graphQLOptions struct {
+	messageChain int
+}
+
+// WithGraphQLMessageChain includes up to max messages from the causal chain of the error, outermost first, in the
+// message_chain extension. As with WithProblemMessageChain, the messages are masked by the installed SecretScanner,
+// but may still describe internals, so they should only be included for trusted clients.
+func WithGraphQLMessageChain(max int) GraphQLOption {
+	return func(o *graphQLOptions) {
+		o.messageChain = max
+	}
+}
+
+// MarshalGraphQL returns the GraphQL error describing err. Like MarshalProblem, only what MarshalExternal exposes is
+// included: the message, and in the extensions the code, the retryability and the params allowed with
+// AllowExternalParams (under params, if there are any). If err is not a terror, it is propagated first.
+func MarshalGraphQL(err error, opts ...GraphQLOption) GraphQLError {
+	o := graphQLOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var terr *Error
+	if err != nil {
+		terr = Propagate(err).(*Error)
+	}
+	external := MarshalExternal(terr)
+	extensions := map[string]interface{}{
+		GraphQLCodeExtension:      external.Code,
+		GraphQLRetryableExtension: terr != nil && terr.Retryable(),
+	}
+	if len(external.Params) > 0 {
+		extensions[GraphQLParamsExtension] = external.Params
+	}
+	if o.messageChain > 0 && terr != nil {
+		if chain := maskedMessageChain(terr, o.messageChain); len(chain) > 0 {
+			extensions[GraphQLMessageChainExtension] = chain
+		}
+	}
+	return GraphQLError{Message: external.Message, Extensions: extensions}
+}
@@ -0,0 +1,49 @@
+package terrors
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalGraphQL(t *testing.T) {
+	withExternalParams(t)
+	AllowExternalParams(ErrNotFound, "account_id")
+
+	cause := NotFound("account", "account not found", map[string]string{
+		"account_id": "acc_123",
+		"user_id":    "user_123",
+	})
+	err := Augment(cause, "loading account", nil)
+
+	g := MarshalGraphQL(err)
+	assert.Equal(t, GraphQLError{
+		Message: "loading account",
+		Extensions: map[string]interface{}{
+			GraphQLCodeExtension:      "not_found.account",
+			GraphQLRetryableExtension: false,
+			GraphQLParamsExtension:    map[string]string{"account_id": "acc_123"},
+		},
+	}, g)
+	assert.Equal(t, "loading account", g.Error())
+
+	data, jsonErr := json.Marshal(g)
+	assert.NoError(t, jsonErr)
+	assert.JSONEq(t, `{
+		"message": "loading account",
+		"extensions": {"code": "not_found.account", "retryable": false, "params": {"account_id": "acc_123"}}
+	}`, string(data))
+
+	g = MarshalGraphQL(err, WithGraphQLMessageChain(1))
+	assert.Equal(t, []string{"account not found"}, g.Extensions[GraphQLMessageChainExtension])
+
+	// Errors which aren't terrors are propagated, and have no params
+	g = MarshalGraphQL(errors.New("boom"))
+	assert.Equal(t, "boom", g.Message)
+	assert.Equal(t, map[string]interface{}{
+		GraphQLCodeExtension:      ErrInternalService,
+		GraphQLRetryableExtension: true,
+	}, g.Extensions)
+}
@@ -0,0 +1,104 @@
+package terrors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Params set on the errors returned by Group.Wait.
+const (
+	// GroupFailuresParam is the number of operations in the group which failed.
+	GroupFailuresParam = "group_failures"
+	// GroupTotalParam is the number of operations in the group.
+	GroupTotalParam = "group_total"
+)
+
+// Group runs operations concurrently, in the style of errgroup, for fanning out calls to other services. Unlike
+// errgroup, a retryable failure doesn't abandon the other operations: the context of the group is only cancelled by
+// the first non-retryable failure, as the group can't succeed after one. Every failure is collected, and Wait
+// returns them as a single terror.
+//
+// A Group must be created with NewGroup, and must not be reused after Wait returns.
+type Group struct {
+	cancel func()
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	total  int
+	errs   []*Error
+	failed bool
+}
+
+// NewGroup returns a new Group, and a context derived from ctx which is cancelled when an operation fails with a
+// non-retryable error, or when Wait returns.
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// Go runs fn in a new goroutine. Errors which aren't terrors are propagated.
+func (g *Group) Go(fn func() error) {
+	g.mu.Lock()
+	g.total++
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		err := fn()
+		if err == nil {
+			return
+		}
+		terr := Propagate(err).(*Error)
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if !terr.Retryable() && !g.failed {
+			g.failed = true
+			// Put the first non-retryable error first, as it decides the code of the result
+			g.errs = append([]*Error{terr}, g.errs...)
+			g.cancel()
+			return
+		}
+		g.errs = append(g.errs, terr)
+	}()
+}
+
+// Wait waits for every operation to return, and then returns nil if they all succeeded, or otherwise an error
+// describing the failures:
+//   - if only one operation failed, its error is returned as-is
+//   - otherwise, a new error is returned with the code of the first non-retryable failure (or, if they were all
+//     retryable, of the first failure), whose cause has each of the failures as a branch. It is retryable only if
+//     all of the failures were and unexpected if any of them was, and records the number of failures and operations
+//     in the GroupFailuresParam and GroupTotalParam params
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch len(g.errs) {
+	case 0:
+		return nil
+	case 1:
+		return g.errs[0]
+	}
+
+	primary := g.errs[0]
+	causes := make(multiError, 0, len(g.errs))
+	for _, err := range g.errs {
+		causes = append(causes, err)
+	}
+	err := errorFactory(primary.Code, fmt.Sprintf("%d of %d operations failed", len(g.errs), g.total),
+		map[string]string{
+			GroupFailuresParam: strconv.Itoa(len(g.errs)),
+			GroupTotalParam:    strconv.Itoa(g.total),
+		})
+	err.attachCause(causes)
+	// The flags are those attachCause derived from all of the failures, but the retryability is explained by the
+	// failure which decided the code
+	err.setRetryable(!g.failed, retryabilityReason{kind: retryabilityInheritedTerror, detail: primary.Code})
+	return err
+}
@@ -0,0 +1,93 @@
+package terrors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupSuccess(t *testing.T) {
+	g, _ := NewGroup(context.Background())
+	for i := 0; i < 3; i++ {
+		g.Go(func() error { return nil })
+	}
+	assert.NoError(t, g.Wait())
+}
+
+func TestGroupSingleFailure(t *testing.T) {
+	g, _ := NewGroup(context.Background())
+	orig := NotFound("foo", "foo not found", nil)
+	g.Go(func() error { return nil })
+	g.Go(func() error { return orig })
+	assert.Equal(t, orig, g.Wait())
+}
+
+func TestGroupRetryableFailuresDontCancel(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	done := make(chan struct{})
+	g.Go(func() error {
+		defer close(done)
+		return Timeout("a", "a timed out", nil)
+	})
+	g.Go(func() error {
+		<-done
+		// The context isn't cancelled by a retryable failure
+		assert.NoError(t, ctx.Err())
+		return errors.New("b failed")
+	})
+	g.Go(func() error { return nil })
+
+	err := g.Wait()
+	terr := err.(*Error)
+	assert.True(t, terr.Retryable())
+	assert.True(t, Is(err, ErrTimeout, "a"))
+	assert.Equal(t, "2 of 3 operations failed", terr.Message)
+	assert.Equal(t, "2", terr.Params[GroupFailuresParam])
+	assert.Equal(t, "3", terr.Params[GroupTotalParam])
+	assert.Equal(t, "timeout.a: 2 of 3 operations failed: 2 errors: [1] timeout.a: a timed out; "+
+		"[2] internal_service: b failed: b failed", err.Error())
+	assert.Error(t, ctx.Err())
+}
+
+func TestGroupNonRetryableFailureCancels(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	g.Go(func() error {
+		select {
+		case <-ctx.Done():
+			return Augment(ctx.Err(), "waiting", nil)
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	})
+	g.Go(func() error {
+		return BadRequest("invalid", "invalid request", nil)
+	})
+
+	err := g.Wait()
+	terr := err.(*Error)
+	assert.Equal(t, "bad_request.invalid", terr.Code)
+	assert.False(t, terr.Retryable())
+	assert.Equal(t, "2", terr.Params[GroupFailuresParam])
+	assert.Contains(t, err.Error(), context.Canceled.Error())
+}
+
+func TestGroupUnexpectedIfAnyFailureIs(t *testing.T) {
+	g, _ := NewGroup(context.Background())
+	g.Go(func() error {
+		err := Timeout("a", "a timed out", nil)
+		err.SetIsUnexpected(true)
+		return err
+	})
+	g.Go(func() error {
+		return BadRequest("invalid", "invalid request", nil)
+	})
+
+	// The expected failure decides the code, but not whether the result is unexpected
+	terr := g.Wait().(*Error)
+	assert.Equal(t, "bad_request.invalid", terr.Code)
+	assert.False(t, terr.Retryable())
+	assert.True(t, terr.Unexpected())
+}
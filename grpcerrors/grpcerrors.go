@@ -0,0 +1,102 @@
+// Package grpcerrors recovers panics in gRPC servers, and marshals the errors of their handlers for the transport, in
+// the same way as the httperrors package does for net/http. It doesn't depend on gRPC itself: the error returned to the
+// gRPC runtime is built from the marshalled terror by an Encoder supplied by the server, typically as a status with the
+// terror attached as a detail. Wiring it into a server is a few lines:
+//
+//	encode := func(p *pe.Error) error {
+//		st, err := status.New(codes.Unknown, p.Message).WithDetails(p)
+//		if err != nil {
+//			return status.Error(codes.Internal, p.Message)
+//		}
+//		return st.Err()
+//	}
+//	server := grpc.NewServer(
+//		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+//			handler grpc.UnaryHandler) (interface{}, error) {
+//			return grpcerrors.RecoverUnary(ctx, req, handler, encode)
+//		}),
+//		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo,
+//			handler grpc.StreamHandler) error {
+//			return grpcerrors.RecoverStream(func() error { return handler(srv, ss) }, encode)
+//		}),
+//	)
+package grpcerrors
+
+import (
+	"context"
+
+	"github.com/monzo/terrors"
+	pe "github.com/monzo/terrors/proto"
+)
+
+// UnaryHandler has the signature of grpc.UnaryHandler, so that the handlers passed to unary interceptors can be passed
+// to RecoverUnary as they are.
+type UnaryHandler = func(ctx context.Context, req interface{}) (interface{}, error)
+
+// Encoder converts a marshalled terror into the error returned to the gRPC runtime.
+type Encoder func(*pe.Error) error
+
+// Option configures how errors are marshalled.
+type Option func(*options)
+
+type options struct {
+	omitStack bool
+}
+
+// OmitStack leaves the stack out of marshalled errors (see terrors.OmitStack), for servers whose clients shouldn't see
+// the internals of the service.
+func OmitStack() Option {
+	return func(o *options) {
+		o.omitStack = true
+	}
+}
+
+// RecoverUnary calls handler with ctx and req, for use in a unary server interceptor. Panics in handler are converted
+// into errors with terrors.Recover, so they are unexpected, carry the stack of the goroutine which panicked, and are
+// passed to the PanicHook. Those errors, and any other error returned by handler, are marshalled and converted with
+// encode; errors which aren't terrors are propagated first.
+func RecoverUnary(ctx context.Context, req interface{}, handler UnaryHandler, encode Encoder, opts ...Option) (
+	interface{}, error) {
+	var resp interface{}
+	err := call(func() (err error) {
+		resp, err = handler(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, Encode(err, encode, opts...)
+	}
+	return resp, nil
+}
+
+// RecoverStream calls handler, for use in a stream server interceptor, whose handler needs the server and stream it
+// was given, so is passed in a closure. Panics and errors are converted in the same way as by RecoverUnary.
+func RecoverStream(handler func() error, encode Encoder, opts ...Option) error {
+	if err := call(handler); err != nil {
+		return Encode(err, encode, opts...)
+	}
+	return nil
+}
+
+// Encode marshals err and converts it with encode, for servers which return errors to the gRPC runtime without
+// RecoverUnary or RecoverStream. If err is not a terror, it is propagated first. It returns nil if err is nil.
+func Encode(err error, encode Encoder, opts ...Option) error {
+	if err == nil {
+		return nil
+	}
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var marshalOpts []terrors.MarshalOption
+	if o.omitStack {
+		marshalOpts = append(marshalOpts, terrors.OmitStack())
+	}
+	return encode(terrors.MarshalWithOptions(terrors.Propagate(err).(*terrors.Error), marshalOpts...))
+}
+
+// call calls fn, converting any panic into an error.
+func call(fn func() error) (err error) {
+	defer terrors.Recover(&err)
+	return fn()
+}
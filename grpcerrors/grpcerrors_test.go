@@ -0,0 +1,76 @@
+package grpcerrors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monzo/terrors"
+	pe "github.com/monzo/terrors/proto"
+)
+
+// encodedError stands in for a gRPC status carrying the marshalled terror.
+type encodedError struct {
+	p *pe.Error
+}
+
+func (e *encodedError) Error() string { return e.p.Code }
+
+func encode(p *pe.Error) error {
+	return &encodedError{p: p}
+}
+
+func TestRecoverUnary(t *testing.T) {
+	var hooked []*terrors.Error
+	terrors.SetPanicHook(func(err *terrors.Error) { hooked = append(hooked, err) })
+	t.Cleanup(func() { terrors.SetPanicHook(nil) })
+
+	ctx := context.Background()
+	resp, err := RecoverUnary(ctx, "req", func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "resp to " + req.(string), nil
+	}, encode)
+	assert.NoError(t, err)
+	assert.Equal(t, "resp to req", resp)
+
+	resp, err = RecoverUnary(ctx, "req", func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, terrors.NotFound("account", "account not found", nil)
+	}, encode, OmitStack())
+	assert.Nil(t, resp)
+	var encoded *encodedError
+	if assert.True(t, errors.As(err, &encoded)) {
+		assert.Equal(t, "not_found.account", encoded.p.Code)
+		assert.True(t, encoded.p.StackOmitted)
+	}
+
+	resp, err = RecoverUnary(ctx, "req", func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	}, encode)
+	assert.Nil(t, resp)
+	if assert.True(t, errors.As(err, &encoded)) {
+		assert.Equal(t, terrors.ErrPanic, encoded.p.Code)
+		assert.True(t, encoded.p.Unexpected.Value)
+		assert.NotEmpty(t, encoded.p.Stack)
+	}
+	assert.Len(t, hooked, 1)
+}
+
+func TestRecoverStream(t *testing.T) {
+	assert.NoError(t, RecoverStream(func() error { return nil }, encode))
+
+	err := RecoverStream(func() error { panic(errors.New("boom")) }, encode)
+	var encoded *encodedError
+	if assert.True(t, errors.As(err, &encoded)) {
+		assert.Equal(t, terrors.ErrPanic, encoded.p.Code)
+	}
+
+	err = RecoverStream(func() error { return errors.New("boom") }, encode)
+	if assert.True(t, errors.As(err, &encoded)) {
+		assert.Equal(t, terrors.ErrInternalService, encoded.p.Code)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	assert.Nil(t, Encode(nil, encode))
+}
@@ -0,0 +1,82 @@
+package terrors
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ErrInvalidCode is the code given to errors whose original code was rejected by the CodeHook.
+	ErrInvalidCode = ErrInternalService + ".invalid_code"
+	// RejectedCodeParam records the original code of an error whose code was rejected by the CodeHook.
+	RejectedCodeParam = "rejected_code"
+	// RejectedCodeReasonParam records why the CodeHook rejected an error's code.
+	RejectedCodeReasonParam = "rejected_code_reason"
+)
+
+// CodeHook is called with the code of every error created by the constructors in this package. It returns the code
+// the error should have, which allows codes to be rewritten, or an error to reject the code. The retryability of an
+// error whose code is rewritten is derived from the new code.
+//
+// An error whose code is rejected is given the code ErrInvalidCode instead, with the original code and the reason
+// recorded in params, and is marked as unexpected so that the violation is noticed. Its retryability is still
+// derived from the original code.
+type CodeHook func(code string) (string, error)
+
+var codeHook CodeHook
+
+// SetCodeHook installs a hook which can reject or rewrite the codes of new errors, for example to enforce a naming
+// convention. Passing nil removes the hook, which is the default.
+func SetCodeHook(hook CodeHook) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	codeHook = hook
+}
+
+func currentCodeHook() CodeHook {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return codeHook
+}
+
+// applyCodeHook runs the installed CodeHook (if any) over the code of a newly built error.
+func applyCodeHook(err *Error) {
+	hook := currentCodeHook()
+	if hook == nil {
+		return
+	}
+
+	code, hookErr := hook(err.Code)
+	if hookErr == nil {
+		if code != err.Code {
+			err.Code = code
+			setDefaultRetryability(err)
+			setDefaultUnexpectedness(err)
+		}
+		return
+	}
+
+	params := make(map[string]string, len(err.Params)+2)
+	for k, v := range err.Params {
+		params[k] = v
+	}
+	params[RejectedCodeParam] = err.Code
+	params[RejectedCodeReasonParam] = hookErr.Error()
+	err.Params = params
+	err.Code = ErrInvalidCode
+	err.SetIsUnexpected(true)
+}
+
+// RequireCodePrefix returns a CodeHook which rejects codes that don't start with the given prefix (e.g.
+// "service.payments."), unless they are one of the GenericErrorCodes or a subcode of one.
+func RequireCodePrefix(prefix string) CodeHook {
+	return func(code string) (string, error) {
+		if strings.HasPrefix(code, prefix) {
+			return code, nil
+		}
+		if isKnownCode(code) {
+			return code, nil
+		}
+		return code, fmt.Errorf("code %q is not a generic code and does not start with %q", code, prefix)
+	}
+}
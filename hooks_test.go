@@ -0,0 +1,72 @@
+package terrors
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withCodeHook(t *testing.T, hook CodeHook) {
+	SetCodeHook(hook)
+	t.Cleanup(func() { SetCodeHook(nil) })
+}
+
+func TestCodeHookRewrite(t *testing.T) {
+	withCodeHook(t, func(code string) (string, error) {
+		return strings.Replace(code, "legacy_", "", 1), nil
+	})
+
+	err := New("legacy_foo", "bar", nil)
+	assert.Equal(t, "foo", err.Code)
+	assert.Equal(t, "bad_request.foo", BadRequest("legacy_foo", "bar", nil).Code)
+
+	// Retryability follows the new code
+	assert.True(t, New("legacy_timeout", "bar", nil).Retryable())
+}
+
+func TestCodeHookReject(t *testing.T) {
+	withCodeHook(t, func(code string) (string, error) {
+		if code == "bad_request.forbidden_code" {
+			return code, errors.New("nope")
+		}
+		return code, nil
+	})
+
+	params := map[string]string{"foo": "bar"}
+	err := BadRequest("forbidden_code", "message", params)
+	assert.Equal(t, ErrInvalidCode, err.Code)
+	assert.Equal(t, "message", err.Message)
+	assert.Equal(t, map[string]string{
+		"foo":                   "bar",
+		RejectedCodeParam:       "bad_request.forbidden_code",
+		RejectedCodeReasonParam: "nope",
+	}, err.Params)
+	assert.True(t, err.Unexpected())
+	// Retryability comes from the original code
+	assert.False(t, err.Retryable())
+	// The caller's params are left alone
+	assert.Equal(t, map[string]string{"foo": "bar"}, params)
+
+	assert.Equal(t, "bad_request.other_code", BadRequest("other_code", "message", nil).Code)
+}
+
+func TestRequireCodePrefix(t *testing.T) {
+	hook := RequireCodePrefix("service.payments.")
+	cases := []struct {
+		code  string
+		valid bool
+	}{
+		{"service.payments.card_declined", true},
+		{ErrNotFound, true},
+		{"not_found.card", true},
+		{"service.accounts.missing", false},
+		{"card_declined", false},
+		{"not_foundish", false},
+	}
+	for _, tc := range cases {
+		_, err := hook(tc.code)
+		assert.Equal(t, tc.valid, err == nil, tc.code)
+	}
+}
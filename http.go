@@ -0,0 +1,222 @@
+package terrors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/monzo/terrors/httpbody"
+	pe "github.com/monzo/terrors/proto"
+)
+
+// Params set by SniffHTTPResponse.
+const (
+	// HTTPBodyPrefixParam holds a bounded, sanitised prefix of the response body.
+	HTTPBodyPrefixParam = "http_body_prefix"
+	// HTTPRequestIDParam holds the request ID reported by the server, if any.
+	HTTPRequestIDParam = "http_request_id"
+	// HTTPRetryAfterParam holds the raw value of the Retry-After header, if any.
+	HTTPRetryAfterParam = "http_retry_after"
+)
+
+// DefaultHTTPRequestIDHeaders are the headers searched for a request ID when HTTPSniffOptions.RequestIDHeaders is
+// empty, in order.
+var DefaultHTTPRequestIDHeaders = []string{"X-Request-Id", "Request-Id", "X-Amzn-Requestid", "X-Correlation-Id"}
+
+// HTTPSniffOptions controls what SniffHTTPResponse captures from a response.
+type HTTPSniffOptions struct {
+	// MaxBodyBytes is the maximum number of bytes of the body to capture. Zero disables body capture.
+	MaxBodyBytes int
+	// AllStatuses captures the body of every response, rather than only those with a 5xx status.
+	AllStatuses bool
+	// RequestIDHeaders are the headers searched for a request ID, in order. If empty,
+	// DefaultHTTPRequestIDHeaders is used.
+	RequestIDHeaders []string
+}
+
+// sniffScanner masks secrets in captured bodies, regardless of whether a SecretScanner has been installed: third
+// party bodies are outside our control, so we don't trust them not to echo back sensitive request data.
+var sniffScanner = &SecretScanner{Detectors: DefaultSecretDetectors()}
+
+// SniffHTTPResponse returns params describing resp which are useful when debugging failures of third party APIs: the
+// request ID and Retry-After headers, and (if enabled with MaxBodyBytes) a prefix of the body of 5xx responses.
+// The captured body is stripped of control characters and invalid UTF-8, and has secrets masked.
+// The body of resp is left readable from the start, so it can still be decoded after sniffing.
+func SniffHTTPResponse(resp *http.Response, opts HTTPSniffOptions) map[string]string {
+	params := map[string]string{}
+	if resp == nil {
+		return params
+	}
+
+	headers := opts.RequestIDHeaders
+	if len(headers) == 0 {
+		headers = DefaultHTTPRequestIDHeaders
+	}
+	for _, h := range headers {
+		if v := resp.Header.Get(h); v != "" {
+			params[HTTPRequestIDParam] = sanitizeSniffed(v)
+			break
+		}
+	}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		params[HTTPRetryAfterParam] = sanitizeSniffed(v)
+	}
+
+	if opts.MaxBodyBytes > 0 && resp.Body != nil && (opts.AllStatuses || resp.StatusCode >= 500) {
+		prefix, err := io.ReadAll(io.LimitReader(resp.Body, int64(opts.MaxBodyBytes)))
+		// Put back what we read, so that the caller sees the whole body
+		resp.Body = &sniffedBody{
+			Reader: io.MultiReader(bytes.NewReader(prefix), resp.Body),
+			Closer: resp.Body,
+		}
+		if err == nil && len(prefix) > 0 {
+			body := sanitizeSniffed(string(prefix))
+			if len(prefix) == opts.MaxBodyBytes {
+				body += truncationMarker
+			}
+			params[HTTPBodyPrefixParam] = body
+		}
+	}
+	return params
+}
+
+type sniffedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// sanitizeSniffed makes s safe to put into a param: invalid UTF-8 is dropped, control characters (including
+// newlines) are replaced with spaces, and secrets are masked.
+func sanitizeSniffed(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+	masked, _ := sniffScanner.Mask(strings.TrimSpace(s))
+	return masked
+}
+
+// maxHTTPErrorBodyBytes is the most of the body which FromHTTPResponse decodes. Larger bodies aren't errors we sent.
+const maxHTTPErrorBodyBytes = 1 << 20
+
+// HTTPResponseOption configures FromHTTPResponse.
+type HTTPResponseOption func(*httpResponseOptions)
+
+type httpResponseOptions struct {
+	sniff HTTPSniffOptions
+}
+
+// WithHTTPSniffOptions sets what FromHTTPResponse captures from responses whose body isn't an error (see
+// SniffHTTPResponse). By default, no body is captured, as the bodies of third party APIs may hold personal data; pass
+// e.g. HTTPSniffOptions{MaxBodyBytes: 512} to capture a prefix of the body of 5xx responses. The RequestIDHeaders are
+// used for every response.
+func WithHTTPSniffOptions(opts HTTPSniffOptions) HTTPResponseOption {
+	return func(o *httpResponseOptions) {
+		o.sniff = opts
+	}
+}
+
+// FromHTTPResponse returns the error described by resp, for clients of services which write errors with
+// WriteHTTPError (or any other server which writes marshalled terrors over plain HTTP). It returns nil if resp is nil,
+// or if it succeeded (with a status below 400) and doesn't carry the Terror header.
+//
+// The body is decoded according to its Content-Type: protobuf as the proto codec encodes it, problem+json as
+// described by UnmarshalProblem, and, if the response carries the Terror header, anything else as the JSON body of the
+// httpbody package (so that JSON errors of other APIs aren't mistaken for terrors). A decoded terror keeps
+// its code, params, and retryability and unexpectedness flags, and the marshal count which was incremented when it was
+// marshalled, so that ShouldRetryDownstream works as it does for other transports. If the body isn't an error, the
+// error is built from the status instead, as with FromHTTPStatus, and can record a prefix of the body if enabled with
+// WithHTTPSniffOptions; such errors, and those decoded from problem documents, have a marshal count of one, as they
+// have crossed one process boundary.
+//
+// The request ID and Retry-After headers are recorded as by SniffHTTPResponse, unless the decoded error has params of
+// the same name. The body of resp is left readable from the start, and isn't closed.
+func FromHTTPResponse(resp *http.Response, opts ...HTTPResponseOption) *Error {
+	isTerror := resp != nil && resp.Header.Get(TerrorHeader) != ""
+	if resp == nil || (resp.StatusCode < 400 && !isTerror) {
+		return nil
+	}
+	o := httpResponseOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var data []byte
+	if resp.Body != nil {
+		var err error
+		data, err = io.ReadAll(io.LimitReader(resp.Body, maxHTTPErrorBodyBytes))
+		// Put back what we read, so that the caller sees the whole body
+		resp.Body = &sniffedBody{
+			Reader: io.MultiReader(bytes.NewReader(data), resp.Body),
+			Closer: resp.Body,
+		}
+		if err != nil {
+			data = nil
+		}
+	}
+
+	terr := decodeHTTPErrorBody(resp.Header.Get("Content-Type"), data, isTerror)
+	if terr == nil {
+		params := SniffHTTPResponse(resp, o.sniff)
+		params[HTTPStatusParam] = strconv.Itoa(resp.StatusCode)
+		message := http.StatusText(resp.StatusCode)
+		if message == "" {
+			message = fmt.Sprintf("unexpected HTTP status %d", resp.StatusCode)
+		}
+		terr = errorFactory(httpStatusCode(resp.StatusCode), message, params)
+		terr.MarshalCount = 1
+		return terr
+	}
+
+	if terr.MarshalCount < 1 {
+		terr.MarshalCount = 1
+	}
+	// Params decoded from the body take precedence over those from the headers
+	headerParams := SniffHTTPResponse(resp, HTTPSniffOptions{RequestIDHeaders: o.sniff.RequestIDHeaders})
+	terr.Params = mergeParams(headerParams, terr.Params)
+	return terr
+}
+
+// decodeHTTPErrorBody decodes the body of an HTTP error response with the given content type, or returns nil if it
+// isn't an error. The JSON body of the httpbody package is only decoded if isTerror, as it is too loosely defined to be
+// recognised otherwise.
+func decodeHTTPErrorBody(contentType string, data []byte, isTerror bool) *Error {
+	if len(data) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case ContentTypeProto, "application/x-protobuf":
+		p := &pe.Error{}
+		if err := proto.Unmarshal(data, p); err != nil || p.Code == "" {
+			return nil
+		}
+		return Unmarshal(p)
+	case ContentTypeProblem:
+		var p ProblemDetails
+		if err := json.Unmarshal(data, &p); err != nil || (p.Code == "" && p.Status == 0) {
+			return nil
+		}
+		return UnmarshalProblem(p)
+	default:
+		if !isTerror {
+			return nil
+		}
+		p, err := httpbody.Decode(data)
+		if err != nil {
+			return nil
+		}
+		return Unmarshal(p)
+	}
+}
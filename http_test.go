@@ -0,0 +1,175 @@
+package terrors
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSniffResponse(status int, body string, headers map[string]string) *http.Response {
+	resp := &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+	for k, v := range headers {
+		resp.Header.Set(k, v)
+	}
+	return resp
+}
+
+func TestSniffHTTPResponse(t *testing.T) {
+	t.Run("5xx body and headers", func(t *testing.T) {
+		body := "upstream\nexploded for bob@example.com\x00 with a long tail"
+		resp := newSniffResponse(502, body, map[string]string{
+			"X-Request-Id": "req-123",
+			"Retry-After":  "30",
+		})
+		params := SniffHTTPResponse(resp, HTTPSniffOptions{MaxBodyBytes: 40})
+		assert.Equal(t, map[string]string{
+			HTTPBodyPrefixParam: "upstream exploded for [REDACTED:email]  w...(truncated)",
+			HTTPRequestIDParam:  "req-123",
+			HTTPRetryAfterParam: "30",
+		}, params)
+
+		// The body can still be read in full
+		read, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(read))
+		assert.NoError(t, resp.Body.Close())
+	})
+	t.Run("short body isn't marked as truncated", func(t *testing.T) {
+		resp := newSniffResponse(500, "oops", nil)
+		params := SniffHTTPResponse(resp, HTTPSniffOptions{MaxBodyBytes: 40})
+		assert.Equal(t, map[string]string{HTTPBodyPrefixParam: "oops"}, params)
+	})
+	t.Run("body capture disabled by default", func(t *testing.T) {
+		resp := newSniffResponse(500, "oops", nil)
+		assert.Empty(t, SniffHTTPResponse(resp, HTTPSniffOptions{}))
+	})
+	t.Run("non-5xx body only captured with AllStatuses", func(t *testing.T) {
+		resp := newSniffResponse(404, "missing", nil)
+		assert.Empty(t, SniffHTTPResponse(resp, HTTPSniffOptions{MaxBodyBytes: 40}))
+
+		resp = newSniffResponse(404, "missing", nil)
+		params := SniffHTTPResponse(resp, HTTPSniffOptions{MaxBodyBytes: 40, AllStatuses: true})
+		assert.Equal(t, "missing", params[HTTPBodyPrefixParam])
+	})
+	t.Run("custom request ID headers", func(t *testing.T) {
+		resp := newSniffResponse(500, "", map[string]string{
+			"X-Request-Id": "ignored",
+			"X-Trace":      "trace-1",
+		})
+		params := SniffHTTPResponse(resp, HTTPSniffOptions{RequestIDHeaders: []string{"X-Trace"}})
+		assert.Equal(t, map[string]string{HTTPRequestIDParam: "trace-1"}, params)
+	})
+	t.Run("nil", func(t *testing.T) {
+		assert.Empty(t, SniffHTTPResponse(nil, HTTPSniffOptions{MaxBodyBytes: 40}))
+	})
+}
+
+// recordedResponse returns the response written by WriteHTTPError for err, with the given Accept header.
+func recordedResponse(err error, accept string) *http.Response {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", accept)
+	rec := httptest.NewRecorder()
+	WriteHTTPError(rec, req, err)
+	return rec.Result()
+}
+
+func TestFromHTTPResponse(t *testing.T) {
+	orig := NotFound("account", "account not found", map[string]string{"account_id": "acc_123"})
+	orig.SetIsUnexpected(true)
+
+	for _, accept := range []string{ContentTypeJSON, ContentTypeProto} {
+		t.Run(accept, func(t *testing.T) {
+			resp := recordedResponse(orig, accept)
+			resp.Header.Set("X-Request-Id", "req-123")
+
+			err := FromHTTPResponse(resp)
+			assert.Equal(t, "not_found.account", err.Code)
+			assert.Equal(t, "account not found", err.Message)
+			assert.Equal(t, map[string]string{"account_id": "acc_123", HTTPRequestIDParam: "req-123"}, err.Params)
+			assert.False(t, err.Retryable())
+			assert.True(t, err.Unexpected())
+			assert.Equal(t, 1, err.MarshalCount)
+			assert.NotEmpty(t, err.StackFrames)
+
+			// The body can still be read
+			body, readErr := io.ReadAll(resp.Body)
+			assert.NoError(t, readErr)
+			assert.NotEmpty(t, body)
+		})
+	}
+
+	t.Run("problem", func(t *testing.T) {
+		err := FromHTTPResponse(recordedResponse(orig, ContentTypeProblem))
+		assert.Equal(t, "not_found.account", err.Code)
+		assert.Equal(t, "account not found", err.Message)
+		assert.Equal(t, 1, err.MarshalCount)
+	})
+
+	t.Run("not a terror", func(t *testing.T) {
+		resp := newSniffResponse(http.StatusServiceUnavailable, "<html>down for maintenance</html>", map[string]string{
+			"Content-Type": "text/html",
+			"Retry-After":  "30",
+		})
+		err := FromHTTPResponse(resp)
+		assert.Equal(t, ErrUnavailable, err.Code)
+		assert.Equal(t, "Service Unavailable", err.Message)
+		assert.Equal(t, map[string]string{
+			HTTPStatusParam:     "503",
+			HTTPRetryAfterParam: "30",
+		}, err.Params)
+		assert.True(t, err.Retryable())
+		assert.Equal(t, 1, err.MarshalCount)
+		assert.Contains(t, err.StackFrames[0].Method, "TestFromHTTPResponse")
+	})
+
+	t.Run("JSON which isn't an error", func(t *testing.T) {
+		resp := newSniffResponse(http.StatusBadRequest, `{"error": "bad"}`, map[string]string{
+			"Content-Type": ContentTypeJSON,
+		})
+		err := FromHTTPResponse(resp)
+		assert.Equal(t, ErrBadRequest, err.Code)
+		assert.NotContains(t, err.Params, HTTPBodyPrefixParam)
+	})
+
+	t.Run("terror JSON without the Terror header", func(t *testing.T) {
+		resp := recordedResponse(orig, ContentTypeJSON)
+		resp.Header.Del(TerrorHeader)
+		err := FromHTTPResponse(resp)
+		assert.Equal(t, ErrNotFound, err.Code)
+		assert.Equal(t, "404", err.Params[HTTPStatusParam])
+		assert.NotContains(t, err.Params, HTTPBodyPrefixParam)
+	})
+
+	t.Run("body capture turned on", func(t *testing.T) {
+		opt := WithHTTPSniffOptions(HTTPSniffOptions{MaxBodyBytes: 512, RequestIDHeaders: []string{"X-Trace-Id"}})
+		resp := newSniffResponse(http.StatusServiceUnavailable, "down for maintenance", map[string]string{
+			"X-Trace-Id": "trace-123",
+		})
+		err := FromHTTPResponse(resp, opt)
+		assert.Equal(t, ErrUnavailable, err.Code)
+		assert.Equal(t, map[string]string{
+			HTTPStatusParam:     "503",
+			HTTPRequestIDParam:  "trace-123",
+			HTTPBodyPrefixParam: "down for maintenance",
+		}, err.Params)
+
+		// Only 5xx bodies are captured unless AllStatuses is set
+		resp = newSniffResponse(http.StatusBadRequest, `{"error": "bad"}`, map[string]string{
+			"Content-Type": ContentTypeJSON,
+		})
+		assert.NotContains(t, FromHTTPResponse(resp, opt).Params, HTTPBodyPrefixParam)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		assert.Nil(t, FromHTTPResponse(nil))
+		assert.Nil(t, FromHTTPResponse(newSniffResponse(http.StatusOK, "ok", nil)))
+	})
+}
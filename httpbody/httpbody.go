@@ -0,0 +1,212 @@
+// Package httpbody defines the JSON body with which terrors are carried over plain HTTP, so that an error returned by
+// one service is reconstructed losslessly by another, whatever HTTP stack each of them uses.
+//
+// The body is the JSON form of the marshalled error (pe.Error), so it is byte-for-byte the same as the output of the
+// terrors JSON codec:
+//
+//	{
+//	  "code": "not_found.account",
+//	  "message": "account not found",
+//	  "params": {"account_id": "acc_123"},
+//	  "stack": [{"filename": "account.go", "line": 42, "method": "account.Load"}],
+//	  "retryable": {},
+//	  "marshal_count": 1,
+//	  "message_chain": ["record not found"],
+//	  "unexpected": {"value": true},
+//	  "context_chain": [{"code": "not_found.record", "message": "record not found"}],
+//	  "stack_build_id": "...",
+//	  "stack_omitted": true
+//	}
+//
+// Every field is optional except the code. The retryable and unexpected flags are objects, so that an unset flag
+// (absent) can be distinguished from one explicitly set to false (an empty object).
+//
+// This package only depends on the protobuf form of errors. Use terrors.Marshal and terrors.Unmarshal to convert to
+// and from *terrors.Error.
+package httpbody
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+// ContentType is the content type of the body.
+const ContentType = "application/json"
+
+// ErrNotError is returned (wrapped) by Decode when the data is valid JSON, but doesn't describe an error.
+var ErrNotError = errors.New("body is not an error")
+
+// Body is the JSON body of an HTTP error response.
+type Body struct {
+	Code         string            `json:"code,omitempty"`
+	Message      string            `json:"message,omitempty"`
+	Params       map[string]string `json:"params,omitempty"`
+	Stack        []StackFrame      `json:"stack,omitempty"`
+	Retryable    *Flag             `json:"retryable,omitempty"`
+	MarshalCount int32             `json:"marshal_count,omitempty"`
+	MessageChain []string          `json:"message_chain,omitempty"`
+	Unexpected   *Flag             `json:"unexpected,omitempty"`
+	ContextChain []ContextEntry    `json:"context_chain,omitempty"`
+	StackBuildID string            `json:"stack_build_id,omitempty"`
+	StackOmitted bool              `json:"stack_omitted,omitempty"`
+	RetryInfo    *RetryInfo        `json:"retry_info,omitempty"`
+}
+
+// StackFrame is a frame of the stack of the error.
+type StackFrame struct {
+	Filename   string `json:"filename,omitempty"`
+	Line       int32  `json:"line,omitempty"`
+	Method     string `json:"method,omitempty"`
+	PC         uint64 `json:"pc,omitempty"`
+	SourceHash string `json:"source_hash,omitempty"`
+}
+
+// Flag is an explicitly set boolean flag of the error.
+type Flag struct {
+	Value bool `json:"value,omitempty"`
+}
+
+// RetryInfo holds the structured hints for retrying the error.
+type RetryInfo struct {
+	RetryAfterMs int64 `json:"retry_after_ms,omitempty"`
+	BackoffMs    int64 `json:"backoff_ms,omitempty"`
+	Attempt      int32 `json:"attempt,omitempty"`
+	MaxAttempts  int32 `json:"max_attempts,omitempty"`
+}
+
+// ContextEntry is an entry of the context chain of the error.
+type ContextEntry struct {
+	Code    string            `json:"code,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+}
+
+// FromProto returns the body describing p.
+func FromProto(p *pe.Error) *Body {
+	if p == nil {
+		return &Body{}
+	}
+	b := &Body{
+		Code:         p.Code,
+		Message:      p.Message,
+		Params:       p.Params,
+		Retryable:    flagFromProto(p.Retryable),
+		MarshalCount: p.MarshalCount,
+		MessageChain: p.MessageChain,
+		Unexpected:   flagFromProto(p.Unexpected),
+		StackBuildID: p.StackBuildId,
+		StackOmitted: p.StackOmitted,
+	}
+	if r := p.RetryInfo; r != nil {
+		b.RetryInfo = &RetryInfo{
+			RetryAfterMs: r.RetryAfterMs,
+			BackoffMs:    r.BackoffMs,
+			Attempt:      r.Attempt,
+			MaxAttempts:  r.MaxAttempts,
+		}
+	}
+	if p.Stack != nil {
+		b.Stack = make([]StackFrame, 0, len(p.Stack))
+		for _, f := range p.Stack {
+			b.Stack = append(b.Stack, StackFrame{
+				Filename:   f.Filename,
+				Line:       f.Line,
+				Method:     f.Method,
+				PC:         f.Pc,
+				SourceHash: f.SourceHash,
+			})
+		}
+	}
+	if p.ContextChain != nil {
+		b.ContextChain = make([]ContextEntry, 0, len(p.ContextChain))
+		for _, e := range p.ContextChain {
+			b.ContextChain = append(b.ContextChain, ContextEntry{
+				Code:    e.Code,
+				Message: e.Message,
+				Params:  e.Params,
+			})
+		}
+	}
+	return b
+}
+
+// Proto returns the protobuf form of the error described by b.
+func (b *Body) Proto() *pe.Error {
+	p := &pe.Error{
+		Code:         b.Code,
+		Message:      b.Message,
+		Params:       b.Params,
+		Retryable:    b.Retryable.proto(),
+		MarshalCount: b.MarshalCount,
+		MessageChain: b.MessageChain,
+		Unexpected:   b.Unexpected.proto(),
+		StackBuildId: b.StackBuildID,
+		StackOmitted: b.StackOmitted,
+	}
+	if r := b.RetryInfo; r != nil {
+		p.RetryInfo = &pe.RetryInfo{
+			RetryAfterMs: r.RetryAfterMs,
+			BackoffMs:    r.BackoffMs,
+			Attempt:      r.Attempt,
+			MaxAttempts:  r.MaxAttempts,
+		}
+	}
+	if b.Stack != nil {
+		p.Stack = make([]*pe.StackFrame, 0, len(b.Stack))
+		for _, f := range b.Stack {
+			p.Stack = append(p.Stack, &pe.StackFrame{
+				Filename:   f.Filename,
+				Line:       f.Line,
+				Method:     f.Method,
+				Pc:         f.PC,
+				SourceHash: f.SourceHash,
+			})
+		}
+	}
+	if b.ContextChain != nil {
+		p.ContextChain = make([]*pe.ContextEntry, 0, len(b.ContextChain))
+		for _, e := range b.ContextChain {
+			p.ContextChain = append(p.ContextChain, &pe.ContextEntry{
+				Code:    e.Code,
+				Message: e.Message,
+				Params:  e.Params,
+			})
+		}
+	}
+	return p
+}
+
+func flagFromProto(v *pe.BoolValue) *Flag {
+	if v == nil {
+		return nil
+	}
+	return &Flag{Value: v.Value}
+}
+
+func (f *Flag) proto() *pe.BoolValue {
+	if f == nil {
+		return nil
+	}
+	return &pe.BoolValue{Value: f.Value}
+}
+
+// Encode encodes a marshalled error as the body of an HTTP response.
+func Encode(p *pe.Error) ([]byte, error) {
+	return json.Marshal(FromProto(p))
+}
+
+// Decode decodes the body of an HTTP response into a marshalled error. It fails if data isn't JSON, or if it doesn't
+// have a code, in which case the error wraps ErrNotError.
+func Decode(data []byte) (*pe.Error, error) {
+	b := &Body{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("decoding error body: %w", err)
+	}
+	if b.Code == "" {
+		return nil, fmt.Errorf("decoding error body: %w", ErrNotError)
+	}
+	return b.Proto(), nil
+}
@@ -0,0 +1,71 @@
+package httpbody
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+func testError() *pe.Error {
+	return &pe.Error{
+		Code:    "not_found.account",
+		Message: "account not found",
+		Params:  map[string]string{"account_id": "acc_123"},
+		Stack: []*pe.StackFrame{
+			{Filename: "account.go", Line: 42, Method: "account.Load", Pc: 0x1234, SourceHash: "abc"},
+		},
+		Retryable:    &pe.BoolValue{Value: false},
+		MarshalCount: 2,
+		MessageChain: []string{"record not found"},
+		Unexpected:   &pe.BoolValue{Value: true},
+		ContextChain: []*pe.ContextEntry{
+			{Code: "not_found.record", Message: "record not found", Params: map[string]string{"table": "accounts"}},
+		},
+		StackBuildId: "build-1",
+		StackOmitted: true,
+		RetryInfo:    &pe.RetryInfo{RetryAfterMs: 1500, BackoffMs: 200, Attempt: 2, MaxAttempts: 5},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	orig := testError()
+	data, err := Encode(orig)
+	assert.NoError(t, err)
+
+	decoded, err := Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, orig, decoded)
+}
+
+func TestUnsetFlags(t *testing.T) {
+	decoded, err := Decode([]byte(`{"code": "internal_service"}`))
+	assert.NoError(t, err)
+	assert.Nil(t, decoded.Retryable)
+	assert.Nil(t, decoded.Unexpected)
+
+	decoded, err = Decode([]byte(`{"code": "internal_service", "retryable": {}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &pe.BoolValue{Value: false}, decoded.Retryable)
+}
+
+func TestSameAsProtoJSON(t *testing.T) {
+	// The body is the JSON form of pe.Error, so that it's interchangeable with the JSON codec
+	orig := testError()
+	data, err := Encode(orig)
+	assert.NoError(t, err)
+	protoJSON, err := json.Marshal(orig)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(protoJSON), string(data))
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	_, err := Decode([]byte(`not json`))
+	assert.Error(t, err)
+
+	_, err = Decode([]byte(`{"message": "hello"}`))
+	assert.True(t, errors.Is(err, ErrNotError))
+}
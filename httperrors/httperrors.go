@@ -0,0 +1,125 @@
+// Package httperrors serves terrors from net/http handlers. Handlers return their errors rather than writing them, and
+// Handler writes them as JSON error responses (see the httpbody package) with the status of their code, recovering
+// panics in the same way:
+//
+//	http.Handle("/accounts", httperrors.Handler(func(w http.ResponseWriter, r *http.Request) error {
+//		account, err := loadAccount(r.Context(), r.URL.Query().Get("account_id"))
+//		if err != nil {
+//			return terrors.Augment(err, "failed to load account", nil)
+//		}
+//		return json.NewEncoder(w).Encode(account)
+//	}))
+//
+// Use terrors.WriteHTTPError instead to negotiate the format of the body with the client.
+package httperrors
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/monzo/terrors"
+	"github.com/monzo/terrors/httpbody"
+)
+
+// HandlerFunc is an HTTP handler which returns an error instead of writing it to the response.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Option configures how errors are written.
+type Option func(*options)
+
+type options struct {
+	omitStack bool
+}
+
+// OmitStack leaves the stack out of error responses (see terrors.OmitStack), for servers whose clients shouldn't see
+// the internals of the service.
+func OmitStack() Option {
+	return func(o *options) {
+		o.omitStack = true
+	}
+}
+
+// WriteError writes err to w as a JSON error response, as defined by the httpbody package, with the status of its code
+// (see terrors.HTTPStatus) and the Terror header. If err is retryable or rate limited and carries a hint of when to
+// retry (see terrors.RetryAfter), the hint is also set in the Retry-After header, rounded up to whole seconds. If
+// err is not a terror, it is propagated first. A nil error writes nothing.
+func WriteError(w http.ResponseWriter, err error, opts ...Option) {
+	if err == nil {
+		return
+	}
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	terr := terrors.Propagate(err).(*terrors.Error)
+	var marshalOpts []terrors.MarshalOption
+	if o.omitStack {
+		marshalOpts = append(marshalOpts, terrors.OmitStack())
+	}
+	body, err := httpbody.Encode(terrors.MarshalWithOptions(terr, marshalOpts...))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", httpbody.ContentType)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	header.Set(terrors.TerrorHeader, "1")
+	if retryAfter, ok := terrors.RetryAfter(terr); ok && (terr.Retryable() || terr.PrefixMatches(terrors.ErrRateLimited)) {
+		header.Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	}
+	w.WriteHeader(terrors.HTTPStatus(terr))
+	w.Write(body)
+}
+
+// Handler returns an http.Handler which calls h, and writes any error it returns with WriteError. Panics in h are
+// converted into errors with terrors.Recover, so they are passed to the PanicHook and written in the same way, except
+// for panics with http.ErrAbortHandler, which are passed on.
+//
+// If h has already started the response when it returns an error, the error can't be written, and is dropped.
+func Handler(h HandlerFunc, opts ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		if err := serve(h, rw, r); err != nil && !rw.started {
+			WriteError(w, err, opts...)
+		}
+	})
+}
+
+// serve calls h, converting any panic into an error.
+func serve(h HandlerFunc, w http.ResponseWriter, r *http.Request) (err error) {
+	defer terrors.Recover(&err)
+	return h(w, r)
+}
+
+// responseWriter records whether the response has been started.
+type responseWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, if the underlying writer does.
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.started = true
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,133 @@
+package httperrors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monzo/terrors"
+	"github.com/monzo/terrors/httpbody"
+)
+
+func TestWriteError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, terrors.NotFound("account", "account not found", map[string]string{"account_id": "acc_123"}))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, httpbody.ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "1", rec.Header().Get(terrors.TerrorHeader))
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+
+	p, err := httpbody.Decode(rec.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "not_found.account", p.Code)
+	assert.Equal(t, map[string]string{"account_id": "acc_123"}, p.Params)
+	assert.NotEmpty(t, p.Stack)
+
+	rec = httptest.NewRecorder()
+	WriteError(rec, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.Bytes())
+}
+
+func TestWriteErrorOmitStack(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, errors.New("boom"), OmitStack())
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	p, err := httpbody.Decode(rec.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, terrors.ErrInternalService, p.Code)
+	assert.Empty(t, p.Stack)
+	assert.True(t, p.StackOmitted)
+}
+
+func TestWriteErrorRetryAfter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "rate limited, seconds",
+			err:      terrors.RateLimited("", "slow down", map[string]string{terrors.RetryAfterParam: "30"}),
+			expected: "30",
+		},
+		{
+			name:     "retryable, rounded up",
+			err:      terrors.Unavailable("", "down", map[string]string{terrors.RetryAfterParam: "1500ms"}),
+			expected: "2",
+		},
+		{
+			name:     "from a downstream header",
+			err:      terrors.Unavailable("", "down", map[string]string{terrors.HTTPRetryAfterParam: "5"}),
+			expected: "5",
+		},
+		{
+			name:     "not retryable",
+			err:      terrors.BadRequest("", "bad", map[string]string{terrors.RetryAfterParam: "30"}),
+			expected: "",
+		},
+		{
+			name:     "no hint",
+			err:      terrors.RateLimited("", "slow down", nil),
+			expected: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			WriteError(rec, tc.err)
+			assert.Equal(t, tc.expected, rec.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	handler := Handler(func(w http.ResponseWriter, r *http.Request) error {
+		switch r.URL.Path {
+		case "/error":
+			return terrors.Forbidden("", "not yours", nil)
+		case "/panic":
+			panic("oh no")
+		case "/started":
+			w.WriteHeader(http.StatusAccepted)
+			return terrors.InternalService("", "too late", nil)
+		case "/abort":
+			panic(http.ErrAbortHandler)
+		}
+		w.Write([]byte("ok"))
+		return nil
+	}, OmitStack())
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := serve("/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+
+	rec = serve("/error")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	p, err := httpbody.Decode(rec.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, terrors.ErrForbidden, p.Code)
+	assert.True(t, p.StackOmitted)
+
+	rec = serve("/panic")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	p, err = httpbody.Decode(rec.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, terrors.ErrPanic, p.Code)
+
+	rec = serve("/started")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, rec.Body.Bytes())
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { serve("/abort") })
+}
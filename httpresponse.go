@@ -0,0 +1,103 @@
+package terrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/monzo/terrors/httpbody"
+)
+
+// Content types negotiated by WriteHTTPError.
+const (
+	ContentTypeProto   = "application/protobuf"
+	ContentTypeJSON    = "application/json"
+	ContentTypeProblem = "application/problem+json"
+)
+
+// TerrorHeader is set on responses whose body is a marshalled terror, so that clients (e.g. Typhon) know to decode
+// the body as an error.
+const TerrorHeader = "Terror"
+
+// WriteHTTPError writes err to w as an HTTP error response, in the format preferred by the Accept header of r:
+//   - application/protobuf (or application/x-protobuf): the marshalled error, as the proto codec encodes it
+//   - application/json: the marshalled error, as defined by the httpbody package
+//   - application/problem+json: an RFC 7807 problem document (see MarshalProblem), which only exposes the code, message
+//     and external params, for external clients, with a title in the language preferred by the Accept-Language header
+//
+// JSON is used if the request doesn't accept any of these. The status is derived from the code of the error (see
+// HTTPStatus), and proto and JSON responses carry the Terror header. If err is not a terror, it is propagated first.
+// A nil error writes nothing.
+func WriteHTTPError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	terr := Propagate(err).(*Error)
+	status := httpStatus(terr.Code)
+
+	var accept string
+	if r != nil {
+		accept = r.Header.Get("Accept")
+	}
+	contentType := negotiateErrorContentType(accept)
+
+	var body []byte
+	switch contentType {
+	case ContentTypeProblem:
+		var langs []string
+		if r != nil {
+			langs = acceptedLanguages(r.Header.Get("Accept-Language"))
+		}
+		body, err = json.Marshal(MarshalProblem(terr, WithProblemLanguages(langs...)))
+	case ContentTypeProto:
+		body, err = protoCodec{}.Encode(terr)
+		w.Header().Set(TerrorHeader, "1")
+	default:
+		body, err = httpbody.Encode(Marshal(terr))
+		w.Header().Set(TerrorHeader, "1")
+	}
+	if err != nil {
+		w.Header().Del(TerrorHeader)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// negotiateErrorContentType returns the content type of the error body preferred by an Accept header, honouring
+// quality values. Ties are broken by the order in the header, and JSON is the fallback.
+func negotiateErrorContentType(accept string) string {
+	best, bestQ := ContentTypeJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		var contentType string
+		switch strings.ToLower(strings.TrimSpace(fields[0])) {
+		case ContentTypeProto, "application/x-protobuf":
+			contentType = ContentTypeProto
+		case ContentTypeProblem:
+			contentType = ContentTypeProblem
+		case ContentTypeJSON:
+			contentType = ContentTypeJSON
+		default:
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = contentType, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,95 @@
+package terrors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monzo/terrors/httpbody"
+)
+
+func TestWriteHTTPError(t *testing.T) {
+	err := NotFound("account", "account not found", map[string]string{"account_id": "acc_123"})
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		WriteHTTPError(rec, req, err)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, ContentTypeJSON, rec.Header().Get("Content-Type"))
+		assert.Equal(t, "1", rec.Header().Get(TerrorHeader))
+		decoded, decErr := jsonCodec{}.Decode(rec.Body.Bytes())
+		assert.NoError(t, decErr)
+		assert.Equal(t, "not_found.account", decoded.Code)
+		assert.Equal(t, "acc_123", decoded.Params["account_id"])
+
+		body, decErr := httpbody.Decode(rec.Body.Bytes())
+		assert.NoError(t, decErr)
+		assert.Equal(t, "not_found.account", Unmarshal(body).Code)
+	})
+	t.Run("proto", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "application/x-protobuf")
+		rec := httptest.NewRecorder()
+		WriteHTTPError(rec, req, err)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, ContentTypeProto, rec.Header().Get("Content-Type"))
+		decoded, decErr := protoCodec{}.Decode(rec.Body.Bytes())
+		assert.NoError(t, decErr)
+		assert.Equal(t, "not_found.account", decoded.Code)
+	})
+	t.Run("problem", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "application/problem+json")
+		rec := httptest.NewRecorder()
+		WriteHTTPError(rec, req, err)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, ContentTypeProblem, rec.Header().Get("Content-Type"))
+		assert.Empty(t, rec.Header().Get(TerrorHeader))
+		doc := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+		assert.Equal(t, map[string]interface{}{
+			"type":   "about:blank",
+			"title":  "Not Found",
+			"status": float64(404),
+			"detail": "account not found",
+			"code":   "not_found.account",
+		}, doc)
+	})
+	t.Run("no request", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		WriteHTTPError(rec, nil, assert.AnError)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, ContentTypeJSON, rec.Header().Get("Content-Type"))
+	})
+	t.Run("nil", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		WriteHTTPError(rec, nil, nil)
+		assert.Empty(t, rec.Body.Bytes())
+	})
+}
+
+func TestNegotiateErrorContentType(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		expected string
+	}{
+		{"", ContentTypeJSON},
+		{"*/*", ContentTypeJSON},
+		{"text/html", ContentTypeJSON},
+		{"application/protobuf", ContentTypeProto},
+		{"application/problem+json, application/json", ContentTypeProblem},
+		{"application/json;q=0.5, application/protobuf", ContentTypeProto},
+		{"application/protobuf;q=0.1, application/problem+json;q=0.9", ContentTypeProblem},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, negotiateErrorContentType(tc.accept), tc.accept)
+	}
+}
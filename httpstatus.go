@@ -0,0 +1,130 @@
+package terrors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HTTPStatusParam is the param under which FromHTTPStatus records the status of the response.
+const HTTPStatusParam = "http_status"
+
+// httpStatuses maps the generic codes to HTTP statuses. Codes which aren't listed map to 500.
+var httpStatuses = map[string]int{
+	ErrBadRequest:         http.StatusBadRequest,
+	ErrBadResponse:        http.StatusNotAcceptable,
+	ErrForbidden:          http.StatusForbidden,
+	ErrInternalService:    http.StatusInternalServerError,
+	ErrNotFound:           http.StatusNotFound,
+	ErrPreconditionFailed: http.StatusPreconditionFailed,
+	ErrTimeout:            http.StatusGatewayTimeout,
+	ErrUnauthorized:       http.StatusUnauthorized,
+	ErrUnknown:            http.StatusInternalServerError,
+	ErrRateLimited:        http.StatusTooManyRequests,
+	ErrConflict:           http.StatusConflict,
+	ErrNotImplemented:     http.StatusNotImplemented,
+	ErrUnavailable:        http.StatusServiceUnavailable,
+}
+
+// httpStatusCodes maps HTTP statuses to the codes of the errors FromHTTPStatus creates for them. Other 4xx statuses
+// map to bad_request, other 5xx statuses to internal_service, and anything else to unknown.
+var httpStatusCodes = map[int]string{
+	http.StatusBadRequest:          ErrBadRequest,
+	http.StatusUnauthorized:        ErrUnauthorized,
+	http.StatusForbidden:           ErrForbidden,
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusRequestTimeout:      ErrTimeout,
+	http.StatusConflict:            ErrConflict,
+	http.StatusPreconditionFailed:  ErrPreconditionFailed,
+	http.StatusTooManyRequests:     ErrRateLimited,
+	http.StatusInternalServerError: ErrInternalService,
+	http.StatusNotImplemented:      ErrNotImplemented,
+	http.StatusBadGateway:          ErrUnavailable,
+	http.StatusServiceUnavailable:  ErrUnavailable,
+	http.StatusGatewayTimeout:      ErrTimeout,
+}
+
+// Mappings registered with RegisterHTTPStatus and RegisterHTTPStatusCode. They are guarded by configMu.
+var (
+	registeredHTTPStatuses    = map[string]int{}
+	registeredHTTPStatusCodes = map[int]string{}
+)
+
+// RegisterHTTPStatus sets the HTTP status of errors whose code is code, or starts with code followed by a dot, for
+// HTTPStatus and WriteHTTPError. Where several registered codes match, the longest wins. Registered codes take
+// precedence over the mapping of the generic codes.
+func RegisterHTTPStatus(code string, status int) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	registeredHTTPStatuses[code] = status
+}
+
+// RegisterHTTPStatusCode sets the code of errors created by FromHTTPStatus for status, in place of the default.
+func RegisterHTTPStatusCode(status int, code string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	registeredHTTPStatusCodes[status] = code
+}
+
+// HTTPStatus returns the HTTP status of a response carrying err, e.g. 404 for not_found errors, 429 for rate_limited
+// errors, and 500 for codes which aren't recognised. If err is not a terror, it is propagated first. A nil error is
+// 200.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	return httpStatus(inspect(err).Code)
+}
+
+// httpStatus returns the HTTP status for an error with the given code: the status of the longest matching code
+// registered with RegisterHTTPStatus, or otherwise that of its first segment.
+func httpStatus(code string) int {
+	configMu.RLock()
+	status, matched := 0, ""
+	for prefix, s := range registeredHTTPStatuses {
+		if hasCodePrefix(code, prefix) && (status == 0 || len(prefix) > len(matched)) {
+			status, matched = s, prefix
+		}
+	}
+	configMu.RUnlock()
+	if status != 0 {
+		return status
+	}
+
+	generic := code
+	if i := strings.IndexByte(code, '.'); i >= 0 {
+		generic = code[:i]
+	}
+	if status, ok := httpStatuses[generic]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FromHTTPStatus creates a new error for an HTTP response with the given status, for clients of HTTP APIs which
+// don't return terrors, e.g. not_found for 404 and rate_limited for 429. The status is recorded under
+// HTTPStatusParam, along with params. The retryability of the error is derived from its code as usual.
+func FromHTTPStatus(status int, message string, params map[string]string) *Error {
+	params = mergeParams(params, map[string]string{HTTPStatusParam: strconv.Itoa(status)})
+	return errorFactory(httpStatusCode(status), message, params)
+}
+
+// httpStatusCode returns the code of errors created by FromHTTPStatus for status.
+func httpStatusCode(status int) string {
+	configMu.RLock()
+	code, ok := registeredHTTPStatusCodes[status]
+	configMu.RUnlock()
+	if ok {
+		return code
+	}
+	if code, ok := httpStatusCodes[status]; ok {
+		return code
+	}
+	switch {
+	case status >= 400 && status < 500:
+		return ErrBadRequest
+	case status >= 500 && status < 600:
+		return ErrInternalService
+	}
+	return ErrUnknown
+}
@@ -0,0 +1,76 @@
+package terrors
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withHTTPStatuses(t *testing.T) {
+	configMu.Lock()
+	previousStatuses, previousCodes := registeredHTTPStatuses, registeredHTTPStatusCodes
+	registeredHTTPStatuses, registeredHTTPStatusCodes = map[string]int{}, map[int]string{}
+	configMu.Unlock()
+	t.Cleanup(func() {
+		configMu.Lock()
+		registeredHTTPStatuses, registeredHTTPStatusCodes = previousStatuses, previousCodes
+		configMu.Unlock()
+	})
+}
+
+func TestHTTPStatus(t *testing.T) {
+	withHTTPStatuses(t)
+	assert.Equal(t, http.StatusNotFound, httpStatus("not_found.foo"))
+	assert.Equal(t, http.StatusTooManyRequests, httpStatus(ErrRateLimited))
+	assert.Equal(t, http.StatusConflict, httpStatus("conflict.version"))
+	assert.Equal(t, http.StatusNotImplemented, httpStatus(ErrNotImplemented))
+	assert.Equal(t, http.StatusServiceUnavailable, httpStatus("unavailable.db"))
+	assert.Equal(t, http.StatusInternalServerError, httpStatus("something_else"))
+
+	assert.Equal(t, http.StatusNotFound, HTTPStatus(Augment(NotFound("foo", "", nil), "context", nil)))
+	assert.Equal(t, http.StatusNotFound, HTTPStatus(sql.ErrNoRows))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(errors.New("boom")))
+	assert.Equal(t, http.StatusOK, HTTPStatus(nil))
+
+	RegisterHTTPStatus(ErrBadRequest+".payload", http.StatusRequestEntityTooLarge)
+	RegisterHTTPStatus(ErrBadRequest+".payload.images", http.StatusUnsupportedMediaType)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, HTTPStatus(BadRequest("payload", "", nil)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, HTTPStatus(BadRequest("payload.size", "", nil)))
+	assert.Equal(t, http.StatusUnsupportedMediaType, HTTPStatus(BadRequest("payload.images", "", nil)))
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(BadRequest("payloads", "", nil)))
+}
+
+func TestFromHTTPStatus(t *testing.T) {
+	withHTTPStatuses(t)
+	testCases := []struct {
+		status    int
+		code      string
+		retryable bool
+	}{
+		{http.StatusNotFound, ErrNotFound, false},
+		{http.StatusTooManyRequests, ErrRateLimited, true},
+		{http.StatusServiceUnavailable, ErrUnavailable, true},
+		{http.StatusGatewayTimeout, ErrTimeout, true},
+		{http.StatusTeapot, ErrBadRequest, false},
+		{http.StatusInsufficientStorage, ErrInternalService, true},
+		{http.StatusFound, ErrUnknown, true},
+	}
+	for _, tc := range testCases {
+		err := FromHTTPStatus(tc.status, "failed", map[string]string{"a": "1"})
+		assert.Equal(t, tc.code, err.Code, tc.status)
+		assert.Equal(t, tc.retryable, err.Retryable(), tc.status)
+		assert.Equal(t, "1", err.Params["a"])
+	}
+
+	params := map[string]string{"a": "1"}
+	err := FromHTTPStatus(http.StatusNotFound, "account not found", params)
+	assert.Equal(t, "404", err.Params[HTTPStatusParam])
+	assert.NotContains(t, params, HTTPStatusParam)
+	assert.Contains(t, err.StackFrames[0].Method, "TestFromHTTPStatus")
+
+	RegisterHTTPStatusCode(http.StatusTeapot, ErrNotImplemented+".teapot")
+	assert.Equal(t, "not_implemented.teapot", FromHTTPStatus(http.StatusTeapot, "", nil).Code)
+}
@@ -0,0 +1,32 @@
+package terrors
+
+// IdempotencyKeyParam is the param under which the idempotency key of the request that caused an error is stored.
+// Retry layers can read it back with IdempotencyKey, so that a retried request re-uses the original key rather than
+// generating a new one (which could cause the operation to be applied twice).
+const IdempotencyKeyParam = "idempotency_key"
+
+// WithIdempotencyKey returns a copy of err with the given idempotency key attached as a param. If err is not a terror,
+// it is propagated first so that the key can be attached. A nil error returns nil.
+func WithIdempotencyKey(err error, key string) error {
+	if err == nil {
+		return nil
+	}
+	return addParams(Propagate(err).(*Error), map[string]string{IdempotencyKeyParam: key})
+}
+
+// IdempotencyKey returns the idempotency key attached to err with WithIdempotencyKey. It walks the causal chain, so
+// keys attached to an error before it was augmented are still found.
+func IdempotencyKey(err error) (string, bool) {
+	maxDepth := CurrentMaxCausalDepth()
+	for depth := 0; err != nil && depth < maxDepth; depth++ {
+		terr, ok := err.(*Error)
+		if !ok {
+			return "", false
+		}
+		if key, ok := terr.Params[IdempotencyKeyParam]; ok {
+			return key, true
+		}
+		err = terr.cause
+	}
+	return "", false
+}
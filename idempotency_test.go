@@ -0,0 +1,57 @@
+package terrors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	t.Run("terror", func(t *testing.T) {
+		base := Timeout("downstream", "timed out", map[string]string{"foo": "bar"})
+		err := WithIdempotencyKey(base, "idem-123")
+
+		key, ok := IdempotencyKey(err)
+		assert.True(t, ok)
+		assert.Equal(t, "idem-123", key)
+		assert.Equal(t, "bar", err.(*Error).Params["foo"])
+		// The original error isn't modified
+		assert.NotContains(t, base.Params, IdempotencyKeyParam)
+	})
+	t.Run("non-terror", func(t *testing.T) {
+		err := WithIdempotencyKey(assert.AnError, "idem-123")
+		assert.True(t, Is(err, ErrInternalService))
+		assert.Equal(t, assert.AnError, err.(*Error).Unwrap())
+
+		key, ok := IdempotencyKey(err)
+		assert.True(t, ok)
+		assert.Equal(t, "idem-123", key)
+	})
+	t.Run("non-terror keeps the code it would be propagated with", func(t *testing.T) {
+		err := WithIdempotencyKey(context.DeadlineExceeded, "idem-123")
+		assert.True(t, Is(err, ErrTimeout))
+		assert.True(t, IsRetryable(err))
+	})
+	t.Run("survives augmentation and the wire", func(t *testing.T) {
+		err := WithIdempotencyKey(NotFound("foo", "bar", nil), "idem-123")
+		err = Augment(Unmarshal(Marshal(err.(*Error))), "calling downstream", nil)
+
+		key, ok := IdempotencyKey(err)
+		assert.True(t, ok)
+		assert.Equal(t, "idem-123", key)
+	})
+	t.Run("missing", func(t *testing.T) {
+		_, ok := IdempotencyKey(NotFound("foo", "bar", nil))
+		assert.False(t, ok)
+		_, ok = IdempotencyKey(assert.AnError)
+		assert.False(t, ok)
+		_, ok = IdempotencyKey(Unmarshal(&pe.Error{}))
+		assert.False(t, ok)
+	})
+	t.Run("nil", func(t *testing.T) {
+		assert.Nil(t, WithIdempotencyKey(nil, "idem-123"))
+	})
+}
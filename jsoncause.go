@@ -0,0 +1,50 @@
+package terrors
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// ErrInvalidJSON is the code given to errors created from encoding/json decode failures, which are almost always
+// caused by a malformed payload from the client rather than by our own code.
+const ErrInvalidJSON = ErrBadRequest + ".invalid_json"
+
+// Params set on errors created from encoding/json decode failures.
+const (
+	// JSONOffsetParam is the byte offset in the input at which decoding failed.
+	JSONOffsetParam = "json_offset"
+	// JSONFieldParam is the path of the field which had the wrong type, e.g. "account.balance".
+	JSONFieldParam = "json_field"
+	// JSONExpectedTypeParam is the Go type which the field was being decoded into.
+	JSONExpectedTypeParam = "json_expected_type"
+	// JSONValueParam is the kind of JSON value which was found instead, e.g. "string" or "number".
+	JSONValueParam = "json_value"
+)
+
+// translateJSONCause converts a *json.SyntaxError or *json.UnmarshalTypeError anywhere in the chain of err into a
+// bad_request.invalid_json error. It is consulted after the registered translators, so that they can override it.
+func translateJSONCause(err error) (*Error, bool) {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return untrackedError(ErrInvalidJSON, err.Error(), map[string]string{
+			JSONOffsetParam: strconv.FormatInt(syntaxErr.Offset, 10),
+		}), true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		params := map[string]string{
+			JSONOffsetParam: strconv.FormatInt(typeErr.Offset, 10),
+			JSONValueParam:  typeErr.Value,
+		}
+		if typeErr.Field != "" {
+			params[JSONFieldParam] = typeErr.Field
+		}
+		if typeErr.Type != nil {
+			params[JSONExpectedTypeParam] = typeErr.Type.String()
+		}
+		return untrackedError(ErrInvalidJSON, err.Error(), params), true
+	}
+	return nil, false
+}
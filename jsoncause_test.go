@@ -0,0 +1,58 @@
+package terrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSyntaxErrorCause(t *testing.T) {
+	var v map[string]interface{}
+	jsonErr := json.Unmarshal([]byte(`{"a": }`), &v)
+
+	err := Augment(jsonErr, "decoding request", nil)
+	assert.True(t, Is(err, ErrInvalidJSON))
+	assert.False(t, IsRetryable(err))
+	assert.Equal(t, "7", err.(*Error).Params[JSONOffsetParam])
+	assert.True(t, errors.Is(err, jsonErr))
+}
+
+func TestJSONUnmarshalTypeErrorCause(t *testing.T) {
+	var v struct {
+		Account struct {
+			Balance int `json:"balance"`
+		} `json:"account"`
+	}
+	jsonErr := json.Unmarshal([]byte(`{"account": {"balance": "lots"}}`), &v)
+
+	// Found through wrapping
+	err := Propagate(fmt.Errorf("decoding request: %w", jsonErr))
+	assert.True(t, Is(err, ErrInvalidJSON))
+	assert.False(t, IsRetryable(err))
+	params := err.(*Error).Params
+	assert.Equal(t, "account.balance", params[JSONFieldParam])
+	assert.Equal(t, "int", params[JSONExpectedTypeParam])
+	assert.Equal(t, "string", params[JSONValueParam])
+	assert.NotEmpty(t, params[JSONOffsetParam])
+
+	err = Wrap(jsonErr, map[string]string{"a": "1"})
+	assert.True(t, Is(err, ErrInvalidJSON))
+	assert.Equal(t, "1", err.(*Error).Params["a"])
+}
+
+func TestJSONCauseCanBeOverridden(t *testing.T) {
+	withCauseTranslator(t, func(err error) (*Error, bool) {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return BadResponse("json", err.Error(), nil), true
+		}
+		return nil, false
+	})
+
+	var v map[string]interface{}
+	err := Propagate(json.Unmarshal([]byte(`{`), &v))
+	assert.True(t, Is(err, ErrBadResponse, "json"))
+}
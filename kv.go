@@ -0,0 +1,95 @@
+package terrors
+
+// kvMissingValue is the value given to the final key of an odd number of key-value arguments, in the style of fmt's
+// `%!v(MISSING)`, so that the mistake is visible rather than silently dropped.
+const kvMissingValue = "(MISSING)"
+
+// KV builds a params map from alternating keys and values, e.g. `KV("account_id", id, "currency", "GBP")`. If there is
+// an odd number of arguments, the final key is given the value "(MISSING)". It returns nil if there are no arguments.
+func KV(kv ...string) map[string]string {
+	if len(kv) == 0 {
+		return nil
+	}
+	params := make(map[string]string, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 < len(kv) {
+			params[kv[i]] = kv[i+1]
+		} else {
+			params[kv[i]] = kvMissingValue
+		}
+	}
+	return params
+}
+
+// AugmentKV behaves like Augment, but takes params as alternating keys and values (see KV), which avoids building a
+// map literal for one or two params:
+//
+//	return terrors.AugmentKV(err, "failed to load account", "account_id", id)
+func AugmentKV(err error, context string, kv ...string) error {
+	return Augment(err, context, KV(kv...))
+}
+
+// NewKV behaves like New, but takes params as alternating keys and values (see KV).
+func NewKV(code string, message string, kv ...string) *Error {
+	return errorFactory(code, message, KV(kv...))
+}
+
+// InternalServiceKV behaves like InternalService, but takes params as alternating keys and values (see KV).
+func InternalServiceKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrInternalService, code), message, KV(kv...))
+}
+
+// BadRequestKV behaves like BadRequest, but takes params as alternating keys and values (see KV).
+func BadRequestKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrBadRequest, code), message, KV(kv...))
+}
+
+// BadResponseKV behaves like BadResponse, but takes params as alternating keys and values (see KV).
+func BadResponseKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrBadResponse, code), message, KV(kv...))
+}
+
+// TimeoutKV behaves like Timeout, but takes params as alternating keys and values (see KV).
+func TimeoutKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrTimeout, code), message, KV(kv...))
+}
+
+// NotFoundKV behaves like NotFound, but takes params as alternating keys and values (see KV).
+func NotFoundKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrNotFound, code), message, KV(kv...))
+}
+
+// ForbiddenKV behaves like Forbidden, but takes params as alternating keys and values (see KV).
+func ForbiddenKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrForbidden, code), message, KV(kv...))
+}
+
+// UnauthorizedKV behaves like Unauthorized, but takes params as alternating keys and values (see KV).
+func UnauthorizedKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrUnauthorized, code), message, KV(kv...))
+}
+
+// PreconditionFailedKV behaves like PreconditionFailed, but takes params as alternating keys and values (see KV).
+func PreconditionFailedKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrPreconditionFailed, code), message, KV(kv...))
+}
+
+// RateLimitedKV behaves like RateLimited, but takes params as alternating keys and values (see KV).
+func RateLimitedKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrRateLimited, code), message, KV(kv...))
+}
+
+// ConflictKV behaves like Conflict, but takes params as alternating keys and values (see KV).
+func ConflictKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrConflict, code), message, KV(kv...))
+}
+
+// NotImplementedKV behaves like NotImplemented, but takes params as alternating keys and values (see KV).
+func NotImplementedKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrNotImplemented, code), message, KV(kv...))
+}
+
+// UnavailableKV behaves like Unavailable, but takes params as alternating keys and values (see KV).
+func UnavailableKV(code, message string, kv ...string) *Error {
+	return errorFactory(errCode(ErrUnavailable, code), message, KV(kv...))
+}
@@ -0,0 +1,60 @@
+package terrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKV(t *testing.T) {
+	assert.Nil(t, KV())
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, KV("a", "1", "b", "2"))
+	assert.Equal(t, map[string]string{"a": "1", "b": "(MISSING)"}, KV("a", "1", "b"))
+	assert.Equal(t, map[string]string{"a": "2"}, KV("a", "1", "a", "2"))
+}
+
+func TestAugmentKV(t *testing.T) {
+	assert.Nil(t, AugmentKV(nil, "context", "a", "1"))
+
+	err := AugmentKV(NotFound("foo", "foo not found", map[string]string{"a": "0"}), "loading foo", "a", "1", "b", "2")
+	terr := err.(*Error)
+	assert.Equal(t, "not_found.foo", terr.Code)
+	assert.Equal(t, "loading foo", terr.Message)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, terr.Params)
+
+	err = AugmentKV(errors.New("boom"), "loading foo", "a", "1")
+	assert.True(t, Is(err, ErrInternalService))
+	assert.Equal(t, "1", err.(*Error).Params["a"])
+}
+
+func TestKVConstructors(t *testing.T) {
+	testCases := []struct {
+		constructor  func(code, message string, kv ...string) *Error
+		expectedCode string
+	}{
+		{NewKV, "service.foo"},
+		{InternalServiceKV, "internal_service.service.foo"},
+		{BadRequestKV, "bad_request.service.foo"},
+		{BadResponseKV, "bad_response.service.foo"},
+		{TimeoutKV, "timeout.service.foo"},
+		{NotFoundKV, "not_found.service.foo"},
+		{ForbiddenKV, "forbidden.service.foo"},
+		{UnauthorizedKV, "unauthorized.service.foo"},
+		{PreconditionFailedKV, "precondition_failed.service.foo"},
+		{RateLimitedKV, "rate_limited.service.foo"},
+		{ConflictKV, "conflict.service.foo"},
+		{NotImplementedKV, "not_implemented.service.foo"},
+		{UnavailableKV, "unavailable.service.foo"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expectedCode, func(t *testing.T) {
+			err := tc.constructor("service.foo", "message", "a", "1")
+			assert.Equal(t, tc.expectedCode, err.Code)
+			assert.Equal(t, "message", err.Message)
+			assert.Equal(t, map[string]string{"a": "1"}, err.Params)
+			assert.Contains(t, err.StackFrames[0].Method, "TestKVConstructors")
+		})
+	}
+}
@@ -1,12 +1,50 @@
 package terrors
 
 import (
+	"time"
+
 	pe "github.com/monzo/terrors/proto"
 	"github.com/monzo/terrors/stack"
 )
 
+// MarshalOption configures the behaviour of MarshalWithOptions.
+type MarshalOption func(*marshalOptions)
+
+type marshalOptions struct {
+	programCounters bool
+	omitStack       bool
+}
+
+// WithProgramCounters includes the raw program counter of each stack frame, and the build ID of the binary which
+// produced them, in the marshalled error. This allows a central symbolication service to resolve the frames even when
+// the binary was built with trimmed paths. See SetBuildID.
+func WithProgramCounters() MarshalOption {
+	return func(o *marshalOptions) {
+		o.programCounters = true
+	}
+}
+
+// OmitStack leaves the stack out of the marshalled error, and marks it as omitted, which greatly reduces its size.
+// The message and context chains and params are kept. This is intended for high-volume internal hops where the stack
+// isn't needed; errors unmarshalled from such hops have StackOmitted set.
+func OmitStack() MarshalOption {
+	return func(o *marshalOptions) {
+		o.omitStack = true
+	}
+}
+
 // Marshal an error into a protobuf for transmission
 func Marshal(e *Error) *pe.Error {
+	return MarshalWithOptions(e)
+}
+
+// MarshalWithOptions marshals an error into a protobuf for transmission, with options which control what is included.
+func MarshalWithOptions(e *Error, opts ...MarshalOption) *pe.Error {
+	o := marshalOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Account for nil errors
 	if e == nil {
 		return &pe.Error{
@@ -25,22 +63,61 @@ func Marshal(e *Error) *pe.Error {
 		unexpected.Value = *e.IsUnexpected
 	}
 
+	scanner := currentSecretScanner()
+	message, messageChain := scanner.maskMessages(e.Code, e.Message, e.MessageChain)
+
 	err := &pe.Error{
 		Code:         e.Code,
-		Message:      e.Message,
-		MessageChain: e.MessageChain,
-		Stack:        stackToProto(e.StackFrames),
-		Params:       e.Params,
+		Message:      message,
+		MessageChain: messageChain,
+		Stack:        stackToProto(e.StackFrames, o.programCounters),
+		ContextChain: contextChainToProto(scanner, e.ContextChain),
+		Params:       scanner.maskParams(e.Code, e.Params),
 		Retryable:    retryable,
 		Unexpected:   unexpected,
-		MarshalCount: int32(e.MarshalCount + 1),
+		MarshalCount: int32(chainMarshalCount(e) + 1),
+		RetryInfo:    retryInfoToProto(e),
 	}
 	if err.Code == "" {
 		err.Code = ErrUnknown
 	}
+	if o.omitStack {
+		err.Stack = []*pe.StackFrame{}
+		err.StackOmitted = true
+	} else if len(e.StackFrames) == 0 {
+		// Keep the mark when an error whose stack was omitted upstream is passed on
+		err.StackOmitted = e.StackOmitted
+	}
+	if o.programCounters && !o.omitStack && len(e.StackFrames) > 0 {
+		err.StackBuildId = e.StackBuildID
+		if err.StackBuildId == "" {
+			// The stack was captured in this process
+			err.StackBuildId = CurrentBuildID()
+		}
+	}
 	return err
 }
 
+// chainMarshalCount returns the highest MarshalCount of any terror in the causal chain of e. Augmenting an
+// unmarshalled error locally produces a head whose count may lag behind its cause, and we don't want local
+// wrapping to reset the estimate of how many hops the error has made.
+func chainMarshalCount(e *Error) int {
+	count := e.MarshalCount
+	var next error = e.cause
+	maxDepth := CurrentMaxCausalDepth()
+	for depth := 0; next != nil && depth < maxDepth; depth++ {
+		terr, ok := next.(*Error)
+		if !ok {
+			break
+		}
+		if terr.MarshalCount > count {
+			count = terr.MarshalCount
+		}
+		next = terr.cause
+	}
+	return count
+}
+
 // Unmarshal a protobuf error into a local error
 func Unmarshal(p *pe.Error) *Error {
 	if p == nil {
@@ -65,7 +142,10 @@ func Unmarshal(p *pe.Error) *Error {
 		Code:         p.Code,
 		Message:      p.Message,
 		MessageChain: p.MessageChain,
+		ContextChain: protoToContextChain(p.ContextChain, p.MessageChain),
 		StackFrames:  protoToStack(p.Stack),
+		StackBuildID: p.StackBuildId,
+		StackOmitted: p.StackOmitted,
 		Params:       p.Params,
 		IsRetryable:  retryable,
 		IsUnexpected: unexpected,
@@ -74,6 +154,18 @@ func Unmarshal(p *pe.Error) *Error {
 	if err.Code == "" {
 		err.Code = ErrUnknown
 	}
+	applyUnknownCodePolicy(err)
+	if p.RetryInfo != nil && p.RetryInfo.RetryAfterMs > 0 {
+		retryAfter := time.Duration(p.RetryInfo.RetryAfterMs) * time.Millisecond
+		err.RetryAfter = &retryAfter
+	}
+	if missing := retryInfoParams(p.RetryInfo, err.Params); len(missing) > 0 {
+		// mergeParams copies, so the params of p aren't modified
+		err.Params = mergeParams(err.Params, missing)
+	}
+	if retryable != nil {
+		err.retryableReason = retryabilityReason{kind: retryabilityRemote, value: *retryable}
+	}
 	// empty map[string]string come out as nil. thanks proto.
 	if err.Params == nil {
 		err.Params = map[string]string{}
@@ -81,6 +173,48 @@ func Unmarshal(p *pe.Error) *Error {
 	return err
 }
 
+// NewWithHistory creates an error which has already travelled through other processes, with the given message chain
+// and marshal count, as Unmarshal does for errors received as protobufs. It is intended for custom transports which
+// carry errors in other envelopes, and for test fixtures. The context chain is rebuilt from the message chain, and
+// the retryability of the error is derived from its code, as for New. Like Unmarshal, it doesn't capture a stack, as
+// the error happened elsewhere, and applies any UnknownCodePolicy. The message chain and params are copied.
+func NewWithHistory(code, message string, messageChain []string, marshalCount int, params map[string]string) *Error {
+	err := untrackedError(code, message, mergeParams(params, nil))
+	err.StackFrames = stack.Stack{}
+	if len(messageChain) > 0 {
+		err.MessageChain = append([]string{}, messageChain...)
+		err.ContextChain = protoToContextChain(nil, err.MessageChain)
+	}
+	err.MarshalCount = marshalCount
+	applyUnknownCodePolicy(err)
+	return err
+}
+
+// UnmarshalWithCause unmarshals a protobuf error, and attaches a local error as its cause, for example the
+// transport-level failure which accompanied the remote error. This keeps the story told by the remote service and the
+// local context in one chain: the messages of the local cause are appended to the MessageChain and ContextChain of
+// the remote error, and the local cause can be found with Is and errors.Is. The code and flags of the remote error are
+// kept. If localCause is nil, this is equivalent to Unmarshal.
+func UnmarshalWithCause(p *pe.Error, localCause error) *Error {
+	err := Unmarshal(p)
+	if localCause == nil {
+		return err
+	}
+	err.cause = localCause
+	// The message chain is shared with p, so copy it before appending
+	err.MessageChain = append([]string{}, err.MessageChain...)
+
+	switch v := localCause.(type) {
+	case *Error:
+		err.MessageChain = append(append(err.MessageChain, v.Message), v.MessageChain...)
+		err.ContextChain = append(append(err.ContextChain, v.contextEntry()), v.ContextChain...)
+	default:
+		err.MessageChain = append(err.MessageChain, localCause.Error())
+		err.ContextChain = append(err.ContextChain, ContextEntry{Message: localCause.Error()})
+	}
+	return err
+}
+
 // protoToStack converts a slice of *pe.StackFrame and returns a stack.Stack
 func protoToStack(protoStack []*pe.StackFrame) stack.Stack {
 	if protoStack == nil {
@@ -90,27 +224,82 @@ func protoToStack(protoStack []*pe.StackFrame) stack.Stack {
 	s := make(stack.Stack, 0, len(protoStack))
 	for _, frame := range protoStack {
 		s = append(s, &stack.Frame{
-			Filename: frame.Filename,
-			Method:   frame.Method,
-			Line:     int(frame.Line),
+			Filename:   frame.Filename,
+			Method:     frame.Method,
+			Line:       int(frame.Line),
+			PC:         uintptr(frame.Pc),
+			SourceHash: frame.SourceHash,
 		})
 	}
 	return s
 }
 
-// stackToProto converts a stack.Stack and returns a slice of *pe.StackFrame
-func stackToProto(s stack.Stack) []*pe.StackFrame {
+// stackToProto converts a stack.Stack and returns a slice of *pe.StackFrame. The program counters are only included
+// if programCounters is set.
+func stackToProto(s stack.Stack, programCounters bool) []*pe.StackFrame {
 	if s == nil {
 		return []*pe.StackFrame{}
 	}
 
 	protoStack := make([]*pe.StackFrame, 0, len(s))
 	for _, frame := range s {
-		protoStack = append(protoStack, &pe.StackFrame{
-			Filename: frame.Filename,
-			Line:     int32(frame.Line),
-			Method:   frame.Method,
-		})
+		protoFrame := &pe.StackFrame{
+			Filename:   frame.Filename,
+			Line:       int32(frame.Line),
+			Method:     frame.Method,
+			SourceHash: frame.SourceHash,
+		}
+		if programCounters {
+			protoFrame.Pc = uint64(frame.PC)
+		}
+		protoStack = append(protoStack, protoFrame)
 	}
 	return protoStack
 }
+
+// contextChainToProto converts a context chain and returns a slice of *pe.ContextEntry
+func contextChainToProto(scanner *SecretScanner, chain []ContextEntry) []*pe.ContextEntry {
+	if chain == nil {
+		return nil
+	}
+
+	protoChain := make([]*pe.ContextEntry, 0, len(chain))
+	for _, entry := range chain {
+		protoChain = append(protoChain, &pe.ContextEntry{
+			Code:    entry.Code,
+			Message: scanner.maskField(entry.Code, "context_chain", entry.Message),
+			Params:  scanner.maskParamsField(entry.Code, "context_chain.params.", entry.Params),
+		})
+	}
+	return protoChain
+}
+
+// protoToContextChain converts a slice of *pe.ContextEntry and returns a context chain. Errors from services which
+// don't send a context chain have one built from their message chain, with only the messages populated.
+func protoToContextChain(protoChain []*pe.ContextEntry, messageChain []string) []ContextEntry {
+	if len(protoChain) == 0 {
+		if len(messageChain) == 0 {
+			return nil
+		}
+		chain := make([]ContextEntry, 0, len(messageChain))
+		for _, message := range messageChain {
+			chain = append(chain, ContextEntry{Message: message, Params: map[string]string{}})
+		}
+		return chain
+	}
+
+	chain := make([]ContextEntry, 0, len(protoChain))
+	for _, entry := range protoChain {
+		params := entry.Params
+		// empty map[string]string come out as nil. thanks proto.
+		if params == nil {
+			params = map[string]string{}
+		}
+		chain = append(chain, ContextEntry{
+			Code:    entry.Code,
+			Message: entry.Message,
+			Params:  params,
+		})
+	}
+	return chain
+}
@@ -1,8 +1,10 @@
 package terrors
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 
 	pe "github.com/monzo/terrors/proto"
@@ -73,6 +75,18 @@ var marshalTestCases = []struct {
 			},
 		},
 	},
+	{
+		&Error{
+			Code:        ErrNotImplemented,
+			Message:     "not yet",
+			IsRetryable: &notRetryable,
+		},
+		&pe.Error{
+			Code:      ErrNotImplemented,
+			Message:   "not yet",
+			Retryable: &pe.BoolValue{Value: false},
+		},
+	},
 	{
 		&Error{
 			Code:    ErrForbidden,
@@ -399,3 +413,213 @@ func TestUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestMarshalCountChainAware(t *testing.T) {
+	remote := Unmarshal(&pe.Error{Code: ErrTimeout, Message: "remote", MarshalCount: 3})
+
+	// NewInternalWithCause already copies the count from its cause, but a head built some other way may not
+	head := &Error{Code: ErrInternalService, Message: "local", cause: Augment(remote, "context", nil)}
+	assert.Equal(t, int32(4), Marshal(head).MarshalCount)
+
+	// The head's own count still wins when it is the highest
+	head.MarshalCount = 7
+	assert.Equal(t, int32(8), Marshal(head).MarshalCount)
+}
+
+func TestContextChainRoundTrip(t *testing.T) {
+	base := NotFound("user", "failed to find user", map[string]string{"user_id": "123"})
+	err := Augment(base, "loading account", map[string]string{"account_id": "456"}).(*Error)
+	err = NewInternalWithCause(err, "handling request", map[string]string{"path": "/accounts"}, "")
+
+	expected := []ContextEntry{
+		{Code: "not_found.user", Message: "loading account", Params: map[string]string{"user_id": "123", "account_id": "456"}},
+		{Code: "not_found.user", Message: "failed to find user", Params: map[string]string{"user_id": "123"}},
+	}
+	assert.Equal(t, expected, err.ContextChain)
+
+	unmarshalled := Unmarshal(Marshal(err))
+	assert.Equal(t, expected, unmarshalled.ContextChain)
+	assert.Equal(t, []string{"loading account", "failed to find user"}, unmarshalled.MessageChain)
+
+	// Further augmentation after crossing the wire keeps the remote links
+	augmented := Augment(unmarshalled, "calling accounts", nil).(*Error)
+	assert.Equal(t, "handling request", augmented.ContextChain[0].Message)
+	assert.Equal(t, expected, augmented.ContextChain[1:])
+}
+
+func TestContextChainNonTerrorCause(t *testing.T) {
+	err := Augment(assert.AnError, "context", nil).(*Error)
+	assert.Equal(t, []ContextEntry{{Message: assert.AnError.Error()}}, err.ContextChain)
+}
+
+func TestContextChainFromMessageChain(t *testing.T) {
+	// Older services only send the message chain
+	err := Unmarshal(&pe.Error{Code: ErrTimeout, Message: "3", MessageChain: []string{"2", "1"}})
+	assert.Equal(t, []ContextEntry{
+		{Message: "2", Params: map[string]string{}},
+		{Message: "1", Params: map[string]string{}},
+	}, err.ContextChain)
+}
+
+func TestMarshalWithProgramCounters(t *testing.T) {
+	SetBuildID("build-123")
+	defer SetBuildID("")
+	assert.Equal(t, "build-123", CurrentBuildID())
+
+	err := failyFunction().(*Error)
+
+	t.Run("not included by default", func(t *testing.T) {
+		protoErr := Marshal(err)
+		assert.Empty(t, protoErr.StackBuildId)
+		for _, frame := range protoErr.Stack {
+			assert.Zero(t, frame.Pc)
+		}
+	})
+	t.Run("included with option", func(t *testing.T) {
+		protoErr := MarshalWithOptions(err, WithProgramCounters())
+		assert.Equal(t, "build-123", protoErr.StackBuildId)
+		assert.Len(t, protoErr.Stack, len(err.StackFrames))
+		for i, frame := range protoErr.Stack {
+			assert.Equal(t, uint64(err.StackFrames[i].PC), frame.Pc)
+			assert.NotZero(t, frame.Pc)
+		}
+
+		unmarshalled := Unmarshal(protoErr)
+		assert.Equal(t, "build-123", unmarshalled.StackBuildID)
+		assert.Equal(t, err.StackTrace(), unmarshalled.StackTrace())
+	})
+	t.Run("build ID of another service is preserved", func(t *testing.T) {
+		remote := Unmarshal(MarshalWithOptions(err, WithProgramCounters()))
+		SetBuildID("other-build")
+		protoErr := MarshalWithOptions(Augment(remote, "calling remote", nil).(*Error), WithProgramCounters())
+		// The head has no stack of its own, so no build ID is sent
+		assert.Empty(t, protoErr.StackBuildId)
+
+		protoErr = MarshalWithOptions(addParams(remote, map[string]string{"k": "v"}), WithProgramCounters())
+		assert.Equal(t, "build-123", protoErr.StackBuildId)
+	})
+	t.Run("no stack", func(t *testing.T) {
+		protoErr := MarshalWithOptions(&Error{Code: "foo"}, WithProgramCounters())
+		assert.Empty(t, protoErr.StackBuildId)
+	})
+}
+
+func TestMarshalSourceHashes(t *testing.T) {
+	stack.SetSourceHashing(true)
+	err := failyFunction().(*Error)
+	stack.SetSourceHashing(false)
+
+	assert.NotEmpty(t, err.StackFrames[0].SourceHash)
+	unmarshalled := Unmarshal(Marshal(err))
+	for i, frame := range unmarshalled.StackFrames {
+		assert.Equal(t, err.StackFrames[i].SourceHash, frame.SourceHash)
+	}
+}
+
+func TestMarshalConcurrentWithAugmentation(t *testing.T) {
+	err := NotFound("foo", "bar", map[string]string{"k": "v"})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			_ = Augment(err, "context", map[string]string{"i": "x"})
+			_ = Wrap(err, map[string]string{"k": "w"})
+			_ = AsWarning(err)
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		protoErr := Marshal(err)
+		assert.Equal(t, "not_found.foo", protoErr.Code)
+		assert.Equal(t, "v", protoErr.Params["k"])
+		_ = Marshal(Augment(err, "context", nil).(*Error))
+	}
+	<-done
+}
+
+func TestUnmarshalWithCause(t *testing.T) {
+	remote := Marshal(Augment(NotFound("card", "card not found", nil), "loading card", nil).(*Error))
+
+	t.Run("terror cause", func(t *testing.T) {
+		local := Timeout("transport", "reading response body", map[string]string{"k": "v"})
+		err := UnmarshalWithCause(remote, local)
+
+		assert.Equal(t, "not_found.card", err.Code)
+		assert.False(t, err.Retryable())
+		assert.Equal(t, local, err.Cause())
+		assert.True(t, Is(err, ErrTimeout))
+		assert.Equal(t, "loading card: reading response body", err.ErrorMessage())
+		assert.Equal(t, []string{"card not found", "reading response body"}, err.MessageChain)
+		assert.Len(t, err.ContextChain, 2)
+		assert.Equal(t, "timeout.transport", err.ContextChain[1].Code)
+		assert.Equal(t, "v", err.ContextChain[1].Params["k"])
+	})
+	t.Run("non-terror cause", func(t *testing.T) {
+		local := errors.New("connection reset")
+		err := UnmarshalWithCause(remote, local)
+		assert.True(t, errors.Is(err, local))
+		assert.Equal(t, []string{"card not found", "connection reset"}, err.MessageChain)
+	})
+	t.Run("nil cause", func(t *testing.T) {
+		assert.Equal(t, Unmarshal(remote), UnmarshalWithCause(remote, nil))
+	})
+	t.Run("remote chain isn't modified", func(t *testing.T) {
+		UnmarshalWithCause(remote, errors.New("connection reset"))
+		assert.Equal(t, []string{"card not found"}, remote.MessageChain)
+	})
+}
+
+func TestNewWithHistory(t *testing.T) {
+	chain := []string{"card not found"}
+	params := map[string]string{"card_id": "card_123"}
+	err := NewWithHistory("not_found.card", "loading card", chain, 2, params)
+
+	assert.Equal(t, "not_found.card", err.Code)
+	assert.Equal(t, "loading card", err.Message)
+	assert.Equal(t, []string{"card not found"}, err.MessageChain)
+	assert.Equal(t, []ContextEntry{{Message: "card not found", Params: map[string]string{}}}, err.ContextChain)
+	assert.Equal(t, 2, err.MarshalCount)
+	assert.Equal(t, params, err.Params)
+	assert.False(t, err.Retryable())
+	assert.Empty(t, err.StackFrames)
+	assert.False(t, ShouldRetryDownstream(err))
+
+	// It reads in the same way as an unmarshalled error
+	remote := Unmarshal(Marshal(Augment(NotFound("card", "card not found", nil), "loading card", nil).(*Error)))
+	assert.Equal(t, remote.ErrorMessage(), err.ErrorMessage())
+
+	// The arguments aren't shared
+	chain[0], params["card_id"] = "changed", "changed"
+	assert.Equal(t, []string{"card not found"}, err.MessageChain)
+	assert.Equal(t, "card_123", err.Params["card_id"])
+
+	// Marshalling increments the count as usual
+	assert.Equal(t, int32(3), Marshal(err).MarshalCount)
+}
+
+func TestMarshalOmitStack(t *testing.T) {
+	err := Augment(failyFunction(), "calling", map[string]string{"a": "1"}).(*Error)
+
+	full := MarshalWithOptions(err.cause.(*Error))
+	assert.NotEmpty(t, full.Stack)
+	assert.False(t, full.StackOmitted)
+
+	protoErr := MarshalWithOptions(err.cause.(*Error), OmitStack(), WithProgramCounters())
+	assert.Empty(t, protoErr.Stack)
+	assert.Empty(t, protoErr.StackBuildId)
+	assert.True(t, protoErr.StackOmitted)
+	assert.Less(t, proto.Size(protoErr), proto.Size(full))
+
+	// The chain and params are kept
+	protoErr = MarshalWithOptions(err, OmitStack())
+	assert.Equal(t, []string{"I'm in trouble"}, protoErr.MessageChain)
+	assert.Equal(t, "1", protoErr.Params["a"])
+
+	unmarshalled := Unmarshal(protoErr)
+	assert.True(t, unmarshalled.StackOmitted)
+	assert.Empty(t, unmarshalled.StackFrames)
+
+	// The mark is passed on, until a stack is captured
+	assert.True(t, Marshal(unmarshalled).StackOmitted)
+	assert.True(t, Marshal(addParams(unmarshalled, map[string]string{"b": "2"})).StackOmitted)
+	assert.False(t, Marshal(WrapOpt(unmarshalled, nil, WithStack(true)).(*Error)).StackOmitted)
+}
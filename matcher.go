@@ -0,0 +1,88 @@
+package terrors
+
+import "sync"
+
+// maxMatcherCacheEntries bounds the number of codes whose result a Matcher caches. Codes are usually drawn from a
+// small set, but a Matcher given codes built from untrusted input shouldn't grow without bound.
+const maxMatcherCacheEntries = 1024
+
+// Matcher checks errors against a fixed set of codes, for routing layers which test each error against many codes on
+// every request. It caches the result for each code it sees, including negative results, so after the first error with
+// a given code the check costs a map lookup per terror in the chain, however many codes the Matcher holds.
+//
+// A Matcher is safe for concurrent use. Its decisions aren't passed to the MatchHook.
+type Matcher struct {
+	trie *codeTrie
+
+	mu    sync.RWMutex
+	cache map[string]matcherResult
+}
+
+type matcherResult struct {
+	code    string
+	matched bool
+}
+
+// NewMatcher returns a Matcher for codes. Each code is matched separately, in the same way as a single code given to
+// Is: an error matches if any terror in its chain has a code which starts with it. Note that the arguments of Is are
+// instead the parts of a single code. A Matcher without codes matches nothing.
+func NewMatcher(codes ...string) *Matcher {
+	return &Matcher{
+		trie:  newCodeTrie(codes),
+		cache: map[string]matcherResult{},
+	}
+}
+
+// Match returns whether any terror in the chain of err, including the branches of errors with several causes, has a
+// code which starts with one of the codes of the Matcher.
+func (m *Matcher) Match(err error) bool {
+	_, ok := m.MatchCode(err)
+	return ok
+}
+
+// MatchCode behaves like Match, but also returns the code of the Matcher which matched. The chain is searched from the
+// outside in, and if several codes match the same terror the longest is returned, so that the most specific route can
+// be chosen.
+func (m *Matcher) MatchCode(err error) (string, bool) {
+	switch err := err.(type) {
+	case *Error:
+		if code, ok := m.lookup(err.Code); ok {
+			return code, true
+		}
+		next := err.Unwrap()
+		if next == nil {
+			return "", false
+		}
+		return m.MatchCode(next)
+	case multiUnwrapper:
+		for _, branch := range err.Unwrap() {
+			if code, ok := m.MatchCode(branch); ok {
+				return code, true
+			}
+		}
+	}
+	return "", false
+}
+
+// lookup returns the longest code of the Matcher which code starts with, from the cache if possible.
+func (m *Matcher) lookup(code string) (string, bool) {
+	m.mu.RLock()
+	result, cached := m.cache[code]
+	m.mu.RUnlock()
+	if cached {
+		return result.code, result.matched
+	}
+
+	m.trie.prefixes(code, func(e codeTrieEntry) bool {
+		result = matcherResult{code: e.code, matched: true}
+		return true
+	})
+
+	m.mu.Lock()
+	if len(m.cache) >= maxMatcherCacheEntries {
+		m.cache = make(map[string]matcherResult, len(m.cache))
+	}
+	m.cache[code] = result
+	m.mu.Unlock()
+	return result.code, result.matched
+}
@@ -0,0 +1,102 @@
+package terrors
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher(t *testing.T) {
+	m := NewMatcher(ErrNotFound, "bad_request.missing", "bad_request.missing_param.account_id")
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"nil", nil, ""},
+		{"not a terror", assert.AnError, ""},
+		{"exact", NotFound("", "", nil), ErrNotFound},
+		{"subcode", NotFound("account", "", nil), ErrNotFound},
+		{"no match", Forbidden("", "", nil), ""},
+		{"longest wins", BadRequest("missing_param.account_id", "", nil), "bad_request.missing_param.account_id"},
+		{"prefix of a segment, as with Is", BadRequest("missing_param", "", nil), "bad_request.missing"},
+		{
+			name:     "cause",
+			err:      NewInternalWithCause(NotFound("account", "", nil), "failed", nil, ""),
+			expected: ErrNotFound,
+		},
+		{
+			name:     "branch",
+			err:      Join(Forbidden("", "", nil), NotFound("", "", nil)),
+			expected: ErrNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Twice, to check the cached result
+			for i := 0; i < 2; i++ {
+				code, ok := m.MatchCode(tc.err)
+				assert.Equal(t, tc.expected, code)
+				assert.Equal(t, tc.expected != "", ok)
+				assert.Equal(t, tc.expected != "", m.Match(tc.err))
+				if tc.expected != "" {
+					assert.True(t, Is(tc.err, tc.expected))
+				}
+			}
+		})
+	}
+
+	assert.False(t, NewMatcher().Match(NotFound("", "", nil)))
+}
+
+func TestMatcherCacheIsBounded(t *testing.T) {
+	m := NewMatcher(ErrNotFound)
+	for i := 0; i < maxMatcherCacheEntries*2; i++ {
+		assert.False(t, m.Match(Forbidden(fmt.Sprintf("code_%d", i), "", nil)))
+	}
+	assert.LessOrEqual(t, len(m.cache), maxMatcherCacheEntries)
+	assert.True(t, m.Match(NotFound("", "", nil)))
+}
+
+func TestMatcherConcurrent(t *testing.T) {
+	m := NewMatcher(ErrNotFound, ErrForbidden)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.True(t, m.Match(NotFound(fmt.Sprintf("code_%d", j%10), "", nil)))
+				assert.False(t, m.Match(BadRequest(fmt.Sprintf("code_%d", j%10), "", nil)))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkMatcher(b *testing.B) {
+	codes := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		codes = append(codes, fmt.Sprintf("%s.route_%d", ErrBadRequest, i))
+	}
+	err := Augment(BadRequest("route_49", "", nil), "failed", nil)
+
+	b.Run("Is", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, code := range codes {
+				is(err, code)
+			}
+		}
+	})
+	b.Run("Matcher", func(b *testing.B) {
+		m := NewMatcher(codes...)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.Match(err)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package terrors
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxRenderedBranches is the maximum number of branches of a multi-cause error which are rendered. The rest are
+	// summarised with a count.
+	maxRenderedBranches = 8
+	// maxBranchDepth is the maximum depth of nested multi-cause errors which are rendered.
+	maxBranchDepth = 4
+)
+
+// multiUnwrapper is implemented by errors with several causes, such as those returned by errors.Join and by
+// fmt.Errorf with several %w verbs in Go 1.20 and later.
+type multiUnwrapper interface {
+	Unwrap() []error
+}
+
+// writeMessage writes the messages of err and its causes to output, separated by colons. The branches of multi-cause
+// errors are numbered, and separated by semicolons. At most maxCausalDepth causes are followed, after which the
+// remainder of the chain is elided.
+func writeMessage(output *strings.Builder, err error, maxCausalDepth, branchDepth int) {
+	for next, depth := err, 0; next != nil; depth++ {
+		if depth > 0 {
+			output.WriteString(": ")
+		}
+		if depth > maxCausalDepth {
+			output.WriteString("...")
+			return
+		}
+		switch typed := next.(type) {
+		case *Error:
+			output.WriteString(typed.Message)
+			next = typed.cause
+		case multiUnwrapper:
+			writeBranches(output, typed.Unwrap(), maxCausalDepth, branchDepth)
+			next = nil
+		default:
+			output.WriteString(typed.Error())
+			next = nil
+		}
+	}
+}
+
+// writeBranches writes each of the branches of a multi-cause error to output, in the form
+// `2 errors: [1] first; [2] second`. Terror branches are prefixed with their codes.
+func writeBranches(output *strings.Builder, branches []error, maxCausalDepth, branchDepth int) {
+	branches = nonNilErrors(branches)
+	if branchDepth >= maxBranchDepth {
+		fmt.Fprintf(output, "%d errors: ...", len(branches))
+		return
+	}
+
+	fmt.Fprintf(output, "%d errors: ", len(branches))
+	for i, branch := range branches {
+		if i == maxRenderedBranches {
+			fmt.Fprintf(output, "; ... and %d more", len(branches)-maxRenderedBranches)
+			break
+		}
+		if i > 0 {
+			output.WriteString("; ")
+		}
+		fmt.Fprintf(output, "[%d] ", i+1)
+		if terr, ok := branch.(*Error); ok && terr.Code != "" {
+			output.WriteString(terr.Code)
+			output.WriteString(": ")
+		}
+		writeMessage(output, branch, maxCausalDepth, branchDepth+1)
+	}
+}
+
+func nonNilErrors(errs []error) []error {
+	out := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if err != nil {
+			out = append(out, err)
+		}
+	}
+	return out
+}
+
+// multiError is an error with several causes, which is rendered in the same way as the errors returned by errors.Join.
+type multiError []error
+
+func (m multiError) Error() string {
+	output := &strings.Builder{}
+	writeBranches(output, m, CurrentMaxCausalDepth(), 0)
+	return output.String()
+}
+
+func (m multiError) Unwrap() []error {
+	return m
+}
+
+// Join returns an error whose cause has each of the non-nil errs as a branch, like errors.Join, or nil if there are
+// none. The branches can be found with Is, and (in Go 1.20 and later) with errors.Is and errors.As, and are rendered
+// by Error and StackString. The code of the error is the longest dotted prefix shared by the codes of the branches
+// (e.g. not_found for not_found.account and not_found.card), or internal_service if they share none. Errors which
+// aren't terrors count as the code they would be given by Propagate. The error is only retryable if every branch is,
+// and is unexpected if any branch is.
+func Join(errs ...error) error {
+	branches := nonNilErrors(errs)
+	if len(branches) == 0 {
+		return nil
+	}
+	cause := multiError(branches)
+	err := errorFactory(causeCode(cause), "multiple errors", nil)
+	err.attachCause(cause)
+	return err
+}
+
+// classifyBranches returns the longest code prefix shared by the branches of an error with several causes, whether
+// they are all retryable, and whether any of them are unexpected.
+func classifyBranches(branches []error) (string, bool, bool) {
+	var (
+		common        []string
+		allRetryable  = true
+		anyUnexpected = false
+	)
+	for i, branch := range branches {
+		terr, ok := branch.(*Error)
+		if !ok {
+			terr = untrackedError(causeCode(branch), "", nil)
+			inheritFlags(terr, branch)
+		}
+		allRetryable = allRetryable && terr.Retryable()
+		anyUnexpected = anyUnexpected || terr.Unexpected()
+
+		parts := strings.Split(terr.Code, ".")
+		if i == 0 {
+			common = parts
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(parts) && common[n] == parts[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	code := strings.Join(common, ".")
+	if code == "" {
+		code = ErrInternalService
+	}
+	return code, allRetryable, anyUnexpected
+}
@@ -0,0 +1,123 @@
+package terrors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testJoinedError mirrors the error returned by errors.Join, which isn't available in all the Go versions we support.
+type testJoinedError []error
+
+func (e testJoinedError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e testJoinedError) Unwrap() []error {
+	return e
+}
+
+func TestMultiCauseErrorMessage(t *testing.T) {
+	joined := testJoinedError{
+		Augment(NotFound("user", "user not found", nil), "loading user", nil),
+		nil,
+		errors.New("cache unavailable"),
+	}
+	err := Augment(joined, "loading profile", nil).(*Error)
+	assert.Equal(t,
+		"loading profile: 2 errors: [1] not_found.user: loading user: user not found; [2] cache unavailable",
+		err.ErrorMessage())
+	assert.Equal(t,
+		"internal_service: loading profile: 2 errors: [1] not_found.user: loading user: user not found; [2] cache unavailable",
+		err.Error())
+}
+
+func TestMultiCauseErrorMessageIsBounded(t *testing.T) {
+	t.Run("branches", func(t *testing.T) {
+		var joined testJoinedError
+		for i := 0; i < 20; i++ {
+			joined = append(joined, fmt.Errorf("err %d", i))
+		}
+		msg := Augment(joined, "batch", nil).(*Error).ErrorMessage()
+		assert.True(t, strings.HasPrefix(msg, "batch: 20 errors: [1] err 0; [2] err 1;"))
+		assert.Contains(t, msg, "[8] err 7; ... and 12 more")
+		assert.NotContains(t, msg, "err 8")
+	})
+	t.Run("nesting", func(t *testing.T) {
+		var err error = errors.New("leaf")
+		for i := 0; i < 10; i++ {
+			err = testJoinedError{err}
+		}
+		msg := Augment(err, "nested", nil).(*Error).ErrorMessage()
+		assert.Equal(t, "nested: 1 errors: [1] 1 errors: [1] 1 errors: [1] 1 errors: [1] 1 errors: ...", msg)
+	})
+}
+
+func TestMultiCauseStackString(t *testing.T) {
+	joined := testJoinedError{
+		errors.New("not a terror"),
+		failyFunction(),
+		Augment(failyFunction(), "context", nil),
+	}
+	err := Augment(joined, "fan out", nil).(*Error)
+	ss := err.StackString()
+	t.Log(ss)
+
+	assert.NotContains(t, ss, "--- [1]")
+	assert.Contains(t, ss, "\n--- [2]\n    ")
+	assert.Contains(t, ss, "\n--- [3]\n    ")
+	assert.Equal(t, 2, strings.Count(ss, "failyFunction"))
+	assert.Less(t, len(StackStringWithMaxSize(err, 300)), 300)
+}
+
+func TestJoin(t *testing.T) {
+	assert.Nil(t, Join())
+	assert.Nil(t, Join(nil, nil))
+
+	cause := errors.New("cache unavailable")
+	err := Join(NotFound("account", "account not found", nil), nil, NotFound("card", "card not found", nil)).(*Error)
+	assert.Equal(t, ErrNotFound, err.Code)
+	assert.False(t, err.Retryable())
+	assert.Equal(t, "not_found: multiple errors: 2 errors: [1] not_found.account: account not found; "+
+		"[2] not_found.card: card not found", err.Error())
+	assert.Contains(t, err.StackFrames[0].Method, "TestJoin")
+	assert.True(t, Is(err, ErrNotFound, "card"))
+	assert.False(t, Is(err, ErrNotFound, "user"))
+
+	err = Join(Timeout("a", "", nil), cause).(*Error)
+	assert.Equal(t, ErrInternalService, err.Code)
+	assert.True(t, err.Retryable())
+	assert.True(t, Is(err, ErrTimeout, "a"))
+	assert.Equal(t, cause, err.Unwrap().(multiError)[1])
+
+	// Retryable only if all branches are
+	err = Join(Timeout("a", "", nil), BadRequest("b", "", nil)).(*Error)
+	assert.False(t, err.Retryable())
+
+	// Unexpected if any branch is
+	unexpectedErr := InternalService("", "", nil)
+	unexpectedErr.SetIsUnexpected(true)
+	err = Join(unexpectedErr, Timeout("", "", nil)).(*Error)
+	assert.True(t, err.Unexpected())
+}
+
+func TestMultiCauseCodeAndRetryability(t *testing.T) {
+	joined := testJoinedError{BadRequest("a", "", nil), BadRequest("b", "", nil)}
+	err := Augment(joined, "validating", nil).(*Error)
+	assert.Equal(t, ErrBadRequest, err.Code)
+	assert.False(t, err.Retryable())
+	assert.True(t, Is(err, ErrBadRequest, "b"))
+
+	d := ExplainIs(err, ErrBadRequest, "b")
+	assert.True(t, d.Matched)
+	assert.Contains(t, d.String(), "has 2 causes")
+}
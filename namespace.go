@@ -0,0 +1,122 @@
+package terrors
+
+// ErrorNamespace creates errors whose codes are prefixed with a common namespace, and which carry a common set of
+// params. Use Namespace to create one.
+// The namespace is inserted after the generic code, so that the errors are still matched by Is and PrefixMatches
+// in the usual way: for example, in the "service.payments" namespace
+//
+//	ns.NotFound("card", "card not found", nil)
+//
+// creates an error with the code `not_found.service.payments.card`.
+type ErrorNamespace struct {
+	prefix string
+	params map[string]string
+}
+
+// Namespace returns an ErrorNamespace which prefixes codes with prefix.
+func Namespace(prefix string) *ErrorNamespace {
+	return &ErrorNamespace{prefix: prefix}
+}
+
+// Prefix returns the prefix of the namespace.
+func (n *ErrorNamespace) Prefix() string {
+	return n.prefix
+}
+
+// Sub returns a namespace nested within this one, which inherits its params.
+func (n *ErrorNamespace) Sub(name string) *ErrorNamespace {
+	return &ErrorNamespace{prefix: errCode(n.prefix, name), params: n.params}
+}
+
+// WithParams returns a copy of the namespace which adds params to every error it creates. Params passed to the
+// constructors take precedence over these.
+func (n *ErrorNamespace) WithParams(params map[string]string) *ErrorNamespace {
+	return &ErrorNamespace{prefix: n.prefix, params: mergeParams(n.params, params)}
+}
+
+// code returns the code for an error with the given generic prefix and code, within this namespace.
+func (n *ErrorNamespace) code(generic, code string) string {
+	return errCode(generic, errCode(n.prefix, code))
+}
+
+// withDefaults merges params over the namespace's params.
+func (n *ErrorNamespace) withDefaults(params map[string]string) map[string]string {
+	if len(n.params) == 0 {
+		return params
+	}
+	return mergeParams(n.params, params)
+}
+
+// New creates a new error with the code prefixed by the namespace. Note that unlike the other constructors, there
+// is no generic code ahead of the namespace unless code starts with one.
+func (n *ErrorNamespace) New(code string, message string, params map[string]string) *Error {
+	return errorFactory(errCode(n.prefix, code), message, n.withDefaults(params))
+}
+
+// InternalService creates a new internal service error within the namespace. See InternalService.
+func (n *ErrorNamespace) InternalService(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrInternalService, code), message, n.withDefaults(params))
+}
+
+// NonRetryableInternalService creates a new non-retryable internal service error within the namespace. See
+// NonRetryableInternalService.
+func (n *ErrorNamespace) NonRetryableInternalService(code, message string, params map[string]string) *Error {
+	err := errorFactory(n.code(ErrInternalService, code), message, n.withDefaults(params))
+	err.SetIsRetryable(false)
+	return err
+}
+
+// BadRequest creates a new bad request error within the namespace. See BadRequest.
+func (n *ErrorNamespace) BadRequest(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrBadRequest, code), message, n.withDefaults(params))
+}
+
+// BadResponse creates a new bad response error within the namespace. See BadResponse.
+func (n *ErrorNamespace) BadResponse(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrBadResponse, code), message, n.withDefaults(params))
+}
+
+// Timeout creates a new timeout error within the namespace. See Timeout.
+func (n *ErrorNamespace) Timeout(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrTimeout, code), message, n.withDefaults(params))
+}
+
+// NotFound creates a new not found error within the namespace. See NotFound.
+func (n *ErrorNamespace) NotFound(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrNotFound, code), message, n.withDefaults(params))
+}
+
+// Forbidden creates a new forbidden error within the namespace. See Forbidden.
+func (n *ErrorNamespace) Forbidden(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrForbidden, code), message, n.withDefaults(params))
+}
+
+// Unauthorized creates a new unauthorized error within the namespace. See Unauthorized.
+func (n *ErrorNamespace) Unauthorized(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrUnauthorized, code), message, n.withDefaults(params))
+}
+
+// PreconditionFailed creates a new precondition failed error within the namespace. See PreconditionFailed.
+func (n *ErrorNamespace) PreconditionFailed(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrPreconditionFailed, code), message, n.withDefaults(params))
+}
+
+// RateLimited creates a new rate limited error within the namespace. See RateLimited.
+func (n *ErrorNamespace) RateLimited(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrRateLimited, code), message, n.withDefaults(params))
+}
+
+// Conflict creates a new conflict error within the namespace. See Conflict.
+func (n *ErrorNamespace) Conflict(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrConflict, code), message, n.withDefaults(params))
+}
+
+// NotImplemented creates a new not implemented error within the namespace. See NotImplemented.
+func (n *ErrorNamespace) NotImplemented(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrNotImplemented, code), message, n.withDefaults(params))
+}
+
+// Unavailable creates a new unavailable error within the namespace. See Unavailable.
+func (n *ErrorNamespace) Unavailable(code, message string, params map[string]string) *Error {
+	return errorFactory(n.code(ErrUnavailable, code), message, n.withDefaults(params))
+}
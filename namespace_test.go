@@ -0,0 +1,66 @@
+package terrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	ns := Namespace("service.payments")
+	assert.Equal(t, "service.payments", ns.Prefix())
+
+	cases := []struct {
+		err          *Error
+		expectedCode string
+		retryable    bool
+	}{
+		{ns.InternalService("db", "", nil), "internal_service.service.payments.db", true},
+		{ns.NonRetryableInternalService("db", "", nil), "internal_service.service.payments.db", false},
+		{ns.BadRequest("amount", "", nil), "bad_request.service.payments.amount", false},
+		{ns.BadResponse("format", "", nil), "bad_response.service.payments.format", false},
+		{ns.Timeout("ledger", "", nil), "timeout.service.payments.ledger", true},
+		{ns.NotFound("card", "", nil), "not_found.service.payments.card", false},
+		{ns.Forbidden("card", "", nil), "forbidden.service.payments.card", false},
+		{ns.Unauthorized("card", "", nil), "unauthorized.service.payments.card", false},
+		{ns.PreconditionFailed("state", "", nil), "precondition_failed.service.payments.state", false},
+		{ns.RateLimited("api", "", nil), "rate_limited.service.payments.api", true},
+		{ns.Conflict("api", "", nil), "conflict.service.payments.api", false},
+		{ns.NotImplemented("api", "", nil), "not_implemented.service.payments.api", false},
+		{ns.Unavailable("api", "", nil), "unavailable.service.payments.api", true},
+		{ns.New("custom", "", nil), "service.payments.custom", false},
+		{ns.NotFound("", "", nil), "not_found.service.payments", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expectedCode, func(t *testing.T) {
+			assert.Equal(t, tc.expectedCode, tc.err.Code)
+			assert.Equal(t, tc.retryable, tc.err.Retryable())
+			// The stack starts at the caller of the namespace method
+			assert.Contains(t, tc.err.StackFrames[0].Method, "TestNamespace")
+		})
+	}
+
+	assert.True(t, Is(ns.NotFound("card", "", nil), ErrNotFound))
+}
+
+func TestNamespaceParams(t *testing.T) {
+	ns := Namespace("service.payments").WithParams(map[string]string{"service": "payments", "region": "eu"})
+	sub := ns.Sub("cards").WithParams(map[string]string{"component": "cards"})
+
+	err := sub.NotFound("card", "card not found", map[string]string{"region": "us", "card_id": "123"})
+	assert.Equal(t, "not_found.service.payments.cards.card", err.Code)
+	assert.Equal(t, map[string]string{
+		"service":   "payments",
+		"region":    "us",
+		"component": "cards",
+		"card_id":   "123",
+	}, err.Params)
+
+	// The parent namespace isn't affected by the child's params
+	err = ns.NotFound("card", "card not found", nil)
+	assert.Equal(t, map[string]string{"service": "payments", "region": "eu"}, err.Params)
+
+	// Errors don't share the namespace's params map
+	err.Params["region"] = "changed"
+	assert.Equal(t, "eu", ns.NotFound("card", "", nil).Params["region"])
+}
@@ -0,0 +1,18 @@
+package terrors
+
+import "net"
+
+// Error implements net.Error, so that HTTP clients and retry libraries which type-assert to it make the same decisions
+// for terrors as for network errors.
+var _ net.Error = (*Error)(nil)
+
+// Timeout reports whether the error is a timeout, i.e. whether its code is timeout or starts with `timeout.`.
+func (p *Error) Timeout() bool {
+	return hasCodePrefix(p.Code, ErrTimeout)
+}
+
+// Temporary reports whether the error is temporary, which for a terror is the same as whether it's retryable.
+// It's provided to implement net.Error; use Retryable instead.
+func (p *Error) Temporary() bool {
+	return p.Retryable()
+}
@@ -0,0 +1,28 @@
+package terrors
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetError(t *testing.T) {
+	var netErr net.Error
+	assert.True(t, errors.As(Augment(Timeout("dial", "dial timed out", nil), "calling service.foo", nil), &netErr))
+	assert.True(t, netErr.Timeout())
+	assert.True(t, netErr.Temporary())
+
+	assert.True(t, Timeout("", "timed out", nil).Timeout())
+	assert.False(t, InternalService("", "timeout", nil).Timeout())
+	assert.False(t, New("timeouts", "not a timeout", nil).Timeout())
+
+	// Temporary follows retryability, including when it's set explicitly
+	assert.False(t, NotFound("foo", "bar", nil).Temporary())
+	assert.True(t, InternalService("foo", "bar", nil).Temporary())
+	nonRetryable := Timeout("foo", "bar", nil)
+	nonRetryable.SetIsRetryable(false)
+	assert.True(t, nonRetryable.Timeout())
+	assert.False(t, nonRetryable.Temporary())
+}
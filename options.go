@@ -0,0 +1,131 @@
+package terrors
+
+import (
+	"time"
+
+	"github.com/monzo/terrors/stack"
+)
+
+// ErrorOption configures the error built by NewE.
+type ErrorOption func(*errorOptions)
+
+type errorOptions struct {
+	subCode    string
+	params     map[string]string
+	retryable  *bool
+	unexpected *bool
+	stackSkip  int
+	retryAfter *time.Duration
+	cause      error
+	details    []interface{}
+}
+
+// WithParam sets a param on the error.
+func WithParam(key, value string) ErrorOption {
+	return func(o *errorOptions) {
+		if o.params == nil {
+			o.params = map[string]string{}
+		}
+		o.params[key] = value
+	}
+}
+
+// WithParams sets each of the given params on the error.
+func WithParams(params map[string]string) ErrorOption {
+	return func(o *errorOptions) {
+		if o.params == nil {
+			o.params = make(map[string]string, len(params))
+		}
+		for k, v := range params {
+			o.params[k] = v
+		}
+	}
+}
+
+// WithSubCode appends a sub code to the code of the error, in the same way as the code argument of the built-in
+// constructors, e.g. `NewE(ErrNotFound, "...", WithSubCode("account"))` gives the code not_found.account.
+func WithSubCode(subCode string) ErrorOption {
+	return func(o *errorOptions) {
+		o.subCode = errCode(o.subCode, subCode)
+	}
+}
+
+// WithRetryable explicitly sets the retryability of the error, overriding the default for its code and anything
+// inherited from its cause.
+func WithRetryable(retryable bool) ErrorOption {
+	return func(o *errorOptions) {
+		o.retryable = &retryable
+	}
+}
+
+// WithUnexpected explicitly marks the error as unexpected or not (see SetIsUnexpected).
+func WithUnexpected(unexpected bool) ErrorOption {
+	return func(o *errorOptions) {
+		o.unexpected = &unexpected
+	}
+}
+
+// WithRetryAfter sets a hint of how long the client should wait before retrying the error (see RetryAfter).
+func WithRetryAfter(retryAfter time.Duration) ErrorOption {
+	return func(o *errorOptions) {
+		o.retryAfter = &retryAfter
+	}
+}
+
+// WithStackSkip skips the given number of additional frames when capturing the stack of the error, so that helper
+// functions which construct errors on behalf of their callers can leave themselves out of the stack.
+func WithStackSkip(skip int) ErrorOption {
+	return func(o *errorOptions) {
+		o.stackSkip = skip
+	}
+}
+
+// WithCause sets the cause of the error, in the same way as NewInternalWithCause: the cause is recorded in the message
+// and context chains, and the error inherits its retryability and marshal count.
+func WithCause(cause error) ErrorOption {
+	return func(o *errorOptions) {
+		o.cause = cause
+	}
+}
+
+// WithDetail attaches a typed detail to the error, for retrieval with As (see AttachDetail).
+func WithDetail(detail interface{}) ErrorOption {
+	return func(o *errorOptions) {
+		o.details = append(o.details, detail)
+	}
+}
+
+// NewE creates a new error with the given code and message, configured by options rather than positional arguments:
+//
+//	return terrors.NewE(terrors.ErrNotFound, "account not found",
+//		terrors.WithSubCode("account"),
+//		terrors.WithParam("account_id", id),
+//		terrors.WithCause(err))
+func NewE(code, message string, opts ...ErrorOption) *Error {
+	o := errorOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return newFromOptions(code, message, o)
+}
+
+// newFromOptions builds an error configured by o. Like errorFactory, it must be called directly by a public
+// constructor, so that the stack starts at the caller of the constructor.
+func newFromOptions(code, message string, o errorOptions) *Error {
+	err := buildError(errCode(code, o.subCode), message, o.params)
+	// Skip BuildStack(), newFromOptions() and the public constructor
+	err.StackFrames = stack.BuildStack(3 + o.stackSkip)
+
+	if o.cause != nil {
+		err.attachCause(o.cause)
+	}
+	err.details = o.details
+	if o.retryable != nil {
+		err.setRetryable(*o.retryable, retryabilityReason{kind: retryabilityExplicit})
+	}
+	if o.unexpected != nil {
+		err.SetIsUnexpected(*o.unexpected)
+	}
+	err.RetryAfter = o.retryAfter
+	return err
+}
@@ -0,0 +1,55 @@
+package terrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewE(t *testing.T) {
+	err := NewE(ErrNotFound, "account not found",
+		WithSubCode("account"),
+		WithParam("account_id", "acc_123"),
+		WithParams(map[string]string{"a": "1"}))
+	assert.Equal(t, "not_found.account", err.Code)
+	assert.Equal(t, "account not found", err.Message)
+	assert.Equal(t, map[string]string{"account_id": "acc_123", "a": "1"}, err.Params)
+	assert.False(t, err.Retryable())
+	assert.Contains(t, err.StackFrames[0].Method, "TestNewE")
+
+	err = NewE(ErrBadRequest, "message")
+	assert.Equal(t, "bad_request", err.Code)
+	assert.Equal(t, map[string]string{}, err.Params)
+}
+
+func TestNewEFlags(t *testing.T) {
+	err := NewE(ErrInternalService, "message", WithRetryable(false), WithUnexpected(true))
+	assert.False(t, err.Retryable())
+	assert.True(t, err.Unexpected())
+
+	err = NewE(ErrBadRequest, "message", WithRetryable(true))
+	assert.True(t, err.Retryable())
+}
+
+func TestNewEWithCause(t *testing.T) {
+	cause := NotFound("foo", "foo not found", nil)
+	err := NewE(ErrInternalService, "loading foo", WithCause(cause))
+	assert.True(t, errors.Is(err, cause))
+	assert.Equal(t, []string{"foo not found"}, err.MessageChain)
+	// The retryability of the cause is inherited...
+	assert.False(t, err.Retryable())
+
+	// ...unless it's set explicitly
+	err = NewE(ErrInternalService, "loading foo", WithCause(cause), WithRetryable(true))
+	assert.True(t, err.Retryable())
+}
+
+func newEHelper() *Error {
+	return NewE(ErrInternalService, "message", WithStackSkip(1))
+}
+
+func TestNewEWithStackSkip(t *testing.T) {
+	err := newEHelper()
+	assert.Contains(t, err.StackFrames[0].Method, "TestNewEWithStackSkip")
+}
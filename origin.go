@@ -0,0 +1,39 @@
+package terrors
+
+import (
+	"errors"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+// UnmarshalFrom behaves like Unmarshal, but tags the error with the name of the service it was received from (e.g.
+// from the metadata of the transport), under OriginServiceParam. This lets handlers apply per-dependency policies,
+// with OriginService, without threading the name of the dependency through separately. Any origin set by the
+// service itself is replaced, as the tag describes the hop into this process. An empty service is equivalent to
+// Unmarshal.
+func UnmarshalFrom(p *pe.Error, service string) *Error {
+	err := Unmarshal(p)
+	if service == "" {
+		return err
+	}
+	// mergeParams copies, so the params of p aren't modified
+	err.Params = mergeParams(err.Params, map[string]string{OriginServiceParam: service})
+	return err
+}
+
+// OriginService returns the service the error was received from, as tagged by UnmarshalFrom, or the value of
+// OriginServiceParam set by other means. The chain of err is searched from the outside in, so an error which was
+// received from a dependency and then augmented locally still reports that dependency. It returns an empty string
+// if no error in the chain has an origin.
+func OriginService(err error) string {
+	maxDepth := CurrentMaxCausalDepth()
+	for next, depth := err, 0; next != nil && depth < maxDepth; depth++ {
+		if terr, ok := next.(*Error); ok {
+			if service := terr.Params[OriginServiceParam]; service != "" {
+				return service
+			}
+		}
+		next = errors.Unwrap(next)
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package terrors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalFrom(t *testing.T) {
+	p := Marshal(NotFound("account", "account not found", map[string]string{"account_id": "acc_123"}))
+
+	err := UnmarshalFrom(p, "service.account")
+	assert.Equal(t, "service.account", err.Params[OriginServiceParam])
+	assert.Equal(t, "acc_123", err.Params["account_id"])
+	assert.NotContains(t, p.Params, OriginServiceParam)
+
+	// The tag describes the latest hop
+	p = Marshal(err)
+	assert.Equal(t, "service.ledger", UnmarshalFrom(p, "service.ledger").Params[OriginServiceParam])
+
+	assert.Equal(t, Unmarshal(p).Params, UnmarshalFrom(p, "").Params)
+}
+
+func TestOriginService(t *testing.T) {
+	remote := UnmarshalFrom(Marshal(Timeout("", "timed out", nil)), "service.ledger")
+	assert.Equal(t, "service.ledger", OriginService(remote))
+
+	augmented := Augment(remote, "loading balance", nil)
+	assert.Equal(t, "service.ledger", OriginService(augmented))
+	assert.Equal(t, "service.ledger", OriginService(fmt.Errorf("wrapped: %w", augmented)))
+
+	assert.Equal(t, "", OriginService(NotFound("foo", "bar", nil)))
+	assert.Equal(t, "", OriginService(fmt.Errorf("plain")))
+	assert.Equal(t, "", OriginService(nil))
+}
@@ -0,0 +1,119 @@
+package terrors
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/monzo/terrors/stack"
+)
+
+// ErrPanic is the code of errors created from recovered panics.
+const ErrPanic = ErrInternalService + ".panic"
+
+// PanicHook is called with every error created from a panic by RecoverHTTP and Recover, for example to send an
+// alert. It is called synchronously, before the error is returned to the client.
+type PanicHook func(*Error)
+
+var panicHook PanicHook
+
+// SetPanicHook installs a hook which is called with every error created from a panic by RecoverHTTP and Recover.
+// Passing nil removes the hook, which is the default.
+func SetPanicHook(hook PanicHook) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	panicHook = hook
+}
+
+func currentPanicHook() PanicHook {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return panicHook
+}
+
+// FromPanic creates a new error from a value recovered from a panic. It should be called in the deferred function
+// which recovered, so that the stack of the error starts at the point where the panic was raised. The error is
+// unexpected, as a panic is always a bug, and isn't retryable, as the same request is likely to panic again. If the
+// value is an error, it is attached as the cause.
+func FromPanic(recovered interface{}) *Error {
+	pcs := make([]uintptr, 64)
+	// Skip runtime.Callers() and FromPanic()
+	n := runtime.Callers(2, pcs)
+
+	err := buildError(ErrPanic, fmt.Sprintf("panic: %v", recovered), nil)
+	err.StackFrames = panicStack(stack.BuildStackFromPCs(pcs[:n]))
+	if cause, ok := recovered.(error); ok {
+		err.attachCause(cause)
+	}
+	err.setRetryable(false, retryabilityReason{kind: retryabilityExplicit})
+	err.setUnexpected(true)
+	return err
+}
+
+// panicStack drops the frames of the recovery (the deferred function and the runtime's panic handling) from s, so that
+// it starts at the function which panicked. It is returned as is if it wasn't captured while panicking.
+func panicStack(s stack.Stack) stack.Stack {
+	for i, frame := range s {
+		if frame.Method != "runtime.gopanic" {
+			continue
+		}
+		i++
+		// Panics raised by the runtime (e.g. nil dereferences) have further runtime frames
+		for i < len(s) && strings.HasPrefix(s[i].Method, "runtime.") {
+			i++
+		}
+		return s[i:]
+	}
+	return s
+}
+
+// recoveredError creates the error for a recovered panic, and calls the PanicHook with it.
+func recoveredError(recovered interface{}) *Error {
+	err := FromPanic(recovered)
+	if hook := currentPanicHook(); hook != nil {
+		hook(err)
+	}
+	return err
+}
+
+// RecoverHTTP returns middleware which recovers panics in next, and converts them into unexpected errors (see
+// FromPanic), which are passed to the PanicHook and written to the response with WriteHTTPError. Panics with
+// http.ErrAbortHandler are passed on, as they are used to abort the response deliberately.
+func RecoverHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			WriteHTTPError(w, r, recoveredError(recovered))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Recover converts a panic into an error in the same way as RecoverHTTP, for servers of other transports such as
+// gRPC, whose interceptors can defer it directly and return the error as usual:
+//
+//	func (s *server) intercept(ctx context.Context, ...) (resp interface{}, err error) {
+//		defer terrors.Recover(&err)
+//		return handler(ctx, req)
+//	}
+//
+// The error is passed to the PanicHook and stored in *errp. Like RecoverHTTP, Recover passes on panics with
+// http.ErrAbortHandler, so that it can also be used by HTTP middleware. Recover must be deferred directly, as recover()
+// has no effect otherwise. The grpcerrors package wraps it for gRPC servers, and marshals the errors for the transport.
+func Recover(errp *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if recovered == http.ErrAbortHandler {
+		panic(recovered)
+	}
+	*errp = recoveredError(recovered)
+}
@@ -0,0 +1,110 @@
+package terrors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monzo/terrors/httpbody"
+)
+
+func panickyFunction() {
+	panic("boom")
+}
+
+func nilDereference() int {
+	var p *struct{ n int }
+	return p.n
+}
+
+func withPanicHook(t *testing.T) *[]*Error {
+	var errs []*Error
+	SetPanicHook(func(err *Error) {
+		errs = append(errs, err)
+	})
+	t.Cleanup(func() { SetPanicHook(nil) })
+	return &errs
+}
+
+func TestFromPanic(t *testing.T) {
+	var err *Error
+	func() {
+		defer func() {
+			err = FromPanic(recover())
+		}()
+		panickyFunction()
+	}()
+	assert.Equal(t, ErrPanic, err.Code)
+	assert.Equal(t, "panic: boom", err.Message)
+	assert.True(t, err.Unexpected())
+	assert.False(t, err.Retryable())
+	assert.Contains(t, err.StackFrames[0].Method, "panickyFunction")
+
+	// Runtime panics start at the faulting function
+	func() {
+		defer func() {
+			err = FromPanic(recover())
+		}()
+		nilDereference()
+	}()
+	assert.Contains(t, err.StackFrames[0].Method, "nilDereference")
+	var runtimeErr interface{ RuntimeError() }
+	assert.True(t, errors.As(err.Unwrap(), &runtimeErr))
+}
+
+func TestRecoverHTTP(t *testing.T) {
+	hooked := withPanicHook(t)
+	handler := RecoverHTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panickyFunction()
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	p, err := httpbody.Decode(rec.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, ErrPanic, p.Code)
+	assert.True(t, p.Unexpected.Value)
+
+	assert.Len(t, *hooked, 1)
+	assert.Contains(t, (*hooked)[0].StackFrames[0].Method, "panickyFunction")
+
+	// Deliberate aborts are passed on
+	abort := RecoverHTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		abort.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Len(t, *hooked, 1)
+}
+
+func TestRecover(t *testing.T) {
+	hooked := withPanicHook(t)
+	intercept := func(handler func() error) (err error) {
+		defer Recover(&err)
+		return handler()
+	}
+
+	err := intercept(func() error {
+		panickyFunction()
+		return nil
+	})
+	assert.True(t, Is(err, ErrPanic))
+	assert.Contains(t, err.(*Error).StackFrames[0].Method, "panickyFunction")
+	assert.Len(t, *hooked, 1)
+
+	// Errors returned without a panic are left alone
+	assert.Equal(t, assert.AnError, intercept(func() error { return assert.AnError }))
+	assert.NoError(t, intercept(func() error { return nil }))
+	assert.Len(t, *hooked, 1)
+
+	// Deliberate aborts are passed on
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		intercept(func() error { panic(http.ErrAbortHandler) })
+	})
+	assert.Len(t, *hooked, 1)
+}
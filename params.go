@@ -0,0 +1,82 @@
+package terrors
+
+import (
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	// ParamsDroppedParam records how many params were dropped from an error for exceeding ParamLimits.MaxParams, or
+	// because their key was truncated to the same key as another param's.
+	ParamsDroppedParam = "terrors_params_dropped"
+
+	// truncationMarker is appended to keys and values which were truncated for exceeding ParamLimits, and to
+	// messages cut by ErrorMessageWithLimit.
+	truncationMarker = "...(truncated)"
+)
+
+// limitParams enforces the configured ParamLimits on params. The map passed in is never modified: if any limit is
+// exceeded a new map is returned, otherwise params is returned as-is.
+func limitParams(params map[string]string) map[string]string {
+	limits := CurrentParamLimits()
+	if limits == (ParamLimits{}) || !exceedsLimits(params, limits) {
+		return params
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	dropped := 0
+	if limits.MaxParams > 0 && len(keys) > limits.MaxParams {
+		dropped = len(keys) - limits.MaxParams
+		keys = keys[:limits.MaxParams]
+	}
+
+	limited := make(map[string]string, len(keys)+1)
+	for _, k := range keys {
+		key := truncate(k, limits.MaxKeyLength)
+		if _, ok := limited[key]; ok {
+			// Another key was truncated to the same prefix. The first in sorted order is kept, and this one is counted
+			// as dropped rather than silently overwriting it
+			dropped++
+			continue
+		}
+		limited[key] = truncate(params[k], limits.MaxValueLength)
+	}
+	if dropped > 0 {
+		limited[ParamsDroppedParam] = strconv.Itoa(dropped)
+	}
+	return limited
+}
+
+func exceedsLimits(params map[string]string, limits ParamLimits) bool {
+	if limits.MaxParams > 0 && len(params) > limits.MaxParams {
+		return true
+	}
+	for k, v := range params {
+		if limits.MaxKeyLength > 0 && len(k) > limits.MaxKeyLength {
+			return true
+		}
+		if limits.MaxValueLength > 0 && len(v) > limits.MaxValueLength {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate shortens s to at most max bytes (plus the truncation marker), without splitting a UTF-8 sequence.
+// A max of zero means no limit.
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncationMarker
+}
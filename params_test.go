@@ -0,0 +1,63 @@
+package terrors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withParamLimits(t *testing.T, limits ParamLimits) {
+	previous := CurrentParamLimits()
+	SetParamLimits(limits)
+	t.Cleanup(func() { SetParamLimits(previous) })
+}
+
+func TestParamLimits(t *testing.T) {
+	t.Run("no limits by default", func(t *testing.T) {
+		params := map[string]string{"body": strings.Repeat("x", 1<<20)}
+		err := New("foo", "bar", params)
+		assert.Equal(t, params, err.Params)
+	})
+	t.Run("value length", func(t *testing.T) {
+		withParamLimits(t, ParamLimits{MaxValueLength: 4})
+		params := map[string]string{"short": "abc", "long": "abcdefgh"}
+		err := New("foo", "bar", params)
+		assert.Equal(t, "abc", err.Params["short"])
+		assert.Equal(t, "abcd"+truncationMarker, err.Params["long"])
+		// The caller's map is left alone
+		assert.Equal(t, "abcdefgh", params["long"])
+	})
+	t.Run("value length respects utf8", func(t *testing.T) {
+		withParamLimits(t, ParamLimits{MaxValueLength: 4})
+		err := New("foo", "bar", map[string]string{"k": "abc€"})
+		assert.Equal(t, "abc"+truncationMarker, err.Params["k"])
+	})
+	t.Run("key length", func(t *testing.T) {
+		withParamLimits(t, ParamLimits{MaxKeyLength: 3})
+		err := New("foo", "bar", map[string]string{"abcdef": "v"})
+		assert.Equal(t, map[string]string{"abc" + truncationMarker: "v"}, err.Params)
+	})
+	t.Run("truncated keys which collide", func(t *testing.T) {
+		withParamLimits(t, ParamLimits{MaxKeyLength: 20})
+		err := New("foo", "bar", map[string]string{
+			"request_header_authorization": "a",
+			"request_header_authority":     "b",
+		})
+		assert.Equal(t, map[string]string{
+			"request_header_autho" + truncationMarker: "b",
+			ParamsDroppedParam:                        "1",
+		}, err.Params)
+	})
+	t.Run("count", func(t *testing.T) {
+		withParamLimits(t, ParamLimits{MaxParams: 2})
+		err := New("foo", "bar", map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"})
+		assert.Equal(t, map[string]string{"a": "1", "b": "2", ParamsDroppedParam: "2"}, err.Params)
+	})
+	t.Run("augmentation", func(t *testing.T) {
+		base := New("foo", "bar", map[string]string{"a": "1"})
+		withParamLimits(t, ParamLimits{MaxValueLength: 2})
+		err := Augment(base, "context", map[string]string{"b": "12345"}).(*Error)
+		assert.Equal(t, map[string]string{"a": "1", "b": "12" + truncationMarker}, err.Params)
+	})
+}
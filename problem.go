@@ -0,0 +1,172 @@
+package terrors
+
+import "encoding/json"
+
+// ProblemDetails is an RFC 7807 problem details document, for external APIs which serve application/problem+json.
+type ProblemDetails struct {
+	// Type identifies the kind of problem. It is "about:blank" unless a base is given with WithProblemTypeBase.
+	Type string `json:"type"`
+	// Title is the display name of the code (see DisplayName), which is the text of the HTTP status unless another
+	// name has been registered.
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Detail is the message of the error.
+	Detail string `json:"detail,omitempty"`
+	// Code is the code of the error. It is an extension member.
+	Code string `json:"code"`
+	// MessageChain holds messages from the causal chain of the error, if WithProblemMessageChain is used. It is an
+	// extension member, under "message_chain".
+	MessageChain []string `json:"message_chain,omitempty"`
+	// Extensions holds the other extension members: the params of the error which were allowed with
+	// AllowExternalParams. They are encoded as top-level members of the document.
+	Extensions map[string]string `json:"-"`
+}
+
+// problemMembers are the members of the document which extension members can't replace.
+var problemMembers = map[string]bool{
+	"type":          true,
+	"title":         true,
+	"status":        true,
+	"detail":        true,
+	"instance":      true,
+	"code":          true,
+	"message_chain": true,
+}
+
+// problemFields mirrors the fields of ProblemDetails, without its JSON methods.
+type problemFields ProblemDetails
+
+// MarshalJSON encodes the extension members alongside the standard members.
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(problemFields(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	members := map[string]interface{}{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	for k, v := range p.Extensions {
+		if !problemMembers[k] {
+			members[k] = v
+		}
+	}
+	return json.Marshal(members)
+}
+
+// UnmarshalJSON decodes the document, collecting string extension members into Extensions.
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	var fields problemFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	members := map[string]interface{}{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	*p = ProblemDetails(fields)
+	for k, v := range members {
+		if s, ok := v.(string); ok && !problemMembers[k] {
+			if p.Extensions == nil {
+				p.Extensions = map[string]string{}
+			}
+			p.Extensions[k] = s
+		}
+	}
+	return nil
+}
+
+// ProblemOption configures MarshalProblem.
+type ProblemOption func(*problemOptions)
+
+type problemOptions struct {
+	typeBase     string
+	messageChain int
+	langs        []string
+}
+
+// WithProblemTypeBase sets the type of the document to base followed by the code of the error, e.g.
+// "https://docs.example.com/errors/not_found.account", so that clients can look up the documentation of each code.
+func WithProblemTypeBase(base string) ProblemOption {
+	return func(o *problemOptions) {
+		o.typeBase = base
+	}
+}
+
+// WithProblemMessageChain includes up to max messages from the causal chain of the error, outermost first, in the
+// message_chain member. The messages are masked by the installed SecretScanner, as they are by Marshal, but they may
+// still describe internals, so they should only be included for trusted clients.
+func WithProblemMessageChain(max int) ProblemOption {
+	return func(o *problemOptions) {
+		o.messageChain = max
+	}
+}
+
+// WithProblemLanguages sets the languages accepted by the client, most preferred first, in which the title is given
+// (see DisplayName).
+func WithProblemLanguages(langs ...string) ProblemOption {
+	return func(o *problemOptions) {
+		o.langs = langs
+	}
+}
+
+// MarshalProblem returns the problem details document describing err. Like MarshalExternal, only the code and message
+// are exposed, along with the params allowed with AllowExternalParams, which become extension members. The status is
+// that given by HTTPStatus. If err is not a terror, it is propagated first.
+func MarshalProblem(err error, opts ...ProblemOption) ProblemDetails {
+	o := problemOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var terr *Error
+	if err != nil {
+		terr = Propagate(err).(*Error)
+	}
+	external := MarshalExternal(terr)
+	status := httpStatus(external.Code)
+	p := ProblemDetails{
+		Type:       "about:blank",
+		Title:      DisplayName(external.Code, o.langs...),
+		Status:     status,
+		Detail:     external.Message,
+		Code:       external.Code,
+		Extensions: external.Params,
+	}
+	if o.typeBase != "" {
+		p.Type = o.typeBase + external.Code
+	}
+	if o.messageChain > 0 && terr != nil {
+		p.MessageChain = maskedMessageChain(terr, o.messageChain)
+	}
+	return p
+}
+
+// UnmarshalProblem returns the error described by a problem details document, for clients of APIs which serve them.
+// The code is taken from the code member if it's present, and otherwise derived from the status, as FromHTTPStatus
+// does. The extension members become params. As with Unmarshal, the error has no stack, its flags are derived from
+// its code, and the UnknownCodePolicy is applied.
+func UnmarshalProblem(p ProblemDetails) *Error {
+	code := p.Code
+	if code == "" {
+		code = httpStatusCode(p.Status)
+	}
+	message := p.Detail
+	if message == "" {
+		message = p.Title
+	}
+	err := &Error{
+		Code:    code,
+		Message: message,
+		Params:  mergeParams(p.Extensions, nil),
+	}
+	if len(p.MessageChain) > 0 {
+		err.MessageChain = append([]string(nil), p.MessageChain...)
+		err.ContextChain = make([]ContextEntry, 0, len(p.MessageChain))
+		for _, message := range p.MessageChain {
+			err.ContextChain = append(err.ContextChain, ContextEntry{Message: message})
+		}
+	}
+	applyUnknownCodePolicy(err)
+	return err
+}
@@ -0,0 +1,96 @@
+package terrors
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalProblem(t *testing.T) {
+	withExternalParams(t)
+	AllowExternalParams(ErrRateLimited, "retry_after")
+
+	cause := RateLimited("too_many_requests", "slow down", map[string]string{
+		"retry_after": "30",
+		"account_id":  "acc_123",
+	})
+	err := Augment(cause, "calling ledger", nil)
+
+	p := MarshalProblem(err)
+	assert.Equal(t, ProblemDetails{
+		Type:       "about:blank",
+		Title:      "Too Many Requests",
+		Status:     http.StatusTooManyRequests,
+		Detail:     "calling ledger",
+		Code:       "rate_limited.too_many_requests",
+		Extensions: map[string]string{"retry_after": "30"},
+	}, p)
+
+	data, jsonErr := json.Marshal(p)
+	assert.NoError(t, jsonErr)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Too Many Requests",
+		"status": 429,
+		"detail": "calling ledger",
+		"code": "rate_limited.too_many_requests",
+		"retry_after": "30"
+	}`, string(data))
+
+	p = MarshalProblem(err, WithProblemTypeBase("https://example.com/errors/"), WithProblemMessageChain(1))
+	assert.Equal(t, "https://example.com/errors/rate_limited.too_many_requests", p.Type)
+	assert.Equal(t, []string{"slow down"}, p.MessageChain)
+
+	p = MarshalProblem(errors.New("boom"))
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Equal(t, ErrInternalService, p.Code)
+}
+
+func TestProblemExtensionsDontReplaceMembers(t *testing.T) {
+	p := ProblemDetails{
+		Type:       "about:blank",
+		Status:     http.StatusBadRequest,
+		Code:       ErrBadRequest,
+		Extensions: map[string]string{"status": "200", "code": "ok", "field": "amount"},
+	}
+	data, err := json.Marshal(p)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "about:blank", "title": "", "status": 400, "code": "bad_request", "field": "amount"}`,
+		string(data))
+}
+
+func TestUnmarshalProblem(t *testing.T) {
+	var p ProblemDetails
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"type": "https://example.com/errors/not_found.account",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "account not found",
+		"code": "not_found.account",
+		"message_chain": ["no rows"],
+		"account_id": "acc_123",
+		"attempts": 3
+	}`), &p))
+	assert.Equal(t, map[string]string{"account_id": "acc_123"}, p.Extensions)
+
+	err := UnmarshalProblem(p)
+	assert.Equal(t, "not_found.account", err.Code)
+	assert.Equal(t, "account not found", err.Message)
+	assert.Equal(t, map[string]string{"account_id": "acc_123"}, err.Params)
+	assert.Equal(t, []string{"no rows"}, err.MessageChain)
+	assert.False(t, err.Retryable())
+	assert.Empty(t, err.StackFrames)
+
+	// Documents from other servers may not have a code
+	err = UnmarshalProblem(ProblemDetails{Type: "about:blank", Title: "Service Unavailable", Status: 503})
+	assert.Equal(t, ErrUnavailable, err.Code)
+	assert.Equal(t, "Service Unavailable", err.Message)
+	assert.True(t, err.Retryable())
+
+	// Round trip
+	orig := NotFound("account", "account not found", nil)
+	assert.Equal(t, orig.Code, UnmarshalProblem(MarshalProblem(orig)).Code)
+}
@@ -21,9 +21,13 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type StackFrame struct {
-	Filename             string   `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
-	Line                 int32    `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
-	Method               string   `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`
+	Filename string `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Line     int32  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Method   string `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`
+	// The raw program counter of the frame. Only set when the sender opts in, for symbolication.
+	Pc uint64 `protobuf:"varint,4,opt,name=pc,proto3" json:"pc,omitempty"`
+	// A short hash of the source line of the frame, if source hashing was enabled by the sender.
+	SourceHash           string   `protobuf:"bytes,5,opt,name=source_hash,json=sourceHash,proto3" json:"source_hash,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -75,16 +79,39 @@ func (m *StackFrame) GetMethod() string {
 	return ""
 }
 
+func (m *StackFrame) GetPc() uint64 {
+	if m != nil {
+		return m.Pc
+	}
+	return 0
+}
+
+func (m *StackFrame) GetSourceHash() string {
+	if m != nil {
+		return m.SourceHash
+	}
+	return ""
+}
+
 type Error struct {
 	Code    string            `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
 	Message string            `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	Params  map[string]string `protobuf:"bytes,3,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	Stack   []*StackFrame     `protobuf:"bytes,4,rep,name=stack,proto3" json:"stack,omitempty"`
 	// We don't use google.protobuf.BoolValue as it doesn't serialize properly without jsonpb.
-	Retryable            *BoolValue `protobuf:"bytes,5,opt,name=retryable,proto3" json:"retryable,omitempty"`
-	MarshalCount         int32      `protobuf:"varint,6,opt,name=marshal_count,json=marshalCount,proto3" json:"marshal_count,omitempty"`
-	MessageChain         []string   `protobuf:"bytes,7,rep,name=message_chain,json=messageChain,proto3" json:"message_chain,omitempty"`
-	Unexpected           *BoolValue `protobuf:"bytes,8,opt,name=unexpected,proto3" json:"unexpected,omitempty"`
+	Retryable    *BoolValue `protobuf:"bytes,5,opt,name=retryable,proto3" json:"retryable,omitempty"`
+	MarshalCount int32      `protobuf:"varint,6,opt,name=marshal_count,json=marshalCount,proto3" json:"marshal_count,omitempty"`
+	MessageChain []string   `protobuf:"bytes,7,rep,name=message_chain,json=messageChain,proto3" json:"message_chain,omitempty"`
+	Unexpected   *BoolValue `protobuf:"bytes,8,opt,name=unexpected,proto3" json:"unexpected,omitempty"`
+	// Supersedes message_chain, carrying the code and params of each link as well as its message.
+	ContextChain []*ContextEntry `protobuf:"bytes,9,rep,name=context_chain,json=contextChain,proto3" json:"context_chain,omitempty"`
+	// Identifies the binary which produced the program counters in stack, if they were sent.
+	StackBuildId string `protobuf:"bytes,10,opt,name=stack_build_id,json=stackBuildId,proto3" json:"stack_build_id,omitempty"`
+	// Set when the sender omitted the stack to reduce the size of the error.
+	StackOmitted bool `protobuf:"varint,11,opt,name=stack_omitted,json=stackOmitted,proto3" json:"stack_omitted,omitempty"`
+	// Structured hints for retrying the request which failed, so that clients in any language can retry in the same
+	// way. Unset if there are no hints.
+	RetryInfo            *RetryInfo `protobuf:"bytes,12,opt,name=retry_info,json=retryInfo,proto3" json:"retry_info,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
 	XXX_unrecognized     []byte     `json:"-"`
 	XXX_sizecache        int32      `json:"-"`
@@ -171,6 +198,158 @@ func (m *Error) GetUnexpected() *BoolValue {
 	return nil
 }
 
+func (m *Error) GetContextChain() []*ContextEntry {
+	if m != nil {
+		return m.ContextChain
+	}
+	return nil
+}
+
+func (m *Error) GetStackBuildId() string {
+	if m != nil {
+		return m.StackBuildId
+	}
+	return ""
+}
+
+func (m *Error) GetStackOmitted() bool {
+	if m != nil {
+		return m.StackOmitted
+	}
+	return false
+}
+
+func (m *Error) GetRetryInfo() *RetryInfo {
+	if m != nil {
+		return m.RetryInfo
+	}
+	return nil
+}
+
+// RetryInfo carries hints for retrying a request which failed with an error.
+type RetryInfo struct {
+	// How long to wait before retrying, in milliseconds, e.g. from a rate limiter. Zero if there is no hint.
+	RetryAfterMs int64 `protobuf:"varint,1,opt,name=retry_after_ms,json=retryAfterMs,proto3" json:"retry_after_ms,omitempty"`
+	// The base delay to use for exponential backoff between retries, in milliseconds. Zero if there is no hint.
+	BackoffMs int64 `protobuf:"varint,2,opt,name=backoff_ms,json=backoffMs,proto3" json:"backoff_ms,omitempty"`
+	// The number of attempts which were made before the error was returned, and the maximum allowed by the retry
+	// policy. Zero if unknown.
+	Attempt              int32    `protobuf:"varint,3,opt,name=attempt,proto3" json:"attempt,omitempty"`
+	MaxAttempts          int32    `protobuf:"varint,4,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RetryInfo) Reset()         { *m = RetryInfo{} }
+func (m *RetryInfo) String() string { return proto.CompactTextString(m) }
+func (*RetryInfo) ProtoMessage()    {}
+func (*RetryInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae33a222c066248f, []int{2}
+}
+
+func (m *RetryInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RetryInfo.Unmarshal(m, b)
+}
+func (m *RetryInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RetryInfo.Marshal(b, m, deterministic)
+}
+func (m *RetryInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RetryInfo.Merge(m, src)
+}
+func (m *RetryInfo) XXX_Size() int {
+	return xxx_messageInfo_RetryInfo.Size(m)
+}
+func (m *RetryInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_RetryInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RetryInfo proto.InternalMessageInfo
+
+func (m *RetryInfo) GetRetryAfterMs() int64 {
+	if m != nil {
+		return m.RetryAfterMs
+	}
+	return 0
+}
+
+func (m *RetryInfo) GetBackoffMs() int64 {
+	if m != nil {
+		return m.BackoffMs
+	}
+	return 0
+}
+
+func (m *RetryInfo) GetAttempt() int32 {
+	if m != nil {
+		return m.Attempt
+	}
+	return 0
+}
+
+func (m *RetryInfo) GetMaxAttempts() int32 {
+	if m != nil {
+		return m.MaxAttempts
+	}
+	return 0
+}
+
+// ContextEntry is a single link in the causal chain of an error.
+type ContextEntry struct {
+	Code                 string            `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message              string            `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Params               map[string]string `protobuf:"bytes,3,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ContextEntry) Reset()         { *m = ContextEntry{} }
+func (m *ContextEntry) String() string { return proto.CompactTextString(m) }
+func (*ContextEntry) ProtoMessage()    {}
+func (*ContextEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae33a222c066248f, []int{3}
+}
+
+func (m *ContextEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ContextEntry.Unmarshal(m, b)
+}
+func (m *ContextEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ContextEntry.Marshal(b, m, deterministic)
+}
+func (m *ContextEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContextEntry.Merge(m, src)
+}
+func (m *ContextEntry) XXX_Size() int {
+	return xxx_messageInfo_ContextEntry.Size(m)
+}
+func (m *ContextEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContextEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ContextEntry proto.InternalMessageInfo
+
+func (m *ContextEntry) GetCode() string {
+	if m != nil {
+		return m.Code
+	}
+	return ""
+}
+
+func (m *ContextEntry) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *ContextEntry) GetParams() map[string]string {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
 type BoolValue struct {
 	Value                bool     `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -182,7 +361,7 @@ func (m *BoolValue) Reset()         { *m = BoolValue{} }
 func (m *BoolValue) String() string { return proto.CompactTextString(m) }
 func (*BoolValue) ProtoMessage()    {}
 func (*BoolValue) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ae33a222c066248f, []int{2}
+	return fileDescriptor_ae33a222c066248f, []int{4}
 }
 
 func (m *BoolValue) XXX_Unmarshal(b []byte) error {
@@ -214,6 +393,9 @@ func init() {
 	proto.RegisterType((*StackFrame)(nil), "StackFrame")
 	proto.RegisterType((*Error)(nil), "Error")
 	proto.RegisterMapType((map[string]string)(nil), "Error.ParamsEntry")
+	proto.RegisterType((*RetryInfo)(nil), "RetryInfo")
+	proto.RegisterType((*ContextEntry)(nil), "ContextEntry")
+	proto.RegisterMapType((map[string]string)(nil), "ContextEntry.ParamsEntry")
 	proto.RegisterType((*BoolValue)(nil), "BoolValue")
 }
 
@@ -222,27 +404,41 @@ func init() {
 }
 
 var fileDescriptor_ae33a222c066248f = []byte{
-	// 349 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x51, 0x4d, 0x6f, 0xe2, 0x30,
-	0x10, 0x55, 0x08, 0x09, 0x64, 0xc2, 0xae, 0x56, 0xd6, 0x6a, 0x65, 0x71, 0x0a, 0xec, 0x25, 0xe2,
-	0x90, 0x48, 0xf4, 0xd2, 0xf6, 0x08, 0xa2, 0xe7, 0x2a, 0xad, 0x7a, 0xe8, 0x05, 0x99, 0x30, 0x25,
-	0x11, 0x71, 0x8c, 0x1c, 0xa7, 0x2a, 0xfd, 0xb3, 0xfd, 0x2b, 0x95, 0x1d, 0xf3, 0x21, 0xf5, 0xe4,
-	0xf7, 0xde, 0xbc, 0x79, 0xf6, 0x78, 0x60, 0xb6, 0x2b, 0x55, 0xd1, 0x6e, 0x92, 0x5c, 0xf0, 0x94,
-	0x8b, 0xfa, 0x53, 0xa4, 0x0a, 0xa5, 0x14, 0xb2, 0x49, 0x0f, 0x52, 0x28, 0x91, 0x1a, 0x92, 0x18,
-	0x3c, 0x7d, 0x06, 0x78, 0x52, 0x2c, 0xdf, 0x3f, 0x48, 0xc6, 0x91, 0x8c, 0x61, 0xf8, 0x56, 0x56,
-	0x58, 0x33, 0x8e, 0xd4, 0x89, 0x9c, 0x38, 0xc8, 0xce, 0x9c, 0x10, 0xe8, 0x57, 0x65, 0x8d, 0xb4,
-	0x17, 0x39, 0xb1, 0x97, 0x19, 0x4c, 0xfe, 0x81, 0xcf, 0x51, 0x15, 0x62, 0x4b, 0x5d, 0xe3, 0xb6,
-	0x6c, 0xfa, 0xd5, 0x03, 0x6f, 0xa5, 0x6f, 0xd1, 0x5d, 0xb9, 0xd8, 0x9e, 0xd2, 0x0c, 0x26, 0x14,
-	0x06, 0x1c, 0x9b, 0x86, 0xed, 0xba, 0xb0, 0x20, 0x3b, 0x51, 0x32, 0x03, 0xff, 0xc0, 0x24, 0xe3,
-	0x0d, 0x75, 0x23, 0x37, 0x0e, 0xe7, 0x24, 0x31, 0x29, 0xc9, 0xa3, 0x11, 0x57, 0xb5, 0x92, 0xc7,
-	0xcc, 0x3a, 0xc8, 0x04, 0xbc, 0x46, 0xbf, 0x9c, 0xf6, 0x8d, 0x35, 0x4c, 0x2e, 0x73, 0x64, 0x5d,
-	0x85, 0xc4, 0x10, 0x48, 0x54, 0xf2, 0xc8, 0x36, 0x15, 0x52, 0x2f, 0x72, 0xe2, 0x70, 0x0e, 0xc9,
-	0x42, 0x88, 0xea, 0x85, 0x55, 0x2d, 0x66, 0x97, 0x22, 0xf9, 0x0f, 0xbf, 0x38, 0x93, 0x4d, 0xc1,
-	0xaa, 0x75, 0x2e, 0xda, 0x5a, 0x51, 0xdf, 0x4c, 0x39, 0xb2, 0xe2, 0x52, 0x6b, 0xc6, 0xd4, 0x3d,
-	0x74, 0x9d, 0x17, 0xac, 0xac, 0xe9, 0x20, 0x72, 0xe3, 0x20, 0x1b, 0x59, 0x71, 0xa9, 0x35, 0x32,
-	0x03, 0x68, 0x6b, 0xfc, 0x38, 0x60, 0xae, 0x70, 0x4b, 0x87, 0x3f, 0x2e, 0xbd, 0xaa, 0x8e, 0xef,
-	0x20, 0xbc, 0x9a, 0x8c, 0xfc, 0x01, 0x77, 0x8f, 0x47, 0xfb, 0x55, 0x1a, 0x92, 0xbf, 0xe0, 0xbd,
-	0xeb, 0x2e, 0xfb, 0x4f, 0x1d, 0xb9, 0xef, 0xdd, 0x3a, 0xd3, 0x09, 0x04, 0xe7, 0xcc, 0x8b, 0x4d,
-	0xb7, 0x0e, 0xad, 0x6d, 0xf1, 0xfb, 0x75, 0x64, 0xf7, 0x6e, 0x56, 0xbd, 0xf1, 0xcd, 0x71, 0xf3,
-	0x1d, 0x00, 0x00, 0xff, 0xff, 0x7f, 0xf8, 0x36, 0x67, 0x1f, 0x02, 0x00, 0x00,
+	// 561 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x94, 0x4b, 0x6f, 0xd4, 0x30,
+	0x10, 0xc7, 0x95, 0xdd, 0xcd, 0xb6, 0x99, 0xa4, 0x15, 0xb2, 0x10, 0x32, 0x95, 0x10, 0xdb, 0x85,
+	0x43, 0xe8, 0x21, 0x2b, 0xca, 0x05, 0xb8, 0xb5, 0x55, 0x11, 0x3d, 0x54, 0x20, 0x23, 0x71, 0xe0,
+	0x12, 0x79, 0xb3, 0xde, 0x26, 0x6a, 0x1c, 0x47, 0xb6, 0x83, 0xb6, 0xdc, 0x39, 0x70, 0xe0, 0x8b,
+	0xf0, 0x29, 0x91, 0xc7, 0xee, 0x83, 0xc7, 0x05, 0x71, 0xca, 0xcc, 0xcf, 0x7f, 0xcd, 0x5b, 0x81,
+	0x83, 0x8b, 0xc6, 0xd6, 0xc3, 0xb2, 0xa8, 0x94, 0x5c, 0x48, 0xd5, 0x7d, 0x51, 0x0b, 0x2b, 0xb4,
+	0x56, 0xda, 0x2c, 0x7a, 0xad, 0xac, 0x5a, 0xa0, 0x53, 0xa0, 0x3d, 0xff, 0x1a, 0x01, 0x7c, 0xb0,
+	0xbc, 0xba, 0x7c, 0xa3, 0xb9, 0x14, 0x64, 0x0f, 0xb6, 0xd7, 0x4d, 0x2b, 0x3a, 0x2e, 0x05, 0x8d,
+	0x66, 0x51, 0x9e, 0xb0, 0x1b, 0x9f, 0x10, 0x98, 0xb4, 0x4d, 0x27, 0xe8, 0x68, 0x16, 0xe5, 0x31,
+	0x43, 0x9b, 0x3c, 0x80, 0xa9, 0x14, 0xb6, 0x56, 0x2b, 0x3a, 0x46, 0x75, 0xf0, 0xc8, 0x2e, 0x8c,
+	0xfa, 0x8a, 0x4e, 0x66, 0x51, 0x3e, 0x61, 0xa3, 0xbe, 0x22, 0x8f, 0x21, 0x35, 0x6a, 0xd0, 0x95,
+	0x28, 0x6b, 0x6e, 0x6a, 0x1a, 0xa3, 0x18, 0x3c, 0x7a, 0xcb, 0x4d, 0x3d, 0xff, 0x36, 0x81, 0xf8,
+	0xd4, 0xd5, 0xe5, 0xd2, 0x54, 0x6a, 0x75, 0x9d, 0x1e, 0x6d, 0x42, 0x61, 0x4b, 0x0a, 0x63, 0xf8,
+	0x85, 0xcf, 0x9e, 0xb0, 0x6b, 0x97, 0x1c, 0xc0, 0xb4, 0xe7, 0x9a, 0x4b, 0x43, 0xc7, 0xb3, 0x71,
+	0x9e, 0x1e, 0x92, 0x02, 0xa3, 0x14, 0xef, 0x11, 0x9e, 0x76, 0x56, 0x5f, 0xb1, 0xa0, 0x20, 0xfb,
+	0x10, 0x1b, 0xd7, 0x2a, 0x9d, 0xa0, 0x34, 0x2d, 0x6e, 0x1b, 0x67, 0xfe, 0x85, 0xe4, 0x90, 0x68,
+	0x61, 0xf5, 0x15, 0x5f, 0xb6, 0x02, 0xab, 0x4c, 0x0f, 0xa1, 0x38, 0x56, 0xaa, 0xfd, 0xc8, 0xdb,
+	0x41, 0xb0, 0xdb, 0x47, 0xf2, 0x04, 0x76, 0x24, 0xd7, 0xa6, 0xe6, 0x6d, 0x59, 0xa9, 0xa1, 0xb3,
+	0x74, 0x8a, 0x63, 0xc9, 0x02, 0x3c, 0x71, 0x0c, 0x45, 0xbe, 0xd0, 0xb2, 0xaa, 0x79, 0xd3, 0xd1,
+	0xad, 0xd9, 0x38, 0x4f, 0x58, 0x16, 0xe0, 0x89, 0x63, 0xe4, 0x00, 0x60, 0xe8, 0xc4, 0xa6, 0x17,
+	0x95, 0x15, 0x2b, 0xba, 0xfd, 0x47, 0xd2, 0x3b, 0xaf, 0xe4, 0x10, 0x76, 0x2a, 0xd5, 0x59, 0xb1,
+	0xb1, 0x21, 0x60, 0x82, 0xad, 0xec, 0x14, 0x27, 0x9e, 0xfa, 0x86, 0xb3, 0xa0, 0xf1, 0xf1, 0x9f,
+	0xc2, 0x2e, 0x36, 0x57, 0x2e, 0x87, 0xa6, 0x5d, 0x95, 0xcd, 0x8a, 0x02, 0xce, 0x30, 0x43, 0x7a,
+	0xec, 0xe0, 0xd9, 0xca, 0x95, 0xea, 0x55, 0x4a, 0x36, 0xd6, 0x15, 0x92, 0xce, 0xa2, 0x7c, 0x3b,
+	0x88, 0xde, 0x79, 0x46, 0x9e, 0x01, 0xe0, 0x04, 0xca, 0xa6, 0x5b, 0x2b, 0x9a, 0x85, 0x52, 0x99,
+	0x43, 0x67, 0xdd, 0x5a, 0x85, 0xf9, 0x38, 0x73, 0xef, 0x15, 0xa4, 0x77, 0x76, 0x40, 0xee, 0xc1,
+	0xf8, 0x52, 0x5c, 0x85, 0xa5, 0x3a, 0x93, 0xdc, 0x87, 0xf8, 0xb3, 0xeb, 0x2f, 0x6c, 0xd4, 0x3b,
+	0xaf, 0x47, 0x2f, 0xa3, 0xf9, 0xf7, 0x08, 0x92, 0x9b, 0x98, 0xae, 0x7c, 0x9f, 0x93, 0xaf, 0xad,
+	0xd0, 0xa5, 0x34, 0x18, 0x64, 0xcc, 0x32, 0xa4, 0x47, 0x0e, 0x9e, 0x1b, 0xf2, 0x08, 0x60, 0xc9,
+	0xab, 0x4b, 0xb5, 0x5e, 0x3b, 0xc5, 0x08, 0x15, 0x49, 0x20, 0xe7, 0xc6, 0x1d, 0x10, 0xb7, 0x56,
+	0xc8, 0xde, 0xe2, 0xa1, 0xc6, 0xec, 0xda, 0x25, 0xfb, 0x90, 0x49, 0xbe, 0x29, 0x83, 0x6b, 0xf0,
+	0x66, 0x63, 0x96, 0x4a, 0xbe, 0x39, 0x0a, 0x68, 0xfe, 0x23, 0x82, 0xec, 0xee, 0x7c, 0xff, 0xf1,
+	0x44, 0x9f, 0xff, 0x76, 0xa2, 0x0f, 0x7f, 0x59, 0xd6, 0xdf, 0x2e, 0xf5, 0x7f, 0x86, 0xb7, 0x0f,
+	0xc9, 0xcd, 0xe9, 0xdc, 0xca, 0x22, 0x5c, 0xa6, 0x77, 0x8e, 0x77, 0x3f, 0x65, 0xe1, 0x87, 0x80,
+	0xff, 0x80, 0xe5, 0x14, 0x3f, 0x2f, 0x7e, 0x0e, 0x00, 0x0d, 0x9e, 0x05, 0xbf, 0x38, 0x04, 0x00,
+	0x00,
 }
@@ -0,0 +1,108 @@
+// Package retry implements retry loops driven by the semantics of terrors: whether an error is retryable, how many
+// times it has already crossed a process boundary, how old it is, and any hint from the server about when to retry.
+package retry
+
+import (
+	"context"
+	"time"
+
+	"github.com/monzo/terrors"
+)
+
+// Policy controls how Do retries.
+type Policy struct {
+	// MaxAttempts is the maximum number of times the function is called, including the first. Values less than one
+	// are treated as one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles with each subsequent retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts, including delays hinted by the error. Zero means no cap.
+	MaxDelay time.Duration
+	// MaxMarshalCount stops retrying errors which have been marshalled more than this many times, i.e. which have
+	// already travelled through that many services, to avoid retries on top of retries. Zero means no limit.
+	MaxMarshalCount int
+	// MaxAge stops retrying errors which were created more than this long ago. Zero means no limit.
+	MaxAge time.Duration
+}
+
+// DefaultPolicy is a reasonable policy for calls to other services.
+var DefaultPolicy = Policy{
+	MaxAttempts:     3,
+	BaseDelay:       50 * time.Millisecond,
+	MaxDelay:        2 * time.Second,
+	MaxMarshalCount: 1,
+}
+
+// sleep waits for d, or until ctx is done. It is a variable so that it can be replaced in tests.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Do calls fn until it succeeds, returns an error which shouldn't be retried, or the policy is exhausted. Errors
+// which aren't terrors are treated as they would be by terrors.Propagate.
+// The final error is returned with the number of attempts recorded on it (see terrors.Attempts). If ctx is done while
+// waiting to retry, the last error returned by fn is returned.
+func Do(ctx context.Context, fn func(ctx context.Context) error, policy Policy) error {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	attempt := 0
+	for attempt < maxAttempts {
+		attempt++
+		err = fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if attempt == maxAttempts || !policy.shouldRetry(err) {
+			break
+		}
+		if sleep(ctx, policy.delay(attempt, err)) != nil {
+			break
+		}
+	}
+	return terrors.WithAttempt(err, attempt, maxAttempts)
+}
+
+// shouldRetry reports whether err may be retried under the policy.
+func (p Policy) shouldRetry(err error) bool {
+	summary := terrors.Summary(err)
+	if !summary.Retryable {
+		return false
+	}
+	if terr, ok := err.(*terrors.Error); ok && p.MaxMarshalCount > 0 && terr.MarshalCount > p.MaxMarshalCount {
+		return false
+	}
+	if p.MaxAge > 0 && summary.Age > p.MaxAge {
+		return false
+	}
+	return true
+}
+
+// delay returns how long to wait after the given attempt failed with err. A hint from the error (see
+// terrors.RetryAfter) takes precedence over the exponential backoff.
+func (p Policy) delay(attempt int, err error) time.Duration {
+	d, ok := terrors.RetryAfter(err)
+	if !ok {
+		d = p.BaseDelay
+		for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+			d *= 2
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
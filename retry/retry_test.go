@@ -0,0 +1,121 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monzo/terrors"
+)
+
+// recordSleeps replaces sleep for the duration of the test, recording the delays requested.
+func recordSleeps(t *testing.T) *[]time.Duration {
+	var delays []time.Duration
+	previous := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { sleep = previous })
+	return &delays
+}
+
+func failing(errs ...error) (func(context.Context) error, *int) {
+	calls := 0
+	return func(context.Context) error {
+		calls++
+		if calls > len(errs) {
+			return nil
+		}
+		return errs[calls-1]
+	}, &calls
+}
+
+func TestDo(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond, MaxDelay: 15 * time.Millisecond}
+
+	t.Run("success after retries", func(t *testing.T) {
+		delays := recordSleeps(t)
+		fn, calls := failing(terrors.Timeout("a", "b", nil), terrors.Timeout("a", "b", nil))
+		assert.NoError(t, Do(context.Background(), fn, policy))
+		assert.Equal(t, 3, *calls)
+		assert.Equal(t, []time.Duration{10 * time.Millisecond, 15 * time.Millisecond}, *delays)
+	})
+	t.Run("exhausted", func(t *testing.T) {
+		recordSleeps(t)
+		timeout := terrors.Timeout("a", "b", nil)
+		fn, calls := failing(timeout, timeout, timeout, timeout)
+		err := Do(context.Background(), fn, policy)
+		assert.Equal(t, 3, *calls)
+		assert.True(t, terrors.Is(err, terrors.ErrTimeout))
+		attempt, maxAttempts, ok := terrors.Attempts(err)
+		assert.True(t, ok)
+		assert.Equal(t, 3, attempt)
+		assert.Equal(t, 3, maxAttempts)
+	})
+	t.Run("not retryable", func(t *testing.T) {
+		recordSleeps(t)
+		fn, calls := failing(terrors.BadRequest("a", "b", nil))
+		err := Do(context.Background(), fn, policy)
+		assert.Equal(t, 1, *calls)
+		attempt, _, _ := terrors.Attempts(err)
+		assert.Equal(t, 1, attempt)
+	})
+	t.Run("non-terror", func(t *testing.T) {
+		recordSleeps(t)
+		fn, calls := failing(assert.AnError)
+		assert.NoError(t, Do(context.Background(), fn, policy))
+		assert.Equal(t, 2, *calls)
+	})
+	t.Run("marshal count", func(t *testing.T) {
+		recordSleeps(t)
+		marshalled := terrors.Unmarshal(terrors.Marshal(terrors.Unmarshal(terrors.Marshal(terrors.Timeout("a", "b", nil)))))
+		fn, calls := failing(marshalled)
+		Do(context.Background(), fn, Policy{MaxAttempts: 3, MaxMarshalCount: 1})
+		assert.Equal(t, 1, *calls)
+
+		fn, calls = failing(marshalled)
+		assert.NoError(t, Do(context.Background(), fn, Policy{MaxAttempts: 3, MaxMarshalCount: 2}))
+		assert.Equal(t, 2, *calls)
+	})
+	t.Run("age", func(t *testing.T) {
+		recordSleeps(t)
+		old := terrors.Timeout("a", "b", nil)
+		time.Sleep(5 * time.Millisecond)
+		fn, calls := failing(old)
+		Do(context.Background(), fn, Policy{MaxAttempts: 3, MaxAge: time.Millisecond})
+		assert.Equal(t, 1, *calls)
+	})
+	t.Run("retry after hint", func(t *testing.T) {
+		delays := recordSleeps(t)
+		fn, _ := failing(
+			terrors.RateLimited("a", "b", map[string]string{"retry_after": "100ms"}),
+			terrors.RateLimited("a", "b", map[string]string{terrors.HTTPRetryAfterParam: "2"}),
+		)
+		assert.NoError(t, Do(context.Background(), fn, Policy{MaxAttempts: 3, MaxDelay: time.Second}))
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, time.Second}, *delays)
+	})
+	t.Run("context done", func(t *testing.T) {
+		recordSleeps(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		fn, calls := failing(terrors.Timeout("a", "b", nil))
+		err := Do(ctx, fn, policy)
+		assert.Equal(t, 1, *calls)
+		assert.True(t, terrors.Is(err, terrors.ErrTimeout))
+	})
+	t.Run("at least one attempt", func(t *testing.T) {
+		fn, calls := failing(terrors.Timeout("a", "b", nil))
+		assert.Error(t, Do(context.Background(), fn, Policy{}))
+		assert.Equal(t, 1, *calls)
+	})
+}
+
+func TestSleep(t *testing.T) {
+	assert.NoError(t, sleep(context.Background(), time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, sleep(ctx, time.Hour))
+}
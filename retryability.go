@@ -0,0 +1,129 @@
+package terrors
+
+import (
+	"fmt"
+)
+
+type retryabilityKind uint8
+
+const (
+	// The retryability was derived from the code of the error when it was created.
+	retryabilityCodeDefault retryabilityKind = iota + 1
+	// The retryability was set with SetIsRetryable.
+	retryabilityExplicit
+	// The retryability was inherited from a terror cause.
+	retryabilityInheritedTerror
+	// The retryability was inherited from a cause implementing Retryable() bool.
+	retryabilityInheritedInterface
+	// The retryability was unmarshalled from another service.
+	retryabilityRemote
+)
+
+// retryabilityReason records why IsRetryable was set to the value it was, for RetryabilityReason.
+type retryabilityReason struct {
+	kind  retryabilityKind
+	value bool
+	// detail is the retryable code prefix which matched (for retryabilityCodeDefault), the code of the cause (for
+	// retryabilityInheritedTerror) or the type of the cause (for retryabilityInheritedInterface).
+	detail string
+	// cause is the reason of the cause, for retryabilityInheritedTerror.
+	cause *retryabilityReason
+}
+
+// setRetryable sets the retryability of the error, recording why.
+func (p *Error) setRetryable(value bool, reason retryabilityReason) {
+	if value {
+		p.IsRetryable = &retryable
+	} else {
+		p.IsRetryable = &notRetryable
+	}
+	reason.value = value
+	p.retryableReason = reason
+}
+
+// inheritRetryable sets the retryability of the error to that of a terror cause, if it has one.
+func (p *Error) inheritRetryable(cause *Error) {
+	if cause.IsRetryable == nil {
+		return
+	}
+	causeReason := cause.retryableReason
+	p.IsRetryable = cause.IsRetryable
+	p.retryableReason = retryabilityReason{
+		kind:   retryabilityInheritedTerror,
+		value:  *cause.IsRetryable,
+		detail: cause.Code,
+		cause:  &causeReason,
+	}
+}
+
+// retryableCodePrefix returns the retryable code which code is matched by, if any. Codes registered with RegisterCode
+// take precedence, and may make the code non-retryable, in which case the registered code is returned with false.
+func retryableCodePrefix(p *Error) (string, bool) {
+	if registered, retryable, ok := registeredRetryability(p.Code); ok {
+		return registered, retryable
+	}
+	return currentRetryableCodeTrie().shortestPrefix(p.Code)
+}
+
+// RetryabilityReason explains why err is or isn't retryable, for example
+//
+//	not retryable: inherited from cause "bad_request.invalid_amount" (code "bad_request.invalid_amount" doesn't
+//	match any of the retryable codes)
+//
+// It is intended to help debug surprising retry behaviour; the format of the explanation may change. Errors which
+// aren't terrors are explained as they would be if they were passed to Propagate.
+func RetryabilityReason(err error) string {
+	if err == nil {
+		return "not retryable: there is no error"
+	}
+	terr := inspect(err)
+	prefix := "not retryable: "
+	if terr.Retryable() {
+		prefix = "retryable: "
+	}
+	return prefix + terr.explainRetryable()
+}
+
+// explainRetryable returns the reason for the retryability of p, without saying what it is.
+func (p *Error) explainRetryable() string {
+	if p.IsRetryable == nil {
+		prefix, ok := retryableCodePrefix(p)
+		return "not set, so derived from the code: " + explainCodeDefault(p.Code, prefix, ok)
+	}
+
+	reason := p.retryableReason
+	if reason.kind == 0 || reason.value != *p.IsRetryable {
+		return "IsRetryable was set directly"
+	}
+	return reason.explain(p.Code)
+}
+
+// explain explains the reason, which belongs to an error with the given code.
+func (r *retryabilityReason) explain(code string) string {
+	switch r.kind {
+	case retryabilityCodeDefault:
+		return explainCodeDefault(code, r.detail, r.value)
+	case retryabilityExplicit:
+		return "set explicitly"
+	case retryabilityInheritedTerror:
+		if r.cause == nil || r.cause.kind == 0 || r.cause.value != r.value {
+			return fmt.Sprintf("inherited from cause %q", r.detail)
+		}
+		return fmt.Sprintf("inherited from cause %q (%s)", r.detail, r.cause.explain(r.detail))
+	case retryabilityInheritedInterface:
+		return fmt.Sprintf("inherited from cause of type %s, which implements Retryable()", r.detail)
+	case retryabilityRemote:
+		return "set by the service which sent the error"
+	}
+	return "unknown"
+}
+
+func explainCodeDefault(code, prefix string, matched bool) string {
+	if matched {
+		return fmt.Sprintf("code %q matches the retryable code %q", code, prefix)
+	}
+	if prefix != "" {
+		return fmt.Sprintf("code %q matches the non-retryable code %q", code, prefix)
+	}
+	return fmt.Sprintf("code %q doesn't match any of the retryable codes", code)
+}
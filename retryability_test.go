@@ -0,0 +1,81 @@
+package terrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryabilityReason(t *testing.T) {
+	nonRetryableCause := BadRequest("invalid_amount", "", nil)
+	setExplicitly := NotFound("foo", "", nil)
+	setExplicitly.SetIsRetryable(true)
+	setDirectly := NotFound("foo", "", nil)
+	setDirectly.IsRetryable = &retryable
+	remote := Unmarshal(Marshal(Timeout("foo", "", nil)))
+
+	cases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"nil", nil, "not retryable: there is no error"},
+		{"code default", Timeout("foo", "", nil),
+			`retryable: code "timeout.foo" matches the retryable code "timeout"`},
+		{"code default not matched", BadRequest("foo", "", nil),
+			`not retryable: code "bad_request.foo" doesn't match any of the retryable codes`},
+		{"not implemented", NotImplemented("foo", "", nil),
+			`not retryable: code "not_implemented.foo" doesn't match any of the retryable codes`},
+		{"unavailable", Unavailable("db", "", nil),
+			`retryable: code "unavailable.db" matches the retryable code "unavailable"`},
+		{"explicit", setExplicitly, "retryable: set explicitly"},
+		{"set directly", setDirectly, "retryable: IsRetryable was set directly"},
+		{"not set", &Error{Code: ErrRateLimited},
+			`retryable: not set, so derived from the code: code "rate_limited" matches the retryable code "rate_limited"`},
+		{"inherited from terror", Augment(nonRetryableCause, "context", nil),
+			`not retryable: code "bad_request.invalid_amount" doesn't match any of the retryable codes`},
+		{"inherited by NewInternalWithCause", NewInternalWithCause(nonRetryableCause, "context", nil, ""),
+			`not retryable: inherited from cause "bad_request.invalid_amount" (code "bad_request.invalid_amount" doesn't match any of the retryable codes)`},
+		{"inherited through a wrapper", WrapWithCode(fmt.Errorf("wrapped: %w", nonRetryableCause), nil, ErrInternalService),
+			`not retryable: inherited from cause "bad_request.invalid_amount" (code "bad_request.invalid_amount" doesn't match any of the retryable codes)`},
+		{"inherited from interface", Propagate(&testRetryableError{false}),
+			"not retryable: inherited from cause of type *terrors.testRetryableError, which implements Retryable()"},
+		{"remote", remote, "retryable: set by the service which sent the error"},
+		{"remote augmented", Augment(remote, "context", nil), "retryable: set by the service which sent the error"},
+		{"non-terror", errors.New("boom"),
+			`retryable: code "internal_service" matches the retryable code "internal_service"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, RetryabilityReason(tc.err))
+		})
+	}
+}
+
+func TestSetRetryableCodes(t *testing.T) {
+	defer SetRetryableCodes(nil)
+
+	assert.Equal(t, DefaultRetryableCodes(), CurrentRetryableCodes())
+	assert.True(t, New(ErrUnknown, "", nil).Retryable())
+
+	SetRetryableCodes([]string{ErrTimeout})
+	assert.Equal(t, []string{ErrTimeout}, CurrentRetryableCodes())
+	assert.False(t, New(ErrUnknown, "", nil).Retryable())
+	assert.False(t, (&Error{Code: ErrInternalService}).Retryable())
+	assert.True(t, Timeout("foo", "", nil).Retryable())
+
+	AddRetryableCode("degraded")
+	AddRetryableCode("degraded")
+	assert.Equal(t, []string{ErrTimeout, "degraded"}, CurrentRetryableCodes())
+	assert.True(t, New("degraded.search", "", nil).Retryable())
+
+	// The returned codes are a copy
+	CurrentRetryableCodes()[0] = "changed"
+	assert.Equal(t, ErrTimeout, CurrentRetryableCodes()[0])
+
+	SetRetryableCodes(nil)
+	assert.Equal(t, DefaultRetryableCodes(), CurrentRetryableCodes())
+	assert.True(t, New(ErrUnknown, "", nil).Retryable())
+}
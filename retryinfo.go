@@ -0,0 +1,115 @@
+package terrors
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+// Params holding hints for retrying. The values may be a number of seconds (as in the Retry-After HTTP header) or a Go
+// duration string. They are sent in the structured retry info of the marshalled error, along with the attempts (see
+// WithAttempt), so that clients in other languages can read them without parsing params.
+const (
+	// RetryAfterParam is how long to wait before retrying.
+	RetryAfterParam = "retry_after"
+	// RetryBackoffParam is the base delay to use for exponential backoff between retries.
+	RetryBackoffParam = "retry_backoff"
+)
+
+// parseRetryDuration parses the value of a retry hint param.
+func parseRetryDuration(raw string) (time.Duration, bool) {
+	if raw == "" {
+		// Most errors have no hint, and failing to parse one allocates
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+		return d, true
+	}
+	return 0, false
+}
+
+// RetryAfter returns how long to wait before retrying err, if the server which returned it knows. It is the RetryAfter
+// field of the first terror in the chain of err which has one set, or the value of its RetryAfterParam (or
+// HTTPRetryAfterParam) param, so errors from services which only send params are understood too. The hint of a cause
+// applies to the errors it causes, in the same way as its retryability.
+func RetryAfter(err error) (time.Duration, bool) {
+	maxDepth := CurrentMaxCausalDepth()
+	for next, depth := err, 0; next != nil && depth < maxDepth; depth++ {
+		if terr, ok := next.(*Error); ok {
+			if d, ok := terr.ownRetryAfter(); ok {
+				return d, true
+			}
+		}
+		next = errors.Unwrap(next)
+	}
+	return 0, false
+}
+
+// ownRetryAfter returns the retry hint of p itself, ignoring its causes: its RetryAfter field, or else its
+// RetryAfterParam or HTTPRetryAfterParam param.
+func (p *Error) ownRetryAfter() (time.Duration, bool) {
+	if p.RetryAfter != nil {
+		return *p.RetryAfter, true
+	}
+	if d, ok := parseRetryDuration(p.Params[RetryAfterParam]); ok {
+		return d, true
+	}
+	return parseRetryDuration(p.Params[HTTPRetryAfterParam])
+}
+
+// retryInfoToProto returns the structured retry info of e, or nil if there is none. Only e itself is read, as Augment
+// and Wrap carry the hints of a cause over to the errors built from it.
+func retryInfoToProto(e *Error) *pe.RetryInfo {
+	var info pe.RetryInfo
+	if d, ok := e.ownRetryAfter(); ok {
+		info.RetryAfterMs = d.Milliseconds()
+	}
+	if d, ok := parseRetryDuration(e.Params[RetryBackoffParam]); ok {
+		info.BackoffMs = d.Milliseconds()
+	}
+	if attempt, maxAttempts, ok := parseAttempts(e.Params); ok {
+		info.Attempt = int32(attempt)
+		info.MaxAttempts = int32(maxAttempts)
+	}
+	if info.RetryAfterMs == 0 && info.BackoffMs == 0 && info.MaxAttempts == 0 {
+		return nil
+	}
+	return &pe.RetryInfo{
+		RetryAfterMs: info.RetryAfterMs,
+		BackoffMs:    info.BackoffMs,
+		Attempt:      info.Attempt,
+		MaxAttempts:  info.MaxAttempts,
+	}
+}
+
+// retryInfoParams returns the params described by structured retry info which aren't already in params, so that
+// errors from clients which only send the structured form behave in the same way as those which send params.
+func retryInfoParams(info *pe.RetryInfo, params map[string]string) map[string]string {
+	if info == nil {
+		return nil
+	}
+	missing := map[string]string{}
+	add := func(key, value string) {
+		if _, ok := params[key]; !ok {
+			missing[key] = value
+		}
+	}
+	if info.RetryAfterMs > 0 {
+		add(RetryAfterParam, (time.Duration(info.RetryAfterMs) * time.Millisecond).String())
+	}
+	if info.BackoffMs > 0 {
+		add(RetryBackoffParam, (time.Duration(info.BackoffMs) * time.Millisecond).String())
+	}
+	if info.Attempt > 0 && info.MaxAttempts > 0 {
+		if _, _, ok := parseAttempts(params); !ok {
+			missing[AttemptParam] = strconv.Itoa(int(info.Attempt))
+			missing[MaxAttemptsParam] = strconv.Itoa(int(info.MaxAttempts))
+		}
+	}
+	return missing
+}
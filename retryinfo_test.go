@@ -0,0 +1,157 @@
+package terrors
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+func TestMarshalRetryInfo(t *testing.T) {
+	err := RateLimited("too_many_requests", "slow down", map[string]string{
+		RetryAfterParam:   "30",
+		RetryBackoffParam: "200ms",
+	})
+	err = WithAttempt(err, 2, 5).(*Error)
+
+	info := Marshal(err).GetRetryInfo()
+	assert.Equal(t, int64(30000), info.GetRetryAfterMs())
+	assert.Equal(t, int64(200), info.GetBackoffMs())
+	assert.Equal(t, int32(2), info.GetAttempt())
+	assert.Equal(t, int32(5), info.GetMaxAttempts())
+
+	// The raw Retry-After header is used if there's no other hint
+	err = RateLimited("too_many_requests", "slow down", map[string]string{HTTPRetryAfterParam: "2"})
+	assert.Equal(t, int64(2000), Marshal(err).GetRetryInfo().GetRetryAfterMs())
+
+	// Unparseable hints are ignored, and errors without hints have no retry info
+	err = RateLimited("too_many_requests", "slow down", map[string]string{RetryAfterParam: "soon"})
+	assert.Nil(t, Marshal(err).RetryInfo)
+	assert.Nil(t, Marshal(NotFound("foo", "bar", nil)).RetryInfo)
+}
+
+func TestUnmarshalRetryInfo(t *testing.T) {
+	p := &pe.Error{
+		Code:    ErrRateLimited,
+		Message: "slow down",
+		Params:  map[string]string{"a": "1"},
+		RetryInfo: &pe.RetryInfo{
+			RetryAfterMs: 1500,
+			BackoffMs:    200,
+			Attempt:      2,
+			MaxAttempts:  5,
+		},
+	}
+	err := Unmarshal(p)
+	assert.Equal(t, map[string]string{
+		"a":               "1",
+		RetryAfterParam:   "1.5s",
+		RetryBackoffParam: "200ms",
+		AttemptParam:      "2",
+		MaxAttemptsParam:  "5",
+	}, err.Params)
+	assert.Equal(t, map[string]string{"a": "1"}, p.Params)
+
+	attempt, maxAttempts, ok := Attempts(err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, attempt)
+	assert.Equal(t, 5, maxAttempts)
+
+	// It survives a round trip
+	assert.Equal(t, p.RetryInfo.RetryAfterMs, Marshal(err).GetRetryInfo().GetRetryAfterMs())
+
+	// Params sent by the client take precedence
+	p.Params = map[string]string{RetryAfterParam: "3"}
+	assert.Equal(t, "3", Unmarshal(p).Params[RetryAfterParam])
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := RateLimitedWithRetryAfter("too_many_requests", "slow down", 1500*time.Millisecond, nil)
+	assert.Equal(t, "rate_limited.too_many_requests", err.Code)
+	assert.Contains(t, err.StackFrames[0].Method, "TestRetryAfter")
+	d, ok := RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 1500*time.Millisecond, d)
+
+	// The hint of a cause applies to the errors it causes
+	for _, wrapped := range []error{
+		Augment(err, "calling ledger", nil),
+		NewInternalWithCause(err, "calling ledger", nil, ""),
+		fmt.Errorf("calling ledger: %w", err),
+	} {
+		d, ok := RetryAfter(wrapped)
+		assert.True(t, ok)
+		assert.Equal(t, 1500*time.Millisecond, d)
+	}
+
+	// Params are used if the field isn't set
+	d, ok = RetryAfter(Unavailable("", "down", map[string]string{HTTPRetryAfterParam: "5"}))
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, d)
+
+	_, ok = RetryAfter(RateLimited("", "slow down", nil))
+	assert.False(t, ok)
+	_, ok = RetryAfter(nil)
+	assert.False(t, ok)
+
+	// The builder and NewE can set it too
+	d, _ = RetryAfter(Build(ErrUnavailable).RetryAfter(time.Second).Err())
+	assert.Equal(t, time.Second, d)
+	d, _ = RetryAfter(NewE(ErrUnavailable, "down", WithRetryAfter(2*time.Second)))
+	assert.Equal(t, 2*time.Second, d)
+}
+
+func TestRetryAfterWire(t *testing.T) {
+	err := RateLimitedWithRetryAfter("", "slow down", 30*time.Second, nil)
+	p := Marshal(Augment(err, "calling ledger", nil).(*Error))
+	assert.Equal(t, int64(30000), p.GetRetryInfo().GetRetryAfterMs())
+
+	unmarshalled := Unmarshal(p)
+	if assert.NotNil(t, unmarshalled.RetryAfter) {
+		assert.Equal(t, 30*time.Second, *unmarshalled.RetryAfter)
+	}
+
+	// The field takes precedence over params
+	err.Params[RetryAfterParam] = "5"
+	assert.Equal(t, int64(30000), Marshal(err).GetRetryInfo().GetRetryAfterMs())
+
+	for _, name := range []string{CodecJSON, CodecHeader} {
+		codec, _ := LookupCodec(name)
+		data, encodeErr := codec.Encode(err)
+		assert.NoError(t, encodeErr)
+		decoded, decodeErr := codec.Decode(data)
+		assert.NoError(t, decodeErr)
+		if assert.NotNil(t, decoded.RetryAfter, name) {
+			assert.Equal(t, 30*time.Second, *decoded.RetryAfter, name)
+		}
+	}
+
+	// The JSON form of the error has it too
+	data, jsonErr := json.Marshal(err)
+	assert.NoError(t, jsonErr)
+	var fromJSON Error
+	assert.NoError(t, json.Unmarshal(data, &fromJSON))
+	if assert.NotNil(t, fromJSON.RetryAfter) {
+		assert.Equal(t, 30*time.Second, *fromJSON.RetryAfter)
+	}
+}
+
+func TestMarshalWithoutRetryInfoDoesNotParse(t *testing.T) {
+	err := NotFound("foo", "bar", map[string]string{"k": "v"})
+	assert.Nil(t, retryInfoToProto(err))
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		_ = retryInfoToProto(err)
+	}))
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	err := NotFound("foo", "bar", map[string]string{"k": "v"})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Marshal(err)
+	}
+}
@@ -0,0 +1,120 @@
+package terrors
+
+import (
+	"sync"
+	"time"
+)
+
+// HookSampling limits how often the observability hooks (the EventHook and the MatchHook) are called for errors with
+// each code, so that exporters and metrics hooks aren't overwhelmed during error storms. Each code has a token bucket
+// which holds up to Burst calls, and refills at PerSecond calls a second. Calls which find the bucket empty are
+// dropped, and counted in HookSamplingDrops.
+type HookSampling struct {
+	PerSecond float64 `json:"per_second"`
+	Burst     int     `json:"burst"`
+}
+
+// hookSampler holds the token buckets and drop counts of a HookSampling.
+type hookSampler struct {
+	config HookSampling
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	drops   map[string]int
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var currentSampler *hookSampler
+
+// SetHookSampling installs sampling of the observability hooks. Passing a zero HookSampling (or one with a Burst of
+// less than one) removes the sampling, which is the default. Installing sampling resets the drop counts.
+func SetHookSampling(sampling HookSampling) {
+	var sampler *hookSampler
+	if sampling.Burst >= 1 {
+		sampler = newHookSampler(sampling, time.Now)
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	currentSampler = sampler
+}
+
+// CurrentHookSampling returns the sampling of the observability hooks, which is zero if there is none.
+func CurrentHookSampling() HookSampling {
+	if sampler := currentHookSampler(); sampler != nil {
+		return sampler.config
+	}
+	return HookSampling{}
+}
+
+// HookSamplingDrops returns the number of hook calls which have been dropped for each code since sampling was
+// installed.
+func HookSamplingDrops() map[string]int {
+	drops := map[string]int{}
+	sampler := currentHookSampler()
+	if sampler == nil {
+		return drops
+	}
+	sampler.mu.Lock()
+	defer sampler.mu.Unlock()
+	for code, n := range sampler.drops {
+		drops[code] = n
+	}
+	return drops
+}
+
+func currentHookSampler() *hookSampler {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return currentSampler
+}
+
+func newHookSampler(config HookSampling, now func() time.Time) *hookSampler {
+	return &hookSampler{
+		config:  config,
+		now:     now,
+		buckets: map[string]*tokenBucket{},
+		drops:   map[string]int{},
+	}
+}
+
+// allow takes a token from the bucket for code, and reports whether there was one. A call which isn't allowed is
+// counted as a drop.
+func (s *hookSampler) allow(code string) bool {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	burst := float64(s.config.Burst)
+	b, ok := s.buckets[code]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		s.buckets[code] = b
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * s.config.PerSecond
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		s.drops[code]++
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// sampleHook reports whether a hook should be called for an error with code, according to the installed sampling.
+func sampleHook(code string) bool {
+	sampler := currentHookSampler()
+	if sampler == nil {
+		return true
+	}
+	return sampler.allow(code)
+}
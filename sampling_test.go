@@ -0,0 +1,67 @@
+package terrors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookSampler(t *testing.T) {
+	now := time.Now()
+	s := newHookSampler(HookSampling{PerSecond: 2, Burst: 3}, func() time.Time { return now })
+
+	// The burst is allowed, then calls are dropped
+	for i := 0; i < 3; i++ {
+		assert.True(t, s.allow("timeout"))
+	}
+	assert.False(t, s.allow("timeout"))
+	assert.False(t, s.allow("timeout"))
+
+	// Each code has its own bucket
+	assert.True(t, s.allow("not_found"))
+
+	// The bucket refills over time, but not beyond the burst
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, s.allow("timeout"))
+	assert.False(t, s.allow("timeout"))
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, s.allow("timeout"))
+	}
+	assert.False(t, s.allow("timeout"))
+
+	assert.Equal(t, map[string]int{"timeout": 4}, s.drops)
+}
+
+func TestHookSampling(t *testing.T) {
+	events := withEventHook(t)
+	SetHookSampling(HookSampling{PerSecond: 0.001, Burst: 2})
+	defer SetHookSampling(HookSampling{})
+	assert.Equal(t, HookSampling{PerSecond: 0.001, Burst: 2}, CurrentHookSampling())
+
+	for i := 0; i < 5; i++ {
+		emitEvent(EventWrapCompat, "foo", -1)
+	}
+	emitEvent(EventWrapCompat, "bar", -1)
+	assert.Len(t, *events, 3)
+	assert.Equal(t, map[string]int{"foo": 3}, HookSamplingDrops())
+	assert.Equal(t, map[string]int{"foo": 3}, CurrentDebugState().HookSamplingDrops)
+
+	// Match hooks are sampled by the code of the error, and still match when dropped
+	var decisions []MatchDecision
+	SetMatchHook(func(d MatchDecision) { decisions = append(decisions, d) })
+	defer SetMatchHook(nil)
+	err := NotFound("foo", "bar", nil)
+	for i := 0; i < 3; i++ {
+		assert.True(t, Is(err, ErrNotFound))
+	}
+	assert.Len(t, decisions, 2)
+	assert.Equal(t, 1, HookSamplingDrops()[err.Code])
+
+	// Removing the sampling resets the drops
+	SetHookSampling(HookSampling{})
+	assert.Empty(t, HookSamplingDrops())
+	emitEvent(EventWrapCompat, "foo", -1)
+	assert.Len(t, *events, 4)
+}
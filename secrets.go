@@ -0,0 +1,155 @@
+package terrors
+
+import (
+	"regexp"
+)
+
+// SecretDetector finds values which look like secrets (tokens, card numbers, personal data) in error messages and
+// params.
+type SecretDetector struct {
+	// Name identifies the detector in masked output and violation reports, e.g. "pan".
+	Name string
+	// Pattern matches candidate secrets.
+	Pattern *regexp.Regexp
+	// Validate optionally filters the matches of Pattern, for example with a checksum. If nil, all matches are
+	// treated as secrets.
+	Validate func(match string) bool
+}
+
+// SecretViolation describes a secret found by a SecretScanner.
+type SecretViolation struct {
+	// Detector is the name of the detector which found the secret.
+	Detector string
+	// Code is the code of the error the secret was found in.
+	Code string
+	// Field is where the secret was found: "message", "message_chain", "params.<key>", "context_chain" or
+	// "context_chain.params.<key>".
+	Field string
+}
+
+// SecretScanner masks secrets in errors before they leave the process (Marshal) or are logged (LogMetadata).
+// The error itself is never modified; masking is applied to the copies which are marshalled or logged.
+type SecretScanner struct {
+	Detectors []SecretDetector
+	// OnViolation, if set, is called for each secret found. It can be used to report violations so that the code
+	// putting secrets into errors can be fixed.
+	OnViolation func(SecretViolation)
+}
+
+var secretScanner *SecretScanner
+
+// SetSecretScanner installs a scanner which is run over errors before they are marshalled or logged. Passing nil
+// disables scanning, which is the default.
+func SetSecretScanner(scanner *SecretScanner) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	secretScanner = scanner
+}
+
+func currentSecretScanner() *SecretScanner {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return secretScanner
+}
+
+var (
+	panPattern    = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+)
+
+// DefaultSecretDetectors returns detectors for card numbers (PANs, validated with the Luhn checksum), email addresses
+// and bearer tokens.
+func DefaultSecretDetectors() []SecretDetector {
+	return []SecretDetector{
+		{Name: "pan", Pattern: panPattern, Validate: luhnValid},
+		{Name: "email", Pattern: emailPattern},
+		{Name: "bearer_token", Pattern: bearerPattern},
+	}
+}
+
+// Mask replaces every secret found in s with a `[REDACTED:<detector>]` marker. It returns the masked string and the
+// names of the detectors which matched, in detector order.
+func (s *SecretScanner) Mask(value string) (string, []string) {
+	var matched []string
+	for _, d := range s.Detectors {
+		found := false
+		value = d.Pattern.ReplaceAllStringFunc(value, func(match string) string {
+			if d.Validate != nil && !d.Validate(match) {
+				return match
+			}
+			found = true
+			return "[REDACTED:" + d.Name + "]"
+		})
+		if found {
+			matched = append(matched, d.Name)
+		}
+	}
+	return value, matched
+}
+
+func (s *SecretScanner) maskField(code, field, value string) string {
+	if s == nil {
+		return value
+	}
+	masked, matched := s.Mask(value)
+	if s.OnViolation != nil {
+		for _, name := range matched {
+			s.OnViolation(SecretViolation{Detector: name, Code: code, Field: field})
+		}
+	}
+	return masked
+}
+
+// maskParams returns a masked copy of params, or params itself if no scanner is installed.
+func (s *SecretScanner) maskParams(code string, params map[string]string) map[string]string {
+	return s.maskParamsField(code, "params.", params)
+}
+
+func (s *SecretScanner) maskParamsField(code, fieldPrefix string, params map[string]string) map[string]string {
+	if s == nil || params == nil {
+		return params
+	}
+	masked := make(map[string]string, len(params))
+	for k, v := range params {
+		masked[k] = s.maskField(code, fieldPrefix+k, v)
+	}
+	return masked
+}
+
+// maskMessages returns a masked copy of a message and message chain.
+func (s *SecretScanner) maskMessages(code, message string, chain []string) (string, []string) {
+	if s == nil {
+		return message, chain
+	}
+	message = s.maskField(code, "message", message)
+	if chain != nil {
+		maskedChain := make([]string, len(chain))
+		for i, m := range chain {
+			maskedChain[i] = s.maskField(code, "message_chain", m)
+		}
+		chain = maskedChain
+	}
+	return message, chain
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum used by card numbers.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,67 @@
+package terrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withSecretScanner(t *testing.T, scanner *SecretScanner) {
+	SetSecretScanner(scanner)
+	t.Cleanup(func() { SetSecretScanner(nil) })
+}
+
+func TestSecretScannerMask(t *testing.T) {
+	scanner := &SecretScanner{Detectors: DefaultSecretDetectors()}
+	cases := []struct {
+		in, out string
+		matched []string
+	}{
+		{"card 4111 1111 1111 1111 declined", "card [REDACTED:pan] declined", []string{"pan"}},
+		{"order 4111111111111112 failed", "order 4111111111111112 failed", nil}, // fails the Luhn check
+		{"user jane.doe@example.com not found", "user [REDACTED:email] not found", []string{"email"}},
+		{"Authorization: Bearer abc.def-123", "Authorization: [REDACTED:bearer_token]", []string{"bearer_token"}},
+		{"nothing to see here", "nothing to see here", nil},
+	}
+	for _, tc := range cases {
+		masked, matched := scanner.Mask(tc.in)
+		assert.Equal(t, tc.out, masked)
+		assert.Equal(t, tc.matched, matched)
+	}
+}
+
+func TestSecretScannerAppliedToMarshalAndLogs(t *testing.T) {
+	var violations []SecretViolation
+	withSecretScanner(t, &SecretScanner{
+		Detectors: DefaultSecretDetectors(),
+		OnViolation: func(v SecretViolation) {
+			violations = append(violations, v)
+		},
+	})
+
+	base := BadRequest("invalid_email", "bad email jane@example.com", nil)
+	err := Augment(base, "validating signup", map[string]string{"email": "jane@example.com", "plan": "gold"}).(*Error)
+
+	protoErr := Marshal(err)
+	assert.Equal(t, "validating signup", protoErr.Message)
+	assert.Equal(t, []string{"bad email [REDACTED:email]"}, protoErr.MessageChain)
+	assert.Equal(t, map[string]string{"email": "[REDACTED:email]", "plan": "gold"}, protoErr.Params)
+	assert.Equal(t, []SecretViolation{
+		{Detector: "email", Code: "bad_request.invalid_email", Field: "message_chain"},
+		{Detector: "email", Code: "bad_request.invalid_email", Field: "context_chain"},
+		{Detector: "email", Code: "bad_request.invalid_email", Field: "params.email"},
+	}, violations)
+	assert.Equal(t, "bad email [REDACTED:email]", protoErr.ContextChain[0].Message)
+
+	assert.Equal(t, "[REDACTED:email]", err.LogMetadata()["email"])
+
+	// The error itself is untouched
+	assert.Equal(t, "jane@example.com", err.Params["email"])
+	assert.Equal(t, "bad email jane@example.com", err.MessageChain[0])
+}
+
+func TestNoSecretScannerByDefault(t *testing.T) {
+	err := New("foo", "jane@example.com", map[string]string{"email": "jane@example.com"})
+	assert.Equal(t, "jane@example.com", Marshal(err).Message)
+	assert.Equal(t, "jane@example.com", err.LogMetadata()["email"])
+}
@@ -0,0 +1,55 @@
+package terrors
+
+// SpanStatus is how an error is reflected in the status of a tracing span, e.g. by an OpenTelemetry integration.
+type SpanStatus int
+
+const (
+	// SpanStatusError marks the span as failed. This is the default for every code.
+	SpanStatusError SpanStatus = iota
+	// SpanStatusOK leaves the status of the span alone, and records the error as an event on it instead. This suits
+	// errors which are an expected outcome of the operation, such as not_found from a lookup, which would otherwise
+	// pollute trace-based alerting.
+	SpanStatusOK
+)
+
+// String returns "error" or "ok".
+func (s SpanStatus) String() string {
+	if s == SpanStatusOK {
+		return "ok"
+	}
+	return "error"
+}
+
+// spanStatuses maps codes to the span status of errors with those codes, or codes below them. It is guarded by
+// configMu.
+var spanStatuses = map[string]SpanStatus{}
+
+// SetSpanStatus sets the span status of errors whose code is code, or starts with code followed by a dot. Where
+// several codes match, the longest wins, so that exceptions can be made below a code:
+//
+//	terrors.SetSpanStatus(terrors.ErrNotFound, terrors.SpanStatusOK)
+//	terrors.SetSpanStatus(terrors.ErrNotFound+".config", terrors.SpanStatusError)
+func SetSpanStatus(code string, status SpanStatus) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	spanStatuses[code] = status
+}
+
+// SpanStatusOf returns the span status for err, according to SetSpanStatus. Errors which aren't terrors have the
+// status of the code Propagate would give them. A nil error is SpanStatusOK.
+func SpanStatusOf(err error) SpanStatus {
+	if err == nil {
+		return SpanStatusOK
+	}
+	code := codeOf(err)
+
+	configMu.RLock()
+	defer configMu.RUnlock()
+	status, matched := SpanStatusError, ""
+	for prefix, s := range spanStatuses {
+		if hasCodePrefix(code, prefix) && len(prefix) >= len(matched) {
+			status, matched = s, prefix
+		}
+	}
+	return status
+}
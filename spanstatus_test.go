@@ -0,0 +1,46 @@
+package terrors
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withSpanStatuses(t *testing.T) {
+	configMu.Lock()
+	previous := spanStatuses
+	spanStatuses = map[string]SpanStatus{}
+	configMu.Unlock()
+	t.Cleanup(func() {
+		configMu.Lock()
+		spanStatuses = previous
+		configMu.Unlock()
+	})
+}
+
+func TestSpanStatusOf(t *testing.T) {
+	withSpanStatuses(t)
+	assert.Equal(t, SpanStatusError, SpanStatusOf(NotFound("account", "account not found", nil)))
+	assert.Equal(t, SpanStatusOK, SpanStatusOf(nil))
+
+	SetSpanStatus(ErrNotFound, SpanStatusOK)
+	SetSpanStatus(ErrNotFound+".config", SpanStatusError)
+
+	assert.Equal(t, SpanStatusOK, SpanStatusOf(NotFound("", "not found", nil)))
+	assert.Equal(t, SpanStatusOK, SpanStatusOf(NotFound("account", "account not found", nil)))
+	assert.Equal(t, SpanStatusOK, SpanStatusOf(Augment(NotFound("account", "account not found", nil), "ctx", nil)))
+	assert.Equal(t, SpanStatusError, SpanStatusOf(NotFound("config", "config not found", nil)))
+	assert.Equal(t, SpanStatusError, SpanStatusOf(NotFound("config.flags", "flags not found", nil)))
+	assert.Equal(t, SpanStatusError, SpanStatusOf(InternalService("", "failed", nil)))
+	// Codes are matched by dotted part
+	assert.Equal(t, SpanStatusError, SpanStatusOf(New("not_found_here", "", nil)))
+	// Errors which aren't terrors use the code Propagate gives them
+	assert.Equal(t, SpanStatusError, SpanStatusOf(fmt.Errorf("plain")))
+	SetSpanStatus(ErrInternalService, SpanStatusOK)
+	assert.Equal(t, SpanStatusOK, SpanStatusOf(sql.ErrConnDone))
+
+	assert.Equal(t, "ok", SpanStatusOK.String())
+	assert.Equal(t, "error", SpanStatusError.String())
+}
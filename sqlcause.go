@@ -0,0 +1,19 @@
+package terrors
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrSQLNoRows is the code given to errors created from sql.ErrNoRows, which almost always means that the record
+// being looked up doesn't exist, rather than that something went wrong.
+const ErrSQLNoRows = ErrNotFound + ".no_rows"
+
+// translateSQLCause converts sql.ErrNoRows anywhere in the chain of err into a not_found.no_rows error. Like
+// translateJSONCause, it is consulted after the registered translators, so that they can override it.
+func translateSQLCause(err error) (*Error, bool) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return untrackedError(ErrSQLNoRows, err.Error(), nil), true
+	}
+	return nil, false
+}
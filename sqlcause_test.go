@@ -0,0 +1,40 @@
+package terrors
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLNoRowsCause(t *testing.T) {
+	err := Augment(sql.ErrNoRows, "loading account", map[string]string{"account_id": "acc_123"})
+	assert.True(t, Is(err, ErrNotFound))
+	assert.True(t, Is(err, ErrSQLNoRows))
+	assert.False(t, IsRetryable(err))
+	assert.Equal(t, "acc_123", err.(*Error).Params["account_id"])
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	// Found through wrapping
+	err = Propagate(fmt.Errorf("querying accounts: %w", sql.ErrNoRows))
+	assert.True(t, Is(err, ErrSQLNoRows))
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	err = Wrap(sql.ErrNoRows, nil)
+	assert.True(t, Is(err, ErrSQLNoRows))
+
+	// Other database errors are left alone
+	assert.True(t, Is(Propagate(sql.ErrConnDone), ErrInternalService))
+}
+
+func TestSQLNoRowsCauseOverridden(t *testing.T) {
+	withCauseTranslator(t, func(err error) (*Error, bool) {
+		if errors.Is(err, sql.ErrNoRows) {
+			return NotFound("account", "account not found", nil), true
+		}
+		return nil, false
+	})
+	assert.True(t, Is(Propagate(sql.ErrNoRows), "not_found.account"))
+}
@@ -0,0 +1,55 @@
+package stack
+
+import "sync"
+
+// maxDepth is the maximum number of frames captured by BuildStack.
+const maxDepth = 100
+
+// sigpanicMethod is the Method of the frame the runtime inserts where a panic was raised by a signal (e.g. a nil
+// pointer dereference).
+const sigpanicMethod = "runtime.sigpanic"
+
+// pcBuffers holds the buffers BuildStack captures program counters into, so that capturing a stack doesn't allocate
+// one every time.
+var pcBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]uintptr, maxDepth)
+		return &buf
+	},
+}
+
+// frameCache interns the frames resolved from each program counter. Services can create tens of thousands of errors
+// per second during incidents, nearly all from a handful of call sites, so reusing the resolved frames (and sharing
+// their strings) between stacks saves most of the work of a capture. Frames are cached by value and copied into each
+// stack, as callers may modify them. The cache is bounded by the size of the program's code.
+type frameCache struct {
+	hashing bool
+	// pcs maps a program counter to the frames it resolves to: more than one if calls were inlined at it.
+	pcs sync.Map
+}
+
+var (
+	plainFrames  = &frameCache{hashing: false}
+	hashedFrames = &frameCache{hashing: true}
+)
+
+func cacheFor(hashing bool) *frameCache {
+	if hashing {
+		return hashedFrames
+	}
+	return plainFrames
+}
+
+// frames returns the frames pc resolves to, treating it as a return address. They must not be modified.
+func (c *frameCache) frames(pc uintptr) []Frame {
+	if cached, ok := c.pcs.Load(pc); ok {
+		return cached.([]Frame)
+	}
+	resolved := resolvePCs([]uintptr{pc}, c.hashing)
+	frames := make([]Frame, len(resolved))
+	for i, f := range resolved {
+		frames[i] = *f
+	}
+	c.pcs.Store(pc, frames)
+	return frames
+}
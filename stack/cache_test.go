@@ -0,0 +1,90 @@
+package stack
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestBuildStackDoesNotShareFrames(t *testing.T) {
+	var stacks []Stack
+	for i := 0; i < 2; i++ {
+		stacks = append(stacks, BuildStack(1))
+		if i == 0 && len(stacks[0]) > 0 {
+			// Modifying the frames of one stack mustn't affect the cache
+			stacks[0][0].Line = -1
+		}
+	}
+	a, b := stacks[0], stacks[1]
+	if len(a) == 0 || len(a) != len(b) {
+		t.Fatalf("got stacks of length %d and %d", len(a), len(b))
+	}
+	if b[0].Line == -1 {
+		t.Errorf("modifying a frame changed the cache")
+	}
+	for i := range a {
+		if a[i] == b[i] {
+			t.Errorf("frame %d was shared: %v", i, a[i])
+		}
+	}
+}
+
+func TestBuildStackFromPCsMatchesUncached(t *testing.T) {
+	pcs := make([]uintptr, maxDepth)
+	n := runtime.Callers(1, pcs)
+	if got, want := BuildStackFromPCs(pcs[:n]), resolvePCs(pcs[:n], false); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestBuildStackFromPCsAfterPanic(t *testing.T) {
+	var pcs []uintptr
+	func() {
+		defer func() {
+			recover()
+			pcs = make([]uintptr, maxDepth)
+			pcs = pcs[:runtime.Callers(1, pcs)]
+		}()
+		var m map[string]int
+		m["boom"]++ // panics via the runtime, rather than a signal
+	}()
+	if got, want := BuildStackFromPCs(pcs), resolvePCs(pcs, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	func() {
+		defer func() {
+			recover()
+			pcs = make([]uintptr, maxDepth)
+			pcs = pcs[:runtime.Callers(1, pcs)]
+		}()
+		var p *Frame
+		_ = p.Line // panics via a signal
+	}()
+	got, want := BuildStackFromPCs(pcs), resolvePCs(pcs, false)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	found := false
+	for _, f := range got {
+		found = found || f.Method == sigpanicMethod
+	}
+	if !found {
+		t.Errorf("expected a %s frame in %v", sigpanicMethod, got)
+	}
+}
+
+func BenchmarkBuildStack(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		BuildStack(1)
+	}
+}
+
+func BenchmarkBuildStackUncached(b *testing.B) {
+	b.ReportAllocs()
+	pcs := make([]uintptr, maxDepth)
+	for i := 0; i < b.N; i++ {
+		resolvePCs(pcs[:runtime.Callers(1, pcs)], false)
+	}
+}
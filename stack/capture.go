@@ -0,0 +1,39 @@
+package stack
+
+import "sync/atomic"
+
+// Capturer captures the stack of the goroutine which calls BuildStack. skip is the value passed to BuildStack: the
+// number of frames above BuildStack's caller to skip.
+type Capturer func(skip int) Stack
+
+// capturerValue wraps the installed Capturer, as atomic.Value can't hold nil.
+type capturerValue struct {
+	capture Capturer
+}
+
+var capturer atomic.Value
+
+// SetCapturer installs a Capturer which BuildStack uses instead of capturing the real stack. It is intended for
+// tests: a fake capturer producing stable frames (see FixedCapturer) keeps golden tests of rendered or marshalled
+// errors from breaking whenever line numbers shift. Passing nil restores real capture. Stacks built from program
+// counters with BuildStackFromPCs are not affected.
+func SetCapturer(c Capturer) {
+	capturer.Store(capturerValue{capture: c})
+}
+
+func currentCapturer() Capturer {
+	v, _ := capturer.Load().(capturerValue)
+	return v.capture
+}
+
+// FixedCapturer returns a Capturer which always returns copies of the given frames, regardless of where it's called.
+func FixedCapturer(frames ...Frame) Capturer {
+	return func(int) Stack {
+		s := make(Stack, len(frames))
+		for i := range frames {
+			f := frames[i]
+			s[i] = &f
+		}
+		return s
+	}
+}
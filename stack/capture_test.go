@@ -0,0 +1,37 @@
+package stack
+
+import (
+	"testing"
+)
+
+func TestSetCapturer(t *testing.T) {
+	defer SetCapturer(nil)
+
+	var gotSkip int
+	SetCapturer(func(skip int) Stack {
+		gotSkip = skip
+		return FixedCapturer(Frame{Filename: "fake.go", Method: "fake.Func", Line: 1})(skip)
+	})
+	s := BuildStack(3)
+	if gotSkip != 3 {
+		t.Errorf("got skip: %d", gotSkip)
+	}
+	if len(s) != 1 || s[0].Filename != "fake.go" || s[0].Method != "fake.Func" || s[0].Line != 1 {
+		t.Errorf("got: %v", s)
+	}
+
+	SetCapturer(nil)
+	s = BuildStack(1)
+	if len(s) == 0 || s[0].Method != "stack.TestSetCapturer" {
+		t.Errorf("got: %v", s)
+	}
+}
+
+func TestFixedCapturerCopies(t *testing.T) {
+	capture := FixedCapturer(Frame{Filename: "fake.go", Line: 1})
+	a, b := capture(1), capture(1)
+	a[0].Line = 2
+	if b[0].Line != 1 {
+		t.Errorf("frames were shared between captures")
+	}
+}
@@ -0,0 +1,57 @@
+package stack
+
+import (
+	"fmt"
+	"hash/crc32"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	sourceHashing int32
+	// sourceLines caches the lines of each source file read, keyed by path. Files which can't be read are cached as
+	// nil, so that we don't retry them on every capture.
+	sourceLines sync.Map
+)
+
+// SetSourceHashing enables or disables recording a hash of the source line of each frame when a stack is captured.
+// When stacks from different deploys are compared, a changed hash shows that the code at a frame changed, even if
+// the line number didn't (or vice versa).
+//
+// Hashing requires the source files to be readable at the paths they were compiled from, so it is intended for
+// development and staging environments. Each file is read at most once.
+func SetSourceHashing(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&sourceHashing, v)
+}
+
+func sourceHashingEnabled() bool {
+	return atomic.LoadInt32(&sourceHashing) == 1
+}
+
+// sourceHash returns a short hash of the given line of the file at path, ignoring surrounding whitespace. It returns
+// an empty string if the line can't be read.
+func sourceHash(path string, line int) string {
+	lines := readSourceLines(path)
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(strings.TrimSpace(lines[line-1]))))
+}
+
+func readSourceLines(path string) []string {
+	if cached, ok := sourceLines.Load(path); ok {
+		return cached.([]string)
+	}
+	var lines []string
+	if contents, err := os.ReadFile(path); err == nil {
+		lines = strings.Split(string(contents), "\n")
+	}
+	sourceLines.Store(path, lines)
+	return lines
+}
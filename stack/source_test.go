@@ -0,0 +1,41 @@
+package stack
+
+import (
+	"fmt"
+	"hash/crc32"
+	"testing"
+)
+
+func TestSourceHashing(t *testing.T) {
+	frame := BuildStack(1)[0]
+	if frame.SourceHash != "" {
+		t.Errorf("expected no hash when disabled, got: %s", frame.SourceHash)
+	}
+
+	SetSourceHashing(true)
+	defer SetSourceHashing(false)
+
+	first := BuildStack(1)[0]
+	second := BuildStack(1)[0]
+	if len(first.SourceHash) != 8 {
+		t.Errorf("expected an 8 character hash, got: %q", first.SourceHash)
+	}
+	// The lines differ, so their hashes should too
+	if first.SourceHash == second.SourceHash {
+		t.Errorf("expected different hashes for different lines, got: %s", first.SourceHash)
+	}
+	// Surrounding whitespace is ignored
+	expected := fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte("first := BuildStack(1)[0]")))
+	if first.SourceHash != expected {
+		t.Errorf("expected: %s, got: %s", expected, first.SourceHash)
+	}
+}
+
+func TestSourceHashUnreadable(t *testing.T) {
+	if h := sourceHash("does/not/exist.go", 1); h != "" {
+		t.Errorf("got: %s", h)
+	}
+	if h := sourceHash("source_test.go", 100000); h != "" {
+		t.Errorf("got: %s", h)
+	}
+}
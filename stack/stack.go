@@ -23,36 +23,82 @@ type Frame struct {
 	Method   string  `json:"method"`
 	Line     int     `json:"lineno"`
 	PC       uintptr `json:"pc"`
+	// SourceHash is a short hash of the source line of the frame, if source hashing is enabled. See
+	// SetSourceHashing.
+	SourceHash string `json:"source_hash,omitempty"`
 }
 
 type Stack []*Frame
 
 func BuildStack(skip int) Stack {
-	stack := make(Stack, 0)
+	if capture := currentCapturer(); capture != nil {
+		return capture(skip)
+	}
 
 	// Look up to a maximum depth of 100
-	ret := make([]uintptr, 100)
+	buf := pcBuffers.Get().(*[]uintptr)
+	defer pcBuffers.Put(buf)
 
 	// Note that indexes must be one higher when passed to Callers()
 	// than they would be when passed to Caller()
 	// see https://golang.org/pkg/runtime/#Caller
-	index := runtime.Callers(skip+1, ret)
+	index := runtime.Callers(skip+1, *buf)
 	if index == 0 {
 		// We have no frames to report, skip must be too high
-		return stack
+		return make(Stack, 0)
+	}
+	return BuildStackFromPCs((*buf)[:index])
+}
+
+// BuildStackFromPCs builds a stack from program counters which have already been captured, for example with
+// runtime.Callers in a panic handler. The PCs should be return addresses, as returned by runtime.Callers.
+func BuildStackFromPCs(pcs []uintptr) Stack {
+	if len(pcs) == 0 {
+		return make(Stack, 0)
 	}
 
+	cache := cacheFor(sourceHashingEnabled())
+	frames := make([]Frame, 0, len(pcs))
+	for i, pc := range pcs {
+		if i > 0 && len(frames) > 0 && frames[len(frames)-1].Method == sigpanicMethod {
+			// The PC following a panic isn't a return address, which CallersFrames can only tell from the previous
+			// PC, so it can't be resolved on its own
+			prev := cache.frames(pcs[i-1])
+			for _, f := range resolvePCs(pcs[i-1:i+1], cache.hashing)[len(prev):] {
+				frames = append(frames, *f)
+			}
+			continue
+		}
+		frames = append(frames, cache.frames(pc)...)
+	}
+
+	// The frames are copied out of the cache, so that modifying the frames of one stack doesn't affect others
+	stack := make(Stack, len(frames))
+	for i := range frames {
+		stack[i] = &frames[i]
+	}
+	return stack
+}
+
+// resolvePCs builds the frames of pcs, without using the cache.
+func resolvePCs(pcs []uintptr, hashing bool) Stack {
+	stack := make(Stack, 0, len(pcs))
+
 	// This function takes a list of counters and gets function/file/line information
-	cf := runtime.CallersFrames(ret[:index])
+	cf := runtime.CallersFrames(pcs)
 
 	for {
 		frame, ok := cf.Next()
-		stack = append(stack, &Frame{
+		f := &Frame{
 			Filename: shortenFilePath(frame.File),
 			Method:   functionName(frame.PC),
 			Line:     frame.Line,
 			PC:       frame.PC,
-		})
+		}
+		if hashing {
+			f.SourceHash = sourceHash(frame.File, frame.Line)
+		}
+		stack = append(stack, f)
 		if !ok {
 			// This was the last valid caller
 			break
@@ -78,8 +124,9 @@ func (s Stack) Fingerprint() string {
 // machine the code was compiled on.
 //
 // Examples:
-//   /usr/local/go/src/pkg/runtime/proc.c -> pkg/runtime/proc.c
-//   /home/foo/go/src/github.com/rollbar/rollbar.go -> github.com/rollbar/rollbar.go
+//
+//	/usr/local/go/src/pkg/runtime/proc.c -> pkg/runtime/proc.c
+//	/home/foo/go/src/github.com/rollbar/rollbar.go -> github.com/rollbar/rollbar.go
 func shortenFilePath(s string) string {
 	idx := strings.Index(s, "/src/pkg/")
 	if idx != -1 {
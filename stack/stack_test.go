@@ -1,7 +1,10 @@
 // stolen from https://github.com/stvp/rollbar/blob/master/stack_test.go
 package stack
 
-import "testing"
+import (
+	"runtime"
+	"testing"
+)
 
 func TestBuildStack(t *testing.T) {
 	frame := BuildStack(1)[0]
@@ -11,7 +14,7 @@ func TestBuildStack(t *testing.T) {
 	if frame.Method != "stack.TestBuildStack" {
 		t.Errorf("got: %s", frame.Method)
 	}
-	if frame.Line != 7 {
+	if frame.Line != 10 {
 		t.Errorf("got: %d", frame.Line)
 	}
 }
@@ -24,20 +27,20 @@ func TestStackFingerprint(t *testing.T) {
 		{
 			"9344290d",
 			Stack{
-				&Frame{"foo.go", "Oops", 1, 0},
+				&Frame{"foo.go", "Oops", 1, 0, ""},
 			},
 		},
 		{
 			"a4d78b7",
 			Stack{
-				&Frame{"foo.go", "Oops", 2, 0},
+				&Frame{"foo.go", "Oops", 2, 0, ""},
 			},
 		},
 		{
 			"50e0fcb3",
 			Stack{
-				&Frame{"foo.go", "Oops", 1, 0},
-				&Frame{"foo.go", "Oops", 2, 0},
+				&Frame{"foo.go", "Oops", 1, 0, ""},
+				&Frame{"foo.go", "Oops", 2, 0, ""},
 			},
 		},
 	}
@@ -67,3 +70,18 @@ func TestShortenFilePath(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildStackFromPCs(t *testing.T) {
+	pcs := make([]uintptr, 10)
+	n := runtime.Callers(1, pcs)
+	s := BuildStackFromPCs(pcs[:n])
+	if len(s) == 0 {
+		t.Fatal("got empty stack")
+	}
+	if s[0].Method != "stack.TestBuildStackFromPCs" {
+		t.Errorf("got: %s", s[0].Method)
+	}
+	if len(BuildStackFromPCs(nil)) != 0 {
+		t.Errorf("expected empty stack")
+	}
+}
@@ -0,0 +1,72 @@
+package terrors
+
+import (
+	"time"
+)
+
+// OriginServiceParam is the param under which the name of the service an error originated from is stored.
+const OriginServiceParam = "origin_service"
+
+// ErrorSummary is a compact projection of an error, intended for dashboards and alert payloads which need a
+// consistent view of an error without its full chain, params and stacks.
+type ErrorSummary struct {
+	// Code is the code of the outermost error.
+	Code string `json:"code"`
+	// RootMessage is the message at the very bottom of the chain, including links from other services.
+	RootMessage string `json:"root_message"`
+	Retryable   bool   `json:"retryable"`
+	Unexpected  bool   `json:"unexpected"`
+	// OriginService is the value of the OriginServiceParam param, if set.
+	OriginService string `json:"origin_service,omitempty"`
+	// Age is how long ago the oldest error in the local chain was created. It is zero if this isn't known, for example
+	// for errors which were unmarshalled.
+	Age time.Duration `json:"age"`
+	// Fingerprint identifies the stack of the innermost error in the local chain which has one.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Summary returns the ErrorSummary of err. Errors which are not terrors are summarised as they would be if they were
+// passed to Propagate. A nil error returns an empty summary.
+func Summary(err error) ErrorSummary {
+	if err == nil {
+		return ErrorSummary{}
+	}
+	terr, ok := err.(*Error)
+	if !ok {
+		terr = untrackedError(causeCode(err), err.Error(), nil)
+		inheritFlags(terr, err)
+	}
+
+	summary := ErrorSummary{
+		Code:          terr.Code,
+		RootMessage:   terr.Message,
+		Retryable:     terr.Retryable(),
+		Unexpected:    terr.Unexpected(),
+		OriginService: terr.Params[OriginServiceParam],
+	}
+	if len(terr.MessageChain) > 0 {
+		summary.RootMessage = terr.MessageChain[len(terr.MessageChain)-1]
+	}
+
+	var createdAt time.Time
+	var next error = terr
+	maxDepth := CurrentMaxCausalDepth()
+	for depth := 0; next != nil && depth < maxDepth; depth++ {
+		link, ok := next.(*Error)
+		if !ok {
+			break
+		}
+		if !link.createdAt.IsZero() && (createdAt.IsZero() || link.createdAt.Before(createdAt)) {
+			createdAt = link.createdAt
+		}
+		if len(link.StackFrames) > 0 {
+			summary.Fingerprint = link.StackFrames.Fingerprint()
+		}
+		next = link.cause
+	}
+	if !createdAt.IsZero() {
+		summary.Age = time.Since(createdAt)
+	}
+
+	return summary
+}
@@ -0,0 +1,53 @@
+package terrors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+func TestSummary(t *testing.T) {
+	t.Run("augmented terror", func(t *testing.T) {
+		base := NotFound("foo", "failed to find foo", map[string]string{OriginServiceParam: "service.foo"})
+		base.createdAt = time.Now().Add(-time.Minute)
+		err := Augment(base, "looking up foo", nil)
+
+		summary := Summary(err)
+		assert.Equal(t, "not_found.foo", summary.Code)
+		assert.Equal(t, "failed to find foo", summary.RootMessage)
+		assert.False(t, summary.Retryable)
+		assert.False(t, summary.Unexpected)
+		assert.Equal(t, "service.foo", summary.OriginService)
+		assert.GreaterOrEqual(t, int64(summary.Age), int64(time.Minute))
+		assert.Equal(t, base.StackFrames.Fingerprint(), summary.Fingerprint)
+	})
+	t.Run("unmarshalled terror", func(t *testing.T) {
+		err := Unmarshal(&pe.Error{
+			Code:         ErrTimeout,
+			Message:      "calling downstream",
+			MessageChain: []string{"calling database", "i/o timeout"},
+			Retryable:    &pe.BoolValue{Value: true},
+			Unexpected:   &pe.BoolValue{Value: true},
+		})
+
+		summary := Summary(err)
+		assert.Equal(t, ErrTimeout, summary.Code)
+		assert.Equal(t, "i/o timeout", summary.RootMessage)
+		assert.True(t, summary.Retryable)
+		assert.True(t, summary.Unexpected)
+		assert.Zero(t, summary.Age)
+		assert.Empty(t, summary.Fingerprint)
+	})
+	t.Run("non-terror", func(t *testing.T) {
+		summary := Summary(assert.AnError)
+		assert.Equal(t, ErrInternalService, summary.Code)
+		assert.Equal(t, assert.AnError.Error(), summary.RootMessage)
+		assert.True(t, summary.Retryable)
+	})
+	t.Run("nil", func(t *testing.T) {
+		assert.Equal(t, ErrorSummary{}, Summary(nil))
+	})
+}
@@ -0,0 +1,69 @@
+package terrors
+
+// CodeSwitch routes an error to a handler based on its code, replacing chains of `if terrors.Is(...)` statements:
+//
+//	return terrors.Switch(err).
+//		Case(terrors.ErrNotFound, func(terr *terrors.Error) error { return nil }).
+//		Case(terrors.ErrConflict, retryWithFreshState).
+//		Default(func(terr *terrors.Error) error { return terr }).
+//		Run()
+//
+// Run is only available once Default has been called, so every switch must say explicitly what happens to errors
+// which don't match any case.
+type CodeSwitch struct {
+	err   error
+	cases []switchCase
+}
+
+type switchCase struct {
+	prefix  string
+	handler func(*Error) error
+}
+
+// CompleteCodeSwitch is a CodeSwitch with a default handler, which can be run.
+type CompleteCodeSwitch struct {
+	CodeSwitch
+	fallback func(*Error) error
+}
+
+// Switch starts a CodeSwitch over err.
+func Switch(err error) *CodeSwitch {
+	return &CodeSwitch{err: err}
+}
+
+// Case adds a handler for errors with a code that starts with prefix.
+func (s *CodeSwitch) Case(prefix string, handler func(*Error) error) *CodeSwitch {
+	s.cases = append(s.cases, switchCase{prefix: prefix, handler: handler})
+	return s
+}
+
+// Default sets the handler for errors which don't match any case. It is given the error as it would be returned by
+// Propagate.
+func (s *CodeSwitch) Default(handler func(*Error) error) *CompleteCodeSwitch {
+	return &CompleteCodeSwitch{CodeSwitch: *s, fallback: handler}
+}
+
+// Run calls the handler for the error and returns its result. The links of the causal chain are visited in order,
+// outermost first, and the first case (in the order they were added) whose prefix matches the code of a link wins;
+// its handler is given that link. If no case matches, the default handler is called. A nil error returns nil without
+// calling any handler.
+func (s *CompleteCodeSwitch) Run() error {
+	if s.err == nil {
+		return nil
+	}
+	maxDepth := CurrentMaxCausalDepth()
+	var next error = s.err
+	for depth := 0; next != nil && depth < maxDepth; depth++ {
+		terr, ok := next.(*Error)
+		if !ok {
+			break
+		}
+		for _, c := range s.cases {
+			if terr.PrefixMatches(c.prefix) {
+				return c.handler(terr)
+			}
+		}
+		next = terr.cause
+	}
+	return s.fallback(Propagate(s.err).(*Error))
+}
@@ -0,0 +1,44 @@
+package terrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSwitch(t *testing.T) {
+	route := func(err error) string {
+		var handled string
+		Switch(err).
+			Case(ErrNotFound, func(terr *Error) error { handled = "not_found:" + terr.Code; return nil }).
+			Case(ErrConflict, func(terr *Error) error { handled = "conflict:" + terr.Code; return nil }).
+			Case("not_found.account", func(terr *Error) error { handled = "unreachable"; return nil }).
+			Default(func(terr *Error) error { handled = "default:" + terr.Code; return nil }).
+			Run()
+		return handled
+	}
+
+	assert.Equal(t, "not_found:not_found.account", route(NotFound("account", "", nil)))
+	assert.Equal(t, "conflict:conflict.version", route(Conflict("version", "", nil)))
+	assert.Equal(t, "default:bad_request.foo", route(BadRequest("foo", "", nil)))
+	assert.Equal(t, "default:internal_service", route(errors.New("boom")))
+	assert.Equal(t, "", route(nil))
+
+	// Links are visited in chain order, so the outer conflict wins over the not_found cause
+	chained := NewInternalWithCause(NotFound("account", "", nil), "", nil, "")
+	chained.Code = "conflict.wrapped"
+	assert.Equal(t, "conflict:conflict.wrapped", route(chained))
+
+	// Causes are matched too
+	assert.Equal(t, "not_found:not_found.account", route(NewInternalWithCause(NotFound("account", "", nil), "", nil, "")))
+}
+
+func TestSwitchResult(t *testing.T) {
+	orig := Timeout("foo", "", nil)
+	err := Switch(orig).
+		Case(ErrNotFound, func(*Error) error { return nil }).
+		Default(func(terr *Error) error { return terr }).
+		Run()
+	assert.Equal(t, orig, err)
+}
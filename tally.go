@@ -0,0 +1,108 @@
+package terrors
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Tally counts errors by code, for example those encountered while serving a single request, so that latency
+// sensitive paths can detect internal error churn which doesn't surface in the response (e.g. errors which were
+// retried or fell back). It is safe for concurrent use. A nil *Tally ignores records.
+type Tally struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewTally returns an empty Tally.
+func NewTally() *Tally {
+	return &Tally{counts: map[string]int{}}
+}
+
+// Record counts err under its code. Errors which aren't terrors are counted under the code they would have if they
+// were passed to Propagate. Nil errors aren't counted.
+func (t *Tally) Record(err error) {
+	if t == nil || err == nil {
+		return
+	}
+	code := causeCode(err)
+	if terr, ok := err.(*Error); ok {
+		code = terr.Code
+	} else if translated, ok := translateCause(err, false); ok {
+		code = translated.Code
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counts[code]++
+}
+
+// Counts returns a copy of the number of errors recorded for each code.
+func (t *Tally) Counts() map[string]int {
+	counts := map[string]int{}
+	if t == nil {
+		return counts
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for code, n := range t.counts {
+		counts[code] = n
+	}
+	return counts
+}
+
+// Total returns the number of errors recorded.
+func (t *Tally) Total() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	total := 0
+	for _, n := range t.counts {
+		total += n
+	}
+	return total
+}
+
+// String returns the counts in the form `code=n,code=n`, ordered by code, which is suitable for a response header or
+// a log field.
+func (t *Tally) String() string {
+	counts := t.Counts()
+	codes := make([]string, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	parts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		parts = append(parts, code+"="+strconv.Itoa(counts[code]))
+	}
+	return strings.Join(parts, ",")
+}
+
+type tallyContextKey struct{}
+
+// WithTally returns a context carrying a new Tally, which errors can be recorded into with Record.
+func WithTally(ctx context.Context) (context.Context, *Tally) {
+	t := NewTally()
+	return context.WithValue(ctx, tallyContextKey{}, t), t
+}
+
+// TallyFromContext returns the Tally carried by ctx, or nil if there isn't one.
+func TallyFromContext(ctx context.Context) *Tally {
+	t, _ := ctx.Value(tallyContextKey{}).(*Tally)
+	return t
+}
+
+// Record counts err in the Tally carried by ctx, if any, and returns err unchanged, so that it can be used in return
+// statements:
+//
+//	return terrors.Record(ctx, err)
+func Record(ctx context.Context, err error) error {
+	TallyFromContext(ctx).Record(err)
+	return err
+}
@@ -0,0 +1,87 @@
+package terrors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTally(t *testing.T) {
+	ctx, tally := WithTally(context.Background())
+	assert.Same(t, tally, TallyFromContext(ctx))
+
+	notFound := NotFound("card", "", nil)
+	assert.Equal(t, notFound, Record(ctx, notFound))
+	Record(ctx, Augment(notFound, "context", nil))
+	Record(ctx, Timeout("", "", nil))
+	Record(ctx, errors.New("boom"))
+	assert.Nil(t, Record(ctx, nil))
+
+	assert.Equal(t, map[string]int{
+		"not_found.card":   2,
+		ErrTimeout:         1,
+		ErrInternalService: 1,
+	}, tally.Counts())
+	assert.Equal(t, 4, tally.Total())
+	assert.Equal(t, "internal_service=1,not_found.card=2,timeout=1", tally.String())
+}
+
+func TestTallyConcurrent(t *testing.T) {
+	tally := NewTally()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tally.Record(Timeout("", "", nil))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1000, tally.Total())
+}
+
+func TestTallyWithoutContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, TallyFromContext(ctx))
+	err := Timeout("", "", nil)
+	assert.Equal(t, err, Record(ctx, err))
+
+	var tally *Tally
+	tally.Record(err)
+	assert.Equal(t, 0, tally.Total())
+	assert.Empty(t, tally.Counts())
+	assert.Equal(t, "", tally.String())
+}
+
+func TestTallyUsesTranslators(t *testing.T) {
+	withCauseTranslator(t, func(err error) (*Error, bool) {
+		return NotFound("translated", "", nil), true
+	})
+	tally := NewTally()
+	tally.Record(errors.New("boom"))
+	assert.Equal(t, map[string]int{"not_found.translated": 1}, tally.Counts())
+}
+
+func TestInspectingErrorsDoesNotRecordThem(t *testing.T) {
+	tally := NewTally()
+	SetProcessTally(tally)
+	defer SetProcessTally(nil)
+
+	err := errors.New("boom")
+	IsRetryable(err)
+	PrefixMatches(err, ErrInternalService)
+	Matches(err, "boom")
+	HTTPStatus(err)
+	RetryabilityReason(err)
+	HTTPStatus(&json.SyntaxError{})
+	assert.Empty(t, tally.Counts())
+
+	Propagate(err)
+	assert.Equal(t, map[string]int{ErrInternalService: 1}, tally.Counts())
+}
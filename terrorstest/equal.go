@@ -0,0 +1,119 @@
+package terrorstest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monzo/terrors"
+	"github.com/monzo/terrors/stack"
+)
+
+// CompareOption controls which fields of a terror are ignored by EqualIgnoring.
+type CompareOption func(*compareOptions)
+
+type compareOptions struct {
+	ignoreStack        bool
+	ignoreMarshalCount bool
+	ignoreCause        bool
+	ignoreParams       map[string]bool
+}
+
+// IgnoreStack ignores the stack frames of the errors.
+var IgnoreStack CompareOption = func(o *compareOptions) {
+	o.ignoreStack = true
+}
+
+// IgnoreMarshalCount ignores how many times the errors have been marshalled.
+var IgnoreMarshalCount CompareOption = func(o *compareOptions) {
+	o.ignoreMarshalCount = true
+}
+
+// IgnoreCause ignores the causes of the errors, and the message and context chains which record them. By default,
+// the causal chains are compared link by link.
+var IgnoreCause CompareOption = func(o *compareOptions) {
+	o.ignoreCause = true
+}
+
+// IgnoreParams ignores the params with the given keys, wherever they appear in the errors.
+func IgnoreParams(keys ...string) CompareOption {
+	return func(o *compareOptions) {
+		for _, k := range keys {
+			o.ignoreParams[k] = true
+		}
+	}
+}
+
+// comparableError holds the fields of a terror which EqualIgnoring compares.
+type comparableError struct {
+	Code         string
+	Message      string
+	Params       map[string]string
+	StackFrames  stack.Stack
+	Retryable    bool
+	Unexpected   bool
+	MarshalCount int
+	MessageChain []string
+	ContextChain []terrors.ContextEntry
+	Cause        interface{}
+}
+
+// EqualIgnoring asserts that two terrors are equal, apart from the fields ignored by the options. Volatile fields such
+// as the time the errors were created are always ignored. Retryability and unexpectedness are compared by their
+// effective values, so an unset flag equals a flag explicitly set to its default.
+// It returns whether the errors are equal, and reports a diff through t if not.
+func EqualIgnoring(t testing.TB, want, got error, opts ...CompareOption) bool {
+	t.Helper()
+	o := &compareOptions{ignoreParams: map[string]bool{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return assert.Equal(t, toComparable(want, o), toComparable(got, o))
+}
+
+func toComparable(err error, o *compareOptions) interface{} {
+	terr, ok := err.(*terrors.Error)
+	if !ok || terr == nil {
+		if err == nil {
+			return nil
+		}
+		// Foreign errors are compared by their messages
+		return err.Error()
+	}
+
+	c := &comparableError{
+		Code:         terr.Code,
+		Message:      terr.Message,
+		Params:       filterParams(terr.Params, o),
+		Retryable:    terr.Retryable(),
+		Unexpected:   terr.Unexpected(),
+		MarshalCount: terr.MarshalCount,
+		MessageChain: terr.MessageChain,
+	}
+	if !o.ignoreStack {
+		c.StackFrames = terr.StackFrames
+	}
+	if o.ignoreMarshalCount {
+		c.MarshalCount = 0
+	}
+	for _, entry := range terr.ContextChain {
+		entry.Params = filterParams(entry.Params, o)
+		c.ContextChain = append(c.ContextChain, entry)
+	}
+	if o.ignoreCause {
+		c.MessageChain, c.ContextChain = nil, nil
+	} else {
+		c.Cause = toComparable(terr.Unwrap(), o)
+	}
+	return c
+}
+
+func filterParams(params map[string]string, o *compareOptions) map[string]string {
+	filtered := make(map[string]string, len(params))
+	for k, v := range params {
+		if !o.ignoreParams[k] {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
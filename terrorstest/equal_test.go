@@ -0,0 +1,66 @@
+package terrorstest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monzo/terrors"
+)
+
+// recordingT records failures without failing the test.
+type recordingT struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.failed = true
+}
+
+func TestEqualIgnoring(t *testing.T) {
+	newErr := func(params map[string]string) error {
+		return terrors.Augment(terrors.NotFound("foo", "bar", params), "context", nil)
+	}
+
+	t.Run("equal apart from stack and time", func(t *testing.T) {
+		want := newErr(map[string]string{"id": "1", "time": "10:00"})
+		got := newErr(map[string]string{"id": "1", "time": "10:01"})
+		assert.True(t, EqualIgnoring(t, want, got, IgnoreStack, IgnoreParams("time")))
+	})
+	t.Run("different params", func(t *testing.T) {
+		mock := &recordingT{TB: t}
+		want := newErr(map[string]string{"id": "1"})
+		got := newErr(map[string]string{"id": "2"})
+		assert.False(t, EqualIgnoring(mock, want, got, IgnoreStack))
+		assert.True(t, mock.failed)
+	})
+	t.Run("stacks compared by default", func(t *testing.T) {
+		mock := &recordingT{TB: t}
+		assert.False(t, EqualIgnoring(mock, newErr(nil), newErr(nil)))
+	})
+	t.Run("causes", func(t *testing.T) {
+		want := terrors.Augment(errors.New("boom"), "context", nil)
+		got := terrors.Augment(errors.New("boom"), "context", nil)
+		assert.True(t, EqualIgnoring(t, want, got, IgnoreStack))
+
+		mock := &recordingT{TB: t}
+		got = terrors.Augment(errors.New("bang"), "context", nil)
+		assert.False(t, EqualIgnoring(mock, want, got, IgnoreStack))
+		assert.True(t, EqualIgnoring(t, want, got, IgnoreStack, IgnoreCause))
+	})
+	t.Run("effective flags", func(t *testing.T) {
+		want := &terrors.Error{Code: terrors.ErrBadRequest}
+		got := &terrors.Error{Code: terrors.ErrBadRequest}
+		got.SetIsRetryable(false)
+		assert.True(t, EqualIgnoring(t, want, got))
+	})
+	t.Run("marshal count", func(t *testing.T) {
+		want := terrors.NotFound("foo", "bar", nil)
+		got := terrors.Unmarshal(terrors.Marshal(want))
+		mock := &recordingT{TB: t}
+		assert.False(t, EqualIgnoring(mock, want, got))
+		assert.True(t, EqualIgnoring(t, want, got, IgnoreMarshalCount, IgnoreStack))
+	})
+}
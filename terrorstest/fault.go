@@ -0,0 +1,125 @@
+// Package terrorstest provides helpers for testing code which uses terrors.
+package terrorstest
+
+import (
+	"math/rand"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/monzo/terrors"
+)
+
+// FaultInjectedParam is set on every error produced by a Fault, so that injected errors can be told apart from real
+// ones in logs.
+const FaultInjectedParam = "fault_injected"
+
+// Fault is a source of errors which fails at a configured rate, for testing how code behaves when its dependencies
+// fail. Create one with Faulty. It is safe for concurrent use.
+type Fault struct {
+	rate   float64
+	code   string
+	params map[string]string
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	count int64
+}
+
+// Faulty returns a Fault which fails with the given probability (between 0 and 1) with errors with the given code.
+// The errors are built like real terrors: they have a stack pointing at the caller, and their retryability is
+// derived from the code.
+func Faulty(rate float64, code string) *Fault {
+	return &Fault{
+		rate: rate,
+		code: code,
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// WithSeed makes the faults injected deterministic.
+func (f *Fault) WithSeed(seed int64) *Fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rand = rand.New(rand.NewSource(seed))
+	return f
+}
+
+// WithParams adds params to the injected errors.
+func (f *Fault) WithParams(params map[string]string) *Fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.params = params
+	return f
+}
+
+// Injected returns the number of errors which have been injected so far.
+func (f *Fault) Injected() int {
+	return int(atomic.LoadInt64(&f.count))
+}
+
+// Err returns an error with the configured probability, and nil otherwise.
+func (f *Fault) Err() error {
+	// Skip maybeFail() and Err()
+	return f.maybeFail(2, nil)
+}
+
+// maybeFail returns an error with the configured probability, with the given extra params. The stack of the error
+// skips the given number of frames, including maybeFail itself.
+func (f *Fault) maybeFail(skip int, extra map[string]string) error {
+	f.mu.Lock()
+	fail := f.rand.Float64() < f.rate
+	params := make(map[string]string, len(f.params)+len(extra)+1)
+	for k, v := range f.params {
+		params[k] = v
+	}
+	f.mu.Unlock()
+	if !fail {
+		return nil
+	}
+
+	n := atomic.AddInt64(&f.count, 1)
+	for k, v := range extra {
+		params[k] = v
+	}
+	params[FaultInjectedParam] = strconv.FormatInt(n, 10)
+
+	pcs := make([]uintptr, 64)
+	// Also skip runtime.Callers()
+	pcs = pcs[:runtime.Callers(skip+1, pcs)]
+	return terrors.NewFromPCs(f.code, "injected fault", pcs, params)
+}
+
+// RoundTripper wraps next (or http.DefaultTransport if nil) so that requests fail with the configured probability,
+// without being sent.
+func (f *Fault) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &faultyRoundTripper{fault: f, next: next}
+}
+
+type faultyRoundTripper struct {
+	fault *Fault
+	next  http.RoundTripper
+}
+
+func (rt *faultyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Skip maybeFail() and RoundTrip()
+	err := rt.fault.maybeFail(2, map[string]string{
+		"method": req.Method,
+		"host":   req.URL.Host,
+		"path":   req.URL.Path,
+	})
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,74 @@
+package terrorstest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monzo/terrors"
+)
+
+func TestFaulty(t *testing.T) {
+	t.Run("always", func(t *testing.T) {
+		f := Faulty(1, terrors.ErrTimeout+".downstream").WithParams(map[string]string{"dependency": "ledger"})
+		err := f.Err()
+		assert.True(t, terrors.Is(err, terrors.ErrTimeout))
+
+		terr := err.(*terrors.Error)
+		assert.True(t, terr.Retryable())
+		assert.Equal(t, "ledger", terr.Params["dependency"])
+		assert.Equal(t, "1", terr.Params[FaultInjectedParam])
+		assert.Contains(t, terr.StackFrames[0].Method, "TestFaulty")
+		assert.Equal(t, 1, f.Injected())
+	})
+	t.Run("never", func(t *testing.T) {
+		f := Faulty(0, terrors.ErrTimeout)
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, f.Err())
+		}
+		assert.Equal(t, 0, f.Injected())
+	})
+	t.Run("rate", func(t *testing.T) {
+		f := Faulty(0.3, terrors.ErrInternalService).WithSeed(42)
+		for i := 0; i < 1000; i++ {
+			f.Err()
+		}
+		assert.InDelta(t, 300, f.Injected(), 50)
+	})
+	t.Run("seeded", func(t *testing.T) {
+		results := func() []bool {
+			f := Faulty(0.5, terrors.ErrInternalService).WithSeed(7)
+			var out []bool
+			for i := 0; i < 20; i++ {
+				out = append(out, f.Err() != nil)
+			}
+			return out
+		}
+		assert.Equal(t, results(), results())
+	})
+}
+
+func TestFaultyRoundTripper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Faulty(1, terrors.ErrRateLimited).RoundTripper(nil)}
+	_, err := client.Get(srv.URL + "/foo")
+	// The client wraps the error from the transport in a *url.Error
+	var terr *terrors.Error
+	assert.True(t, errors.As(err, &terr))
+	assert.True(t, terrors.Is(terr, terrors.ErrRateLimited))
+	assert.Equal(t, http.MethodGet, terr.Params["method"])
+	assert.Equal(t, "/foo", terr.Params["path"])
+
+	client = &http.Client{Transport: Faulty(0, terrors.ErrRateLimited).RoundTripper(nil)}
+	resp, err := client.Get(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,244 @@
+package terrorstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+// WireVector is a canonical encoding of an error on the wire, which implementations of terrors in other languages
+// can use to check that they are compatible with this one: they should decode Proto to the fields in Expected, and
+// encoding Expected should produce bytes which VerifyWireVector accepts.
+//
+// The vectors are also published as JSON in testdata/wire_vectors.json (see WireVectorsJSON), so that they can be
+// consumed without running Go.
+type WireVector struct {
+	// Name identifies the vector.
+	Name string `json:"name"`
+	// Description says what the vector exercises.
+	Description string `json:"description"`
+	// Proto is the protobuf encoding of Expected, with map entries in key order. It is base64 encoded in JSON.
+	Proto []byte `json:"proto"`
+	// Expected holds the field values encoded by Proto.
+	Expected *pe.Error `json:"expected"`
+}
+
+// wireVectorErrors are the errors encoded by the vectors. They must not change once published, as other
+// implementations test against them; add new vectors instead.
+func wireVectorErrors() []WireVector {
+	return []WireVector{
+		{
+			Name:        "minimal",
+			Description: "only a code and message",
+			Expected: &pe.Error{
+				Code:    "bad_request.missing_param",
+				Message: "missing param: account_id",
+			},
+		},
+		{
+			Name:        "empty",
+			Description: "no fields set at all",
+			Expected:    &pe.Error{},
+		},
+		{
+			Name:        "flags",
+			Description: "params, explicit retryability and unexpectedness, and a marshal count",
+			Expected: &pe.Error{
+				Code:         "internal_service.ledger_unavailable",
+				Message:      "ledger is unavailable",
+				Params:       map[string]string{"account_id": "acc_123", "attempt": "2", "zone": "eu-west-1"},
+				Retryable:    &pe.BoolValue{Value: true},
+				Unexpected:   &pe.BoolValue{Value: false},
+				MarshalCount: 3,
+			},
+		},
+		{
+			Name:        "stack",
+			Description: "stack frames, including program counters, source hashes and the build ID",
+			Expected: &pe.Error{
+				Code:    "timeout",
+				Message: "timed out calling service.ledger",
+				Stack: []*pe.StackFrame{
+					{Filename: "github.com/monzo/ledger/client.go", Line: 42, Method: "client.(*Client).Post"},
+					{Filename: "github.com/monzo/ledger/handler.go", Line: 7, Method: "ledger.handle", Pc: 4198400,
+						SourceHash: "0a1b2c3d"},
+				},
+				StackBuildId: "github.com/monzo/ledger@v1.2.3",
+			},
+		},
+		{
+			Name:        "chains",
+			Description: "message and context chains from an error augmented twice",
+			Expected: &pe.Error{
+				Code:         "not_found.account",
+				Message:      "handling request",
+				Params:       map[string]string{"account_id": "acc_123"},
+				MessageChain: []string{"loading account", "account not found"},
+				ContextChain: []*pe.ContextEntry{
+					{Code: "not_found.account", Message: "loading account", Params: map[string]string{"shard": "7"}},
+					{Code: "not_found.account", Message: "account not found"},
+				},
+				Retryable:    &pe.BoolValue{Value: false},
+				MarshalCount: 1,
+			},
+		},
+		{
+			Name:        "unicode",
+			Description: "non-ASCII text in messages and params",
+			Expected: &pe.Error{
+				Code:    "bad_request.invalid_name",
+				Message: "name «Zoë 🦄» is invalid",
+				Params:  map[string]string{"name": "Zoë 🦄", "ключ": "значение"},
+			},
+		},
+		{
+			Name:        "stack_omitted",
+			Description: "the stack omitted by the sender, with the chains kept",
+			Expected: &pe.Error{
+				Code:         "timeout",
+				Message:      "calling service.ledger",
+				MessageChain: []string{"timed out"},
+				ContextChain: []*pe.ContextEntry{{Code: "timeout", Message: "timed out"}},
+				MarshalCount: 1,
+				StackOmitted: true,
+			},
+		},
+		{
+			Name:        "retry_info",
+			Description: "structured retry hints alongside the equivalent params",
+			Expected: &pe.Error{
+				Code:    "rate_limited.too_many_requests",
+				Message: "slow down",
+				Params: map[string]string{
+					"retry_after":  "1500ms",
+					"attempt":      "2",
+					"max_attempts": "5",
+				},
+				Retryable:    &pe.BoolValue{Value: true},
+				MarshalCount: 1,
+				RetryInfo: &pe.RetryInfo{
+					RetryAfterMs: 1500,
+					BackoffMs:    200,
+					Attempt:      2,
+					MaxAttempts:  5,
+				},
+			},
+		},
+	}
+}
+
+// WireVectors returns the canonical wire vectors.
+func WireVectors() []WireVector {
+	vectors := wireVectorErrors()
+	for i := range vectors {
+		data, err := marshalDeterministic(vectors[i].Expected)
+		if err != nil {
+			// The vectors are fixed, so this can only be a bug
+			panic(fmt.Sprintf("terrorstest: encoding wire vector %q: %v", vectors[i].Name, err))
+		}
+		vectors[i].Proto = data
+	}
+	return vectors
+}
+
+// WireVectorsJSON returns the canonical wire vectors as indented JSON, in the form published in
+// testdata/wire_vectors.json.
+func WireVectorsJSON() ([]byte, error) {
+	return json.MarshalIndent(WireVectors(), "", "  ")
+}
+
+// VerifyWireVector checks that data, produced by another implementation by encoding the fields of the named vector,
+// decodes to those fields. Map entries and unknown fields may be encoded in any order. It returns an error describing
+// the first mismatched field, if any.
+func VerifyWireVector(name string, data []byte) error {
+	var want *pe.Error
+	for _, v := range wireVectorErrors() {
+		if v.Name == name {
+			want = v.Expected
+		}
+	}
+	if want == nil {
+		return fmt.Errorf("no wire vector named %q", name)
+	}
+
+	got := &pe.Error{}
+	if err := proto.Unmarshal(data, got); err != nil {
+		return fmt.Errorf("wire vector %q: decoding: %v", name, err)
+	}
+	if proto.Equal(want, got) {
+		return nil
+	}
+	return fmt.Errorf("wire vector %q: %s", name, describeMismatch(want, got))
+}
+
+// describeMismatch names the first field of want and got which differs.
+func describeMismatch(want, got *pe.Error) string {
+	fields := []struct {
+		name      string
+		want, got interface{}
+	}{
+		{"code", want.Code, got.Code},
+		{"message", want.Message, got.Message},
+		{"params", want.Params, got.Params},
+		{"retryable", want.Retryable.GetValue(), got.Retryable.GetValue()},
+		{"retryable set", want.Retryable != nil, got.Retryable != nil},
+		{"unexpected", want.Unexpected.GetValue(), got.Unexpected.GetValue()},
+		{"unexpected set", want.Unexpected != nil, got.Unexpected != nil},
+		{"marshal_count", want.MarshalCount, got.MarshalCount},
+		{"message_chain", want.MessageChain, got.MessageChain},
+		{"stack_build_id", want.StackBuildId, got.StackBuildId},
+		{"stack_omitted", want.StackOmitted, got.StackOmitted},
+		{"retry_info set", want.RetryInfo != nil, got.RetryInfo != nil},
+		{"retry_info.retry_after_ms", want.RetryInfo.GetRetryAfterMs(), got.RetryInfo.GetRetryAfterMs()},
+		{"retry_info.backoff_ms", want.RetryInfo.GetBackoffMs(), got.RetryInfo.GetBackoffMs()},
+		{"retry_info.attempt", want.RetryInfo.GetAttempt(), got.RetryInfo.GetAttempt()},
+		{"retry_info.max_attempts", want.RetryInfo.GetMaxAttempts(), got.RetryInfo.GetMaxAttempts()},
+		{"stack length", len(want.Stack), len(got.Stack)},
+		{"context_chain length", len(want.ContextChain), len(got.ContextChain)},
+	}
+	for _, f := range fields {
+		if !reflect.DeepEqual(normalise(f.want), normalise(f.got)) {
+			return fmt.Sprintf("%s: want %v, got %v", f.name, f.want, f.got)
+		}
+	}
+	for i := range want.Stack {
+		if !proto.Equal(want.Stack[i], got.Stack[i]) {
+			return fmt.Sprintf("stack[%d]: want %v, got %v", i, want.Stack[i], got.Stack[i])
+		}
+	}
+	for i := range want.ContextChain {
+		if !proto.Equal(want.ContextChain[i], got.ContextChain[i]) {
+			return fmt.Sprintf("context_chain[%d]: want %v, got %v", i, want.ContextChain[i], got.ContextChain[i])
+		}
+	}
+	return "fields differ"
+}
+
+// normalise treats empty maps and slices as equal to nil ones, as they are indistinguishable on the wire.
+func normalise(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]string:
+		if len(v) == 0 {
+			return nil
+		}
+	case []string:
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return v
+}
+
+func marshalDeterministic(e *pe.Error) ([]byte, error) {
+	b := proto.NewBuffer(nil)
+	b.SetDeterministic(true)
+	if err := b.Marshal(e); err != nil {
+		return nil, err
+	}
+	// An empty error encodes to no bytes, which should be published as such rather than as null
+	return append([]byte{}, b.Bytes()...), nil
+}
@@ -0,0 +1,48 @@
+package terrorstest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/monzo/terrors"
+	pe "github.com/monzo/terrors/proto"
+)
+
+func TestWireVectorsPublished(t *testing.T) {
+	published, err := os.ReadFile("testdata/wire_vectors.json")
+	assert.NoError(t, err)
+	generated, err := WireVectorsJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, string(published), string(generated)+"\n",
+		"testdata/wire_vectors.json is out of date: regenerate it from WireVectorsJSON")
+}
+
+func TestWireVectorsRoundTrip(t *testing.T) {
+	for _, v := range WireVectors() {
+		t.Run(v.Name, func(t *testing.T) {
+			assert.NoError(t, VerifyWireVector(v.Name, v.Proto))
+
+			// The vectors must survive a round trip through this implementation
+			terr := terrors.Unmarshal(v.Expected)
+			assert.Equal(t, v.Expected.GetMessage(), terr.Message)
+			assert.Equal(t, len(v.Expected.GetStack()), len(terr.StackFrames))
+		})
+	}
+}
+
+func TestVerifyWireVector(t *testing.T) {
+	assert.EqualError(t, VerifyWireVector("nope", nil), `no wire vector named "nope"`)
+
+	wrong, err := proto.Marshal(&pe.Error{
+		Code:    "bad_request.missing_param",
+		Message: "missing param: card_id",
+	})
+	assert.NoError(t, err)
+	assert.EqualError(t, VerifyWireVector("minimal", wrong),
+		`wire vector "minimal": message: want missing param: account_id, got missing param: card_id`)
+
+	assert.Error(t, VerifyWireVector("minimal", []byte{0xff}))
+}
@@ -0,0 +1,184 @@
+package terrors
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TimeoutPhase is the phase of a call in which a timeout happened.
+type TimeoutPhase string
+
+// Phases of an HTTP call, as recorded by HTTPTimeoutTracer. Other transports may use these, or their own phases.
+const (
+	// TimeoutPhaseConnect is waiting for a connection, before a new one is dialled, e.g. for a free connection in the
+	// pool.
+	TimeoutPhaseConnect TimeoutPhase = "connect"
+	// TimeoutPhaseDNS is resolving the address of the server.
+	TimeoutPhaseDNS TimeoutPhase = "dns"
+	// TimeoutPhaseDial is establishing the connection.
+	TimeoutPhaseDial TimeoutPhase = "dial"
+	// TimeoutPhaseTLS is the TLS handshake.
+	TimeoutPhaseTLS TimeoutPhase = "tls"
+	// TimeoutPhaseRequest is writing the request.
+	TimeoutPhaseRequest TimeoutPhase = "request"
+	// TimeoutPhaseFirstByte is waiting for the server to start responding, after the request was written.
+	TimeoutPhaseFirstByte TimeoutPhase = "first_byte"
+	// TimeoutPhaseBody is reading the response.
+	TimeoutPhaseBody TimeoutPhase = "body"
+)
+
+// Params set by AttachTimeoutDetail, along with ContextDeadlineParam for the deadline.
+const (
+	// TimeoutElapsedParam holds how long the call ran for before timing out, as a Go duration string.
+	TimeoutElapsedParam = "timeout_elapsed"
+	// TimeoutPhaseParam holds the phase of the call which timed out.
+	TimeoutPhaseParam = "timeout_phase"
+)
+
+// TimeoutDetail describes a timeout, so that it can be diagnosed without a packet trace: whether the call timed out
+// connecting to the server or waiting for it to respond, and how the time it took compares to its deadline.
+type TimeoutDetail struct {
+	// Elapsed is how long the call ran for before timing out.
+	Elapsed time.Duration
+	// Deadline is when the call had to complete by. It is zero if the deadline isn't known.
+	Deadline time.Time
+	// Phase is the phase of the call which timed out. It is empty if the phase isn't known.
+	Phase TimeoutPhase
+}
+
+// AttachTimeoutDetail returns a copy of err with detail attached, for retrieval with TimeoutDetailOf or As, in the same
+// way as AttachDetail. Unlike other details, it is also recorded in params (TimeoutElapsedParam, TimeoutPhaseParam and
+// ContextDeadlineParam), so it survives being marshalled. Clients of transports without a tracer of their own (e.g.
+// gRPC interceptors) can attach it directly:
+//
+//	start := time.Now()
+//	err := invoker(ctx, method, req, reply, cc, opts...)
+//	if terrors.Is(err, terrors.ErrTimeout) {
+//		deadline, _ := ctx.Deadline()
+//		err = terrors.AttachTimeoutDetail(err, terrors.TimeoutDetail{Elapsed: time.Since(start), Deadline: deadline})
+//	}
+//
+// If err is not a terror, it is propagated first. It returns nil if err is nil.
+func AttachTimeoutDetail(err error, detail TimeoutDetail) error {
+	if err == nil {
+		return nil
+	}
+	terr := addParams(Propagate(err).(*Error), detail.params())
+	terr.details = append(append([]interface{}{}, terr.details...), detail)
+	return terr
+}
+
+// params returns the params recording d.
+func (d TimeoutDetail) params() map[string]string {
+	params := map[string]string{TimeoutElapsedParam: d.Elapsed.String()}
+	if !d.Deadline.IsZero() {
+		params[ContextDeadlineParam] = d.Deadline.Format(time.RFC3339Nano)
+	}
+	if d.Phase != "" {
+		params[TimeoutPhaseParam] = string(d.Phase)
+	}
+	return params
+}
+
+// TimeoutDetailOf returns the detail of the timeout which caused err, as attached by AttachTimeoutDetail. For errors
+// which were unmarshalled from another service, it is rebuilt from the params of the first terror in the chain which
+// has them.
+func TimeoutDetailOf(err error) (TimeoutDetail, bool) {
+	var detail TimeoutDetail
+	if As(err, &detail) {
+		return detail, true
+	}
+	maxDepth := CurrentMaxCausalDepth()
+	for next, depth := err, 0; next != nil && depth < maxDepth; depth++ {
+		if terr, ok := next.(*Error); ok {
+			if elapsed, err := time.ParseDuration(terr.Params[TimeoutElapsedParam]); err == nil {
+				detail.Elapsed = elapsed
+				detail.Deadline, _ = time.Parse(time.RFC3339Nano, terr.Params[ContextDeadlineParam])
+				detail.Phase = TimeoutPhase(terr.Params[TimeoutPhaseParam])
+				return detail, true
+			}
+		}
+		next = errors.Unwrap(next)
+	}
+	return TimeoutDetail{}, false
+}
+
+// HTTPTimeoutTracer follows the progress of an HTTP request, so that if it times out, the error can record the phase
+// in which it did (see TraceHTTPTimeouts).
+type HTTPTimeoutTracer struct {
+	start    time.Time
+	deadline time.Time
+
+	mu    sync.Mutex
+	phase TimeoutPhase
+}
+
+// TraceHTTPTimeouts returns a copy of req which is traced by the returned HTTPTimeoutTracer. Pass errors from the
+// client, and from reading the response, through the Error method of the tracer:
+//
+//	req, tracer := terrors.TraceHTTPTimeouts(req)
+//	resp, err := client.Do(req)
+//	if err != nil {
+//		return tracer.Error(err)
+//	}
+//
+// The deadline is taken from the context of req. The timeout of the http.Client isn't visible to the tracer, so set
+// a deadline on the context instead if it should be recorded.
+func TraceHTTPTimeouts(req *http.Request) (*http.Request, *HTTPTimeoutTracer) {
+	t := &HTTPTimeoutTracer{start: time.Now(), phase: TimeoutPhaseConnect}
+	ctx := req.Context()
+	if deadline, ok := ctx.Deadline(); ok {
+		t.deadline = deadline
+	}
+	trace := &httptrace.ClientTrace{
+		GetConn:              func(string) { t.enter(TimeoutPhaseConnect) },
+		DNSStart:             func(httptrace.DNSStartInfo) { t.enter(TimeoutPhaseDNS) },
+		ConnectStart:         func(string, string) { t.enter(TimeoutPhaseDial) },
+		TLSHandshakeStart:    func() { t.enter(TimeoutPhaseTLS) },
+		GotConn:              func(httptrace.GotConnInfo) { t.enter(TimeoutPhaseRequest) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.enter(TimeoutPhaseFirstByte) },
+		GotFirstResponseByte: func() { t.enter(TimeoutPhaseBody) },
+	}
+	return req.WithContext(httptrace.WithClientTrace(ctx, trace)), t
+}
+
+// enter records that the request has reached phase.
+func (t *HTTPTimeoutTracer) enter(phase TimeoutPhase) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.phase = phase
+}
+
+// Phase returns the phase which the request has reached.
+func (t *HTTPTimeoutTracer) Phase() TimeoutPhase {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.phase
+}
+
+// Error converts an error from the traced request into a terror. Timeouts, whether from the deadline of the context
+// or from the timeouts of the client or transport, become timeout errors with a TimeoutDetail attached. Other errors
+// are propagated as usual. It returns nil if err is nil.
+func (t *HTTPTimeoutTracer) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	var netErr net.Error
+	if !errors.Is(err, context.DeadlineExceeded) && !(errors.As(err, &netErr) && netErr.Timeout()) {
+		return Propagate(err)
+	}
+	detail := TimeoutDetail{Elapsed: time.Since(t.start), Deadline: t.deadline, Phase: t.Phase()}
+	terr, ok := err.(*Error)
+	if !ok || !terr.Timeout() {
+		// Errors from the transport's own timeouts don't always wrap context.DeadlineExceeded, so would otherwise
+		// become internal service errors
+		terr = errorFactory(ErrTimeout, err.Error(), nil)
+		terr.attachCause(err)
+	}
+	return AttachTimeoutDetail(terr, detail)
+}
@@ -0,0 +1,114 @@
+package terrors
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachTimeoutDetail(t *testing.T) {
+	deadline := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	detail := TimeoutDetail{Elapsed: 1500 * time.Millisecond, Deadline: deadline, Phase: TimeoutPhaseDial}
+
+	err := AttachTimeoutDetail(Timeout("ledger", "timed out", nil), detail)
+	assert.Equal(t, map[string]string{
+		TimeoutElapsedParam:  "1.5s",
+		TimeoutPhaseParam:    "dial",
+		ContextDeadlineParam: "2020-01-02T03:04:05Z",
+	}, err.(*Error).Params)
+
+	got, ok := TimeoutDetailOf(Augment(err, "calling ledger", nil))
+	assert.True(t, ok)
+	assert.Equal(t, detail, got)
+
+	// It is rebuilt from params after crossing a process boundary
+	got, ok = TimeoutDetailOf(Unmarshal(Marshal(err.(*Error))))
+	assert.True(t, ok)
+	assert.Equal(t, detail, got)
+
+	_, ok = TimeoutDetailOf(Timeout("ledger", "timed out", nil))
+	assert.False(t, ok)
+	assert.Nil(t, AttachTimeoutDetail(nil, detail))
+}
+
+// tracedGet makes a GET request to url with a timeout, tracing it with TraceHTTPTimeouts, and reads the response. It
+// returns the deadline of the request and the traced error.
+func tracedGet(t *testing.T, client *http.Client, url string, timeout time.Duration) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	assert.NoError(t, err)
+	req, tracer := TraceHTTPTimeouts(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return deadline, tracer.Error(err)
+	}
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	return deadline, tracer.Error(err)
+}
+
+func TestTraceHTTPTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			<-release
+		case "/stall":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-release
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	t.Run("first byte", func(t *testing.T) {
+		deadline, err := tracedGet(t, srv.Client(), srv.URL+"/slow", 50*time.Millisecond)
+		assert.True(t, Is(err, ErrTimeout))
+		detail, ok := TimeoutDetailOf(err)
+		assert.True(t, ok)
+		assert.Equal(t, TimeoutPhaseFirstByte, detail.Phase)
+		assert.Equal(t, deadline, detail.Deadline)
+		assert.GreaterOrEqual(t, int64(detail.Elapsed), int64(50*time.Millisecond))
+	})
+
+	t.Run("body", func(t *testing.T) {
+		_, err := tracedGet(t, srv.Client(), srv.URL+"/stall", 50*time.Millisecond)
+		detail, ok := TimeoutDetailOf(err)
+		assert.True(t, ok)
+		assert.Equal(t, TimeoutPhaseBody, detail.Phase)
+	})
+
+	t.Run("client timeout", func(t *testing.T) {
+		client := srv.Client()
+		client.Timeout = 50 * time.Millisecond
+		_, err := tracedGet(t, client, srv.URL+"/slow", time.Minute)
+		assert.True(t, Is(err, ErrTimeout))
+		detail, ok := TimeoutDetailOf(err)
+		assert.True(t, ok)
+		assert.Equal(t, TimeoutPhaseFirstByte, detail.Phase)
+	})
+
+	t.Run("not a timeout", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		_, err := tracedGet(t, closed.Client(), closed.URL, time.Minute)
+		assert.Error(t, err)
+		assert.False(t, Is(err, ErrTimeout))
+		_, ok := TimeoutDetailOf(err)
+		assert.False(t, ok)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		_, err := tracedGet(t, srv.Client(), srv.URL, time.Minute)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,152 @@
+package terrors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MatchStep records how a single link of a causal chain was compared by Is or PrefixMatches.
+type MatchStep struct {
+	// Depth is the position of the link in the chain; the error passed in is at depth 0.
+	Depth int
+	// Code is the code of the link, or empty if it isn't a terror.
+	Code    string
+	Matched bool
+	// Reason explains the comparison, e.g. which dotted part of the code differed from the prefix.
+	Reason string
+}
+
+// MatchDecision explains the result of a call to Is or PrefixMatches.
+type MatchDecision struct {
+	// Func is the name of the matching function, i.e. "Is" or "PrefixMatches".
+	Func string
+	// Prefix is the prefix which was matched against.
+	Prefix  string
+	Matched bool
+	// Steps records each link of the chain which was compared, in order.
+	Steps []MatchStep
+}
+
+// String returns a readable, multi-line explanation of the decision.
+func (d MatchDecision) String() string {
+	var b strings.Builder
+	result := "no match"
+	if d.Matched {
+		result = "match"
+	}
+	fmt.Fprintf(&b, "%s(%q): %s", d.Func, d.Prefix, result)
+	for _, step := range d.Steps {
+		fmt.Fprintf(&b, "\n  [%d] %s", step.Depth, step.Reason)
+	}
+	return b.String()
+}
+
+// MatchHook is called with the decision made by every call to Is and PrefixMatches.
+type MatchHook func(MatchDecision)
+
+var matchHook MatchHook
+
+// SetMatchHook installs a hook which is called with an explanation of every decision made by Is and PrefixMatches,
+// for debugging unexpected error routing. Tracing the decisions is expensive, so the hook should only be installed
+// while debugging. Decisions may be sampled (see SetHookSampling), in which case they aren't traced. Passing nil
+// removes the hook, which is the default.
+func SetMatchHook(hook MatchHook) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	matchHook = hook
+}
+
+func currentMatchHook() MatchHook {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return matchHook
+}
+
+// ExplainIs returns the decision Is would make for the given error and code, with the reasoning for each link of the
+// chain which was compared.
+func ExplainIs(err error, code ...string) MatchDecision {
+	d := MatchDecision{Func: "Is", Prefix: strings.Join(code, ".")}
+	d.Matched = explainIs(&d, err, 0, CurrentMaxCausalDepth())
+	return d
+}
+
+// explainIs records the steps of matching the chain starting at err, at the given depth, and returns whether it
+// matched. The branches of errors with several causes are each explained in turn.
+func explainIs(d *MatchDecision, err error, depth, maxDepth int) bool {
+	for ; depth <= maxDepth; depth++ {
+		terr, ok := err.(*Error)
+		if !ok {
+			if multi, isMulti := err.(multiUnwrapper); isMulti {
+				branches := multi.Unwrap()
+				d.Steps = append(d.Steps, MatchStep{
+					Depth:  depth,
+					Reason: fmt.Sprintf("%T has %d causes, so each branch is followed", err, len(branches)),
+				})
+				for _, branch := range branches {
+					if explainIs(d, branch, depth+1, maxDepth) {
+						return true
+					}
+				}
+				return false
+			}
+			if err != nil {
+				d.Steps = append(d.Steps, MatchStep{
+					Depth:  depth,
+					Reason: fmt.Sprintf("%T is not a terror, so the chain can't be followed any further", err),
+				})
+			}
+			return false
+		}
+		step := explainPrefixMatch(terr.Code, d.Prefix)
+		step.Depth = depth
+		d.Steps = append(d.Steps, step)
+		if step.Matched {
+			return true
+		}
+		if terr.cause == nil {
+			return false
+		}
+		err = terr.cause
+	}
+	return false
+}
+
+// ExplainPrefixMatches returns the decision PrefixMatches would make for the given error and prefix.
+func ExplainPrefixMatches(err error, prefixParts ...string) MatchDecision {
+	d := MatchDecision{Func: "PrefixMatches", Prefix: strings.Join(prefixParts, ".")}
+	if err == nil {
+		return d
+	}
+	terr := inspect(err)
+	step := explainPrefixMatch(terr.Code, d.Prefix)
+	if _, isTerr := err.(*Error); !isTerr {
+		step.Reason = fmt.Sprintf("%T was wrapped as a terror; %s", err, step.Reason)
+	}
+	d.Steps = append(d.Steps, step)
+	d.Matched = step.Matched
+	return d
+}
+
+// explainPrefixMatch compares a code with a prefix in the same way as PrefixMatches, explaining where they differ.
+func explainPrefixMatch(code, prefix string) MatchStep {
+	step := MatchStep{Code: code, Matched: strings.HasPrefix(code, prefix)}
+	if step.Matched {
+		step.Reason = fmt.Sprintf("code %q starts with %q", code, prefix)
+		return step
+	}
+
+	codeParts := strings.Split(code, ".")
+	prefixParts := strings.Split(prefix, ".")
+	for i, part := range prefixParts {
+		if i >= len(codeParts) {
+			step.Reason = fmt.Sprintf("code %q has fewer parts than %q", code, prefix)
+			return step
+		}
+		if codeParts[i] != part && !(i == len(prefixParts)-1 && strings.HasPrefix(codeParts[i], part)) {
+			step.Reason = fmt.Sprintf("code %q differs from %q at part %d (%q != %q)", code, prefix, i+1, codeParts[i], part)
+			return step
+		}
+	}
+	step.Reason = fmt.Sprintf("code %q doesn't start with %q", code, prefix)
+	return step
+}
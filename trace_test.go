@@ -0,0 +1,67 @@
+package terrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainIs(t *testing.T) {
+	err := NewInternalWithCause(NotFound("card", "card not found", nil), "loading card", nil, "loader")
+
+	d := ExplainIs(err, ErrNotFound, "card")
+	assert.True(t, d.Matched)
+	assert.Equal(t, "not_found.card", d.Prefix)
+	assert.Len(t, d.Steps, 2)
+	assert.False(t, d.Steps[0].Matched)
+	assert.Equal(t, "internal_service.loader", d.Steps[0].Code)
+	assert.Equal(t, `code "internal_service.loader" differs from "not_found.card" at part 1 ("internal_service" != "not_found")`, d.Steps[0].Reason)
+	assert.True(t, d.Steps[1].Matched)
+	assert.Equal(t, 1, d.Steps[1].Depth)
+
+	d = ExplainIs(err, ErrNotFound, "account")
+	assert.False(t, d.Matched)
+	assert.Equal(t, `code "not_found.card" differs from "not_found.account" at part 2 ("card" != "account")`, d.Steps[1].Reason)
+	assert.Equal(t, Is(err, ErrNotFound, "account"), d.Matched)
+
+	d = ExplainIs(NotFound("", "", nil), ErrNotFound, "card")
+	assert.Equal(t, `code "not_found" has fewer parts than "not_found.card"`, d.Steps[0].Reason)
+
+	d = ExplainIs(Augment(errors.New("boom"), "context", nil), ErrNotFound)
+	assert.False(t, d.Matched)
+	assert.Len(t, d.Steps, 2)
+	assert.Equal(t, "*errors.errorString is not a terror, so the chain can't be followed any further", d.Steps[1].Reason)
+
+	assert.Empty(t, ExplainIs(nil, ErrNotFound).Steps)
+}
+
+func TestExplainPrefixMatches(t *testing.T) {
+	d := ExplainPrefixMatches(BadRequest("missing_param", "", nil), ErrBadRequest, "missing")
+	assert.True(t, d.Matched)
+	assert.Equal(t, `code "bad_request.missing_param" starts with "bad_request.missing"`, d.Steps[0].Reason)
+
+	d = ExplainPrefixMatches(errors.New("boom"), ErrBadRequest)
+	assert.False(t, d.Matched)
+	assert.Equal(t, `*errors.errorString was wrapped as a terror; code "internal_service" differs from "bad_request" at part 1 ("internal_service" != "bad_request")`, d.Steps[0].Reason)
+}
+
+func TestMatchHook(t *testing.T) {
+	var decisions []MatchDecision
+	SetMatchHook(func(d MatchDecision) {
+		decisions = append(decisions, d)
+	})
+	defer SetMatchHook(nil)
+
+	err := Augment(NotFound("card", "", nil), "context", nil)
+	assert.True(t, Is(err, ErrNotFound))
+	assert.False(t, PrefixMatches(err, ErrBadRequest))
+	// Checking retryability doesn't produce decisions
+	assert.False(t, err.(*Error).Retryable())
+
+	assert.Len(t, decisions, 2)
+	assert.Equal(t, "Is", decisions[0].Func)
+	assert.Equal(t, "PrefixMatches", decisions[1].Func)
+	assert.Equal(t, fmt.Sprintf("Is(%q): match\n  [0] code \"not_found.card\" starts with \"not_found\"", ErrNotFound), decisions[0].String())
+}
@@ -0,0 +1,74 @@
+package terrors
+
+// TranslationRule maps errors from a downstream service to the code this service returns to its callers.
+type TranslationRule struct {
+	// From is the code prefix of the downstream errors the rule applies to, e.g. "not_found.account".
+	From string
+	// To is the code of the translated error, e.g. "bad_request.unknown_account".
+	To string
+	// Message replaces the message of the downstream error, if set.
+	Message string
+	// CarryParams lists the params which are copied from the downstream error. If nil, all params are copied.
+	CarryParams []string
+}
+
+// Translator applies a table of TranslationRules at a service boundary, so that downstream codes are mapped to this
+// service's outward codes in one place rather than in bespoke switch statements in every handler.
+type Translator struct {
+	rules []TranslationRule
+}
+
+// NewTranslator returns a Translator which applies the given rules, in order.
+func NewTranslator(rules ...TranslationRule) *Translator {
+	return &Translator{rules: rules}
+}
+
+// Translate returns err translated by the first matching rule. The links of the causal chain are visited in order,
+// outermost first, and the first rule (in the order they were given) whose From prefix matches the code of a link
+// wins. The translated error has the rule's code, with the retryability and unexpectedness that code has by default,
+// and err as its cause, so the context of every link is kept. If no rule matches, err is returned as it would be by
+// Propagate. A nil error returns nil.
+func (t *Translator) Translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	maxDepth := CurrentMaxCausalDepth()
+	var next error = err
+	for depth := 0; next != nil && depth < maxDepth; depth++ {
+		link, ok := next.(*Error)
+		if !ok {
+			break
+		}
+		for _, rule := range t.rules {
+			if !link.PrefixMatches(rule.From) {
+				continue
+			}
+			message := rule.Message
+			if message == "" {
+				message = link.Message
+			}
+			translated := errorFactory(rule.To, message, rule.carriedParams(link.Params))
+			translated.attachCause(err)
+			// The flags of the downstream error don't apply to the translated code
+			setDefaultRetryability(translated)
+			translated.IsUnexpected = nil
+			setDefaultUnexpectedness(translated)
+			return translated
+		}
+		next = link.cause
+	}
+	return Propagate(err)
+}
+
+func (r TranslationRule) carriedParams(params map[string]string) map[string]string {
+	if r.CarryParams == nil {
+		return mergeParams(params, nil)
+	}
+	carried := make(map[string]string, len(r.CarryParams))
+	for _, k := range r.CarryParams {
+		if v, ok := params[k]; ok {
+			carried[k] = v
+		}
+	}
+	return carried
+}
@@ -0,0 +1,65 @@
+package terrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslator(t *testing.T) {
+	translator := NewTranslator(
+		TranslationRule{
+			From:        "not_found.account",
+			To:          "bad_request.unknown_account",
+			Message:     "the account doesn't exist",
+			CarryParams: []string{"account_id"},
+		},
+		TranslationRule{From: ErrRateLimited, To: "unavailable.ledger"},
+	)
+
+	t.Run("matched", func(t *testing.T) {
+		downstream := Unmarshal(Marshal(NotFound("account", "no such account", map[string]string{
+			"account_id": "acc_123",
+			"shard":      "7",
+		})))
+		err := translator.Translate(Augment(downstream, "loading account", nil))
+		terr := err.(*Error)
+		assert.Equal(t, "bad_request.unknown_account", terr.Code)
+		assert.Equal(t, "the account doesn't exist", terr.Message)
+		assert.Equal(t, map[string]string{"account_id": "acc_123"}, terr.Params)
+		assert.False(t, terr.Retryable())
+		assert.Equal(t, 1, terr.MarshalCount)
+		assert.Equal(t, []string{"loading account", "no such account"}, terr.MessageChain)
+		assert.True(t, Is(terr, "not_found.account"))
+		assert.Contains(t, terr.StackFrames[0].Method, "TestTranslator")
+	})
+	t.Run("all params carried", func(t *testing.T) {
+		err := translator.Translate(RateLimited("ledger", "slow down", map[string]string{"a": "1"}))
+		terr := err.(*Error)
+		assert.Equal(t, "unavailable.ledger", terr.Code)
+		assert.Equal(t, "slow down", terr.Message)
+		assert.Equal(t, map[string]string{"a": "1"}, terr.Params)
+		assert.True(t, terr.Retryable())
+	})
+	t.Run("cause matched", func(t *testing.T) {
+		downstream := RateLimited("ledger", "slow down", nil)
+		downstream.SetIsUnexpected(true)
+		err := translator.Translate(NewInternalWithCause(downstream, "posting entry", map[string]string{"a": "1"}, ""))
+		terr := err.(*Error)
+		assert.Equal(t, "unavailable.ledger", terr.Code)
+		assert.True(t, terr.Retryable())
+		assert.False(t, terr.Unexpected())
+		// The links outside the matching one keep their context
+		assert.Equal(t, []string{"posting entry", "slow down"}, terr.MessageChain)
+		assert.Equal(t, "posting entry", terr.ContextChain[0].Message)
+		assert.Equal(t, map[string]string{"a": "1"}, terr.ContextChain[0].Params)
+		assert.True(t, Is(terr, ErrInternalService))
+	})
+	t.Run("unmatched", func(t *testing.T) {
+		orig := Timeout("ledger", "", nil)
+		assert.Equal(t, orig, translator.Translate(orig))
+		assert.True(t, Is(translator.Translate(errors.New("boom")), ErrInternalService))
+		assert.Nil(t, translator.Translate(nil))
+	})
+}
@@ -0,0 +1,62 @@
+package terrors
+
+import "github.com/monzo/terrors/stack"
+
+// CauseTranslator converts an error which is not a terror into a terror with a more appropriate code than the default
+// of internal_service, for example mapping an ORM's "record not found" error to not_found. It returns false if it
+// doesn't recognise the error. The returned error is copied before its cause and stack are set, so translators may
+// return shared sentinel errors. They are also consulted by read-only helpers such as IsRetryable and HTTPStatus, so shouldn't have side effects.
+type CauseTranslator func(err error) (*Error, bool)
+
+var causeTranslators []CauseTranslator
+
+// RegisterCauseTranslator registers a translator which is consulted by Wrap, Augment and Propagate when they are given
+// an error which is not a terror, before falling back to creating an internal_service error (or, for encoding/json
+// decode failures, a bad_request.invalid_json error, and for sql.ErrNoRows, a not_found.no_rows error). Translators are
+// consulted in the order in which they were registered, and the first to recognise the error wins.
+func RegisterCauseTranslator(translator CauseTranslator) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	causeTranslators = append(causeTranslators, translator)
+}
+
+// ConverterFunc is an alias of CauseTranslator, for use with RegisterConverter.
+type ConverterFunc = CauseTranslator
+
+// RegisterConverter registers a function which converts library errors (e.g. from database drivers or SDKs) into
+// terrors. It is the same as RegisterCauseTranslator, under the name used by some other error libraries.
+func RegisterConverter(f ConverterFunc) {
+	RegisterCauseTranslator(f)
+}
+
+// translateCause runs err through the registered translators, and then the built-in translations of encoding/json
+// decode failures and sql.ErrNoRows. If one recognises it, the resulting terror is returned with err set as its cause
+// (unless the translator set a cause itself). Any stack the translator captured points into the translator, so it is
+// dropped; callers capture their own. Errors from the built-in translations are recorded in the process tally if
+// record is set, as they would be if they were created by Propagate.
+func translateCause(err error, record bool) (*Error, bool) {
+	configMu.RLock()
+	translators := causeTranslators
+	configMu.RUnlock()
+
+	for _, translator := range translators {
+		if terr, ok := translator(err); ok && terr != nil {
+			terr = terr.Clone()
+			if terr.cause == nil {
+				terr.cause = err
+			}
+			terr.StackFrames = stack.Stack{}
+			return terr, true
+		}
+	}
+	for _, builtin := range []CauseTranslator{translateJSONCause, translateSQLCause} {
+		if terr, ok := builtin(err); ok {
+			terr.cause = err
+			if record {
+				CurrentProcessTally().Record(terr)
+			}
+			return terr, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,130 @@
+package terrors
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errRecordNotFound = errors.New("record not found")
+
+func withCauseTranslator(t *testing.T, translator CauseTranslator) {
+	configMu.Lock()
+	previous := causeTranslators
+	configMu.Unlock()
+	RegisterCauseTranslator(translator)
+	t.Cleanup(func() {
+		configMu.Lock()
+		causeTranslators = previous
+		configMu.Unlock()
+	})
+}
+
+func translateRecordNotFound(err error) (*Error, bool) {
+	if errors.Is(err, errRecordNotFound) {
+		return NotFound("record", err.Error(), nil), true
+	}
+	return nil, false
+}
+
+func TestCauseTranslators(t *testing.T) {
+	withCauseTranslator(t, translateRecordNotFound)
+
+	t.Run("Propagate", func(t *testing.T) {
+		err := Propagate(errRecordNotFound)
+		assert.True(t, Is(err, ErrNotFound, "record"))
+		assert.False(t, IsRetryable(err))
+		assert.True(t, errors.Is(err, errRecordNotFound))
+	})
+	t.Run("Augment", func(t *testing.T) {
+		err := Augment(errRecordNotFound, "loading user", map[string]string{"user_id": "123"})
+		assert.Equal(t, "not_found.record: loading user: record not found: record not found", err.Error())
+		assert.True(t, Is(err, ErrNotFound, "record"))
+		assert.Equal(t, "123", err.(*Error).Params["user_id"])
+		assert.True(t, errors.Is(err, errRecordNotFound))
+	})
+	t.Run("Wrap", func(t *testing.T) {
+		err := Wrap(errRecordNotFound, map[string]string{"user_id": "123"})
+		assert.True(t, Is(err, ErrNotFound, "record"))
+		assert.Equal(t, "123", err.(*Error).Params["user_id"])
+	})
+	t.Run("WrapWithCode uses the given code", func(t *testing.T) {
+		err := WrapWithCode(errRecordNotFound, nil, ErrForbidden)
+		assert.True(t, Is(err, ErrForbidden))
+	})
+	t.Run("unrecognised errors fall back to internal_service", func(t *testing.T) {
+		err := Propagate(assert.AnError)
+		assert.True(t, Is(err, ErrInternalService))
+	})
+}
+
+func TestCauseTranslatorOrder(t *testing.T) {
+	withCauseTranslator(t, translateRecordNotFound)
+	withCauseTranslator(t, func(err error) (*Error, bool) {
+		return Forbidden("", err.Error(), nil), true
+	})
+
+	assert.True(t, Is(Propagate(errRecordNotFound), ErrNotFound))
+	assert.True(t, Is(Propagate(assert.AnError), ErrForbidden))
+}
+
+func TestRegisterConverter(t *testing.T) {
+	configMu.Lock()
+	previous := causeTranslators
+	configMu.Unlock()
+	t.Cleanup(func() {
+		configMu.Lock()
+		causeTranslators = previous
+		configMu.Unlock()
+	})
+
+	var converter ConverterFunc = translateRecordNotFound
+	RegisterConverter(converter)
+	assert.True(t, Is(Propagate(errRecordNotFound), ErrNotFound, "record"))
+	assert.True(t, Is(Augment(errRecordNotFound, "loading user", nil), ErrNotFound, "record"))
+	assert.True(t, Is(Wrap(errRecordNotFound, nil), ErrNotFound, "record"))
+}
+
+func TestTranslatedCausesHaveCallerStack(t *testing.T) {
+	withCauseTranslator(t, translateRecordNotFound)
+
+	for name, err := range map[string]error{
+		"Propagate":    Propagate(errRecordNotFound),
+		"Wrap":         Wrap(errRecordNotFound, nil),
+		"WrapOpt":      WrapOpt(errRecordNotFound, nil),
+		"ExtendParams": ExtendParams(errRecordNotFound, nil),
+		"Augment":      Augment(errRecordNotFound, "loading user", nil),
+		"built-in":     Augment(sql.ErrNoRows, "loading account", nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			terr := err.(*Error)
+			if assert.NotEmpty(t, terr.StackFrames) {
+				assert.Contains(t, terr.StackFrames[0].Method, "TestTranslatedCausesHaveCallerStack")
+			}
+		})
+	}
+
+	// The translated link under an augmented head doesn't repeat the stack
+	augmented := Augment(errRecordNotFound, "loading user", nil).(*Error)
+	assert.Empty(t, augmented.cause.(*Error).StackFrames)
+}
+
+func TestCauseTranslatorsMayReturnSharedErrors(t *testing.T) {
+	sentinel := NotFound("record", "record not found", nil)
+	stackFrames := sentinel.StackFrames
+	withCauseTranslator(t, func(err error) (*Error, bool) {
+		return sentinel, errors.Is(err, errRecordNotFound)
+	})
+
+	first := Propagate(errRecordNotFound).(*Error)
+	second := Propagate(fmt.Errorf("loading user: %w", errRecordNotFound)).(*Error)
+	assert.Equal(t, errRecordNotFound, first.cause)
+	assert.EqualError(t, second.cause, "loading user: record not found")
+
+	// The sentinel is left as it was
+	assert.Nil(t, sentinel.cause)
+	assert.Equal(t, stackFrames, sentinel.StackFrames)
+}
@@ -0,0 +1,86 @@
+package terrors
+
+import "strings"
+
+// UnknownCodeParam records the original code of an unmarshalled error whose code was replaced by the
+// UnknownCodePolicy.
+const UnknownCodeParam = "unknown_code"
+
+// UnknownCodePolicy decides what Unmarshal does with an error from another service whose code isn't known: one which
+// isn't one of the GenericErrorCodes (including those added with RegisterCode), or a subcode of one. It returns the
+// code to give the error. If the code is changed, the original is kept in the UnknownCodeParam param.
+type UnknownCodePolicy func(code string) string
+
+var unknownCodePolicy UnknownCodePolicy
+
+// SetUnknownCodePolicy installs a policy for the unknown codes of unmarshalled errors, so that strict services can
+// quarantine unexpected codes from misbehaving peers rather than propagating them. Passing nil restores the default,
+// AcceptUnknownCodes.
+func SetUnknownCodePolicy(policy UnknownCodePolicy) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	unknownCodePolicy = policy
+}
+
+func currentUnknownCodePolicy() UnknownCodePolicy {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return unknownCodePolicy
+}
+
+// AcceptUnknownCodes is the default UnknownCodePolicy, which keeps unknown codes as they are.
+func AcceptUnknownCodes(code string) string {
+	return code
+}
+
+// MapUnknownCodesToUnknown is an UnknownCodePolicy which gives every error with an unknown code the code ErrUnknown.
+func MapUnknownCodesToUnknown(code string) string {
+	return ErrUnknown
+}
+
+// MapUnknownCodesByPrefix returns an UnknownCodePolicy which replaces the longest prefix of an unknown code found in
+// prefixes, keeping any subcode, e.g. with {"legacy_timeout": ErrTimeout}, legacy_timeout.ledger becomes
+// timeout.ledger. Codes without a mapped prefix become ErrUnknown.
+func MapUnknownCodesByPrefix(prefixes map[string]string) UnknownCodePolicy {
+	mapped := make(map[string]string, len(prefixes))
+	for from, to := range prefixes {
+		mapped[from] = to
+	}
+	return func(code string) string {
+		best := ""
+		for from := range mapped {
+			if hasCodePrefix(code, from) && len(from) > len(best) {
+				best = from
+			}
+		}
+		if best == "" {
+			return ErrUnknown
+		}
+		return mapped[best] + code[len(best):]
+	}
+}
+
+// isKnownCode returns whether code is one of the GenericErrorCodes, or a subcode of one.
+func isKnownCode(code string) bool {
+	return currentGenericCodeTrie().hasCodePrefixOf(code)
+}
+
+// hasCodePrefix returns whether code is prefix, or a subcode of it.
+func hasCodePrefix(code, prefix string) bool {
+	return code == prefix || strings.HasPrefix(code, prefix+".")
+}
+
+// applyUnknownCodePolicy replaces the code of an unmarshalled error according to the installed UnknownCodePolicy.
+func applyUnknownCodePolicy(err *Error) {
+	policy := currentUnknownCodePolicy()
+	if policy == nil || isKnownCode(err.Code) {
+		return
+	}
+	code := policy(err.Code)
+	if code == err.Code {
+		return
+	}
+	// The params may be shared with the protobuf, so copy them
+	err.Params = mergeParams(err.Params, map[string]string{UnknownCodeParam: err.Code})
+	err.Code = code
+}
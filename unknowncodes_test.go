@@ -0,0 +1,56 @@
+package terrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pe "github.com/monzo/terrors/proto"
+)
+
+func withUnknownCodePolicy(t *testing.T, policy UnknownCodePolicy) {
+	SetUnknownCodePolicy(policy)
+	t.Cleanup(func() { SetUnknownCodePolicy(nil) })
+}
+
+func TestUnknownCodesAcceptedByDefault(t *testing.T) {
+	err := Unmarshal(&pe.Error{Code: "teapot.brewing"})
+	assert.Equal(t, "teapot.brewing", err.Code)
+	assert.NotContains(t, err.Params, UnknownCodeParam)
+}
+
+func TestMapUnknownCodesToUnknown(t *testing.T) {
+	withUnknownCodePolicy(t, MapUnknownCodesToUnknown)
+
+	params := map[string]string{"a": "1"}
+	err := Unmarshal(&pe.Error{Code: "teapot.brewing", Params: params})
+	assert.Equal(t, ErrUnknown, err.Code)
+	assert.Equal(t, map[string]string{"a": "1", UnknownCodeParam: "teapot.brewing"}, err.Params)
+	// The params of the protobuf are untouched
+	assert.Equal(t, map[string]string{"a": "1"}, params)
+
+	// Known codes, and subcodes of them, are untouched
+	assert.Equal(t, "not_found.account", Unmarshal(&pe.Error{Code: "not_found.account"}).Code)
+	assert.Equal(t, ErrTimeout, Unmarshal(&pe.Error{Code: ErrTimeout}).Code)
+	// A code which merely starts with the same letters as a known one isn't known
+	assert.Equal(t, ErrUnknown, Unmarshal(&pe.Error{Code: "timeouts"}).Code)
+}
+
+func TestUnknownCodesIncludeRegisteredCodes(t *testing.T) {
+	withRegisteredCodes(t)
+	withUnknownCodePolicy(t, MapUnknownCodesToUnknown)
+
+	RegisterCode("degraded")
+	assert.Equal(t, "degraded.ledger", Unmarshal(&pe.Error{Code: "degraded.ledger"}).Code)
+}
+
+func TestMapUnknownCodesByPrefix(t *testing.T) {
+	withUnknownCodePolicy(t, MapUnknownCodesByPrefix(map[string]string{
+		"legacy_timeout":        ErrTimeout,
+		"legacy_timeout.ledger": ErrUnavailable,
+	}))
+
+	assert.Equal(t, "timeout.payments", Unmarshal(&pe.Error{Code: "legacy_timeout.payments"}).Code)
+	assert.Equal(t, ErrUnavailable, Unmarshal(&pe.Error{Code: "legacy_timeout.ledger"}).Code)
+	assert.Equal(t, ErrUnknown, Unmarshal(&pe.Error{Code: "teapot"}).Code)
+}
@@ -0,0 +1,76 @@
+package terrors
+
+import (
+	"encoding/json"
+
+	"github.com/monzo/terrors/stack"
+)
+
+type verboseDocument struct {
+	Code         string        `json:"code"`
+	Error        string        `json:"error"`
+	MessageChain []string      `json:"message_chain,omitempty"`
+	Retryable    bool          `json:"retryable"`
+	Unexpected   bool          `json:"unexpected"`
+	MarshalCount int           `json:"marshal_count"`
+	Links        []verboseLink `json:"links"`
+}
+
+// verboseLink is a single error in the causal chain. Links which aren't terrors only have a message.
+type verboseLink struct {
+	Code    string            `json:"code,omitempty"`
+	Message string            `json:"message"`
+	Params  map[string]string `json:"params,omitempty"`
+	Stack   stack.Stack       `json:"stack,omitempty"`
+}
+
+// VerboseJSON returns the same information as VerboseString as a JSON document, so that it can be ingested by
+// structured log search. Each error in the local causal chain is included as a separate link with its own params and
+// stack. Errors which are not terrors are rendered as they would be if passed to Propagate. If a SecretScanner is
+// installed, the messages and params are masked as they are by Marshal. A nil error returns nil.
+func VerboseJSON(err error) []byte {
+	if err == nil {
+		return nil
+	}
+	terr, ok := err.(*Error)
+	if !ok {
+		terr = untrackedError(causeCode(err), err.Error(), nil)
+		inheritFlags(terr, err)
+		terr.cause = err
+	}
+
+	scanner := currentSecretScanner()
+	errorString := terr.Error()
+	if scanner != nil {
+		errorString, _ = scanner.Mask(errorString)
+	}
+	_, messageChain := scanner.maskMessages(terr.Code, "", terr.MessageChain)
+	doc := verboseDocument{
+		Code:         terr.Code,
+		Error:        errorString,
+		MessageChain: messageChain,
+		Retryable:    terr.Retryable(),
+		Unexpected:   terr.Unexpected(),
+		MarshalCount: terr.MarshalCount,
+	}
+	var next error = terr
+	maxDepth := CurrentMaxCausalDepth()
+	for depth := 0; next != nil && depth < maxDepth; depth++ {
+		link, ok := next.(*Error)
+		if !ok {
+			doc.Links = append(doc.Links, verboseLink{Message: scanner.maskField("", "message", next.Error())})
+			break
+		}
+		doc.Links = append(doc.Links, verboseLink{
+			Code:    link.Code,
+			Message: scanner.maskField(link.Code, "message", link.Message),
+			Params:  scanner.maskParams(link.Code, link.Params),
+			Stack:   link.StackFrames,
+		})
+		next = link.cause
+	}
+
+	// None of the types in the document can fail to marshal.
+	out, _ := json.Marshal(doc)
+	return out
+}
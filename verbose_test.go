@@ -0,0 +1,70 @@
+package terrors
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerboseJSON(t *testing.T) {
+	base := NewInternalWithCause(assert.AnError, "calling database", map[string]string{"table": "users"}, "db")
+	err := Augment(base, "loading user", map[string]string{"user_id": "123"})
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(VerboseJSON(err), &doc))
+
+	assert.Equal(t, "internal_service.db", doc["code"])
+	assert.Equal(t, err.Error(), doc["error"])
+	assert.Equal(t, []interface{}{"calling database", assert.AnError.Error()}, doc["message_chain"])
+	assert.Equal(t, true, doc["retryable"])
+
+	links := doc["links"].([]interface{})
+	if !assert.Len(t, links, 3) {
+		return
+	}
+
+	outer := links[0].(map[string]interface{})
+	assert.Equal(t, "loading user", outer["message"])
+	assert.Equal(t, map[string]interface{}{"table": "users", "user_id": "123"}, outer["params"])
+	assert.Nil(t, outer["stack"])
+
+	middle := links[1].(map[string]interface{})
+	assert.Equal(t, "calling database", middle["message"])
+	assert.Equal(t, map[string]interface{}{"table": "users"}, middle["params"])
+	frame := middle["stack"].([]interface{})[0].(map[string]interface{})
+	assert.Contains(t, frame["method"], "TestVerboseJSON")
+
+	assert.Equal(t, map[string]interface{}{"message": assert.AnError.Error()}, links[2])
+}
+
+func TestVerboseJSONNonTerror(t *testing.T) {
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(VerboseJSON(assert.AnError), &doc))
+	assert.Equal(t, ErrInternalService, doc["code"])
+	assert.Len(t, doc["links"], 2)
+
+	assert.Nil(t, VerboseJSON(nil))
+}
+
+func TestVerboseJSONMasksLinks(t *testing.T) {
+	withSecretScanner(t, &SecretScanner{Detectors: DefaultSecretDetectors()})
+	SetLogMetadataMode(LogMetadataChain)
+	t.Cleanup(func() { SetLogMetadataMode(LogMetadataOuter) })
+
+	base := BadRequest("invalid_email", "bad email jane@example.com", map[string]string{"email": "jane@example.com"})
+	err := NewInternalWithCause(base, "validating signup", map[string]string{"plan": "gold"}, "")
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(VerboseJSON(err), &doc))
+	assert.NotContains(t, doc["error"], "jane@example.com")
+	assert.Equal(t, []interface{}{"bad email [REDACTED:email]"}, doc["message_chain"])
+
+	links := doc["links"].([]interface{})
+	inner := links[1].(map[string]interface{})
+	assert.Equal(t, "bad email [REDACTED:email]", inner["message"])
+	// Each link has only its own params, even when log metadata covers the whole chain
+	assert.Equal(t, map[string]interface{}{"email": "[REDACTED:email]"}, inner["params"])
+	outer := links[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"plan": "gold"}, outer["params"])
+}
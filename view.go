@@ -0,0 +1,112 @@
+package terrors
+
+import (
+	"github.com/monzo/terrors/stack"
+)
+
+// ErrorView is a read-only view of an error, for middleware and other code which inspects errors it doesn't own.
+// Unlike *Error, it can't be used to modify the error: everything it returns is a copy, so the discipline of not
+// mutating shared errors is enforced at compile time.
+type ErrorView interface {
+	Code() string
+	Message() string
+	// Params returns a copy of the params.
+	Params() map[string]string
+	Param(key string) (string, bool)
+	Retryable() bool
+	Unexpected() bool
+	MarshalCount() int
+	// MessageChain returns a copy of the message chain.
+	MessageChain() []string
+	// ContextChain returns a copy of the context chain.
+	ContextChain() []ContextEntry
+	// StackFrames returns a copy of the stack. The frames themselves are shared, and must not be modified.
+	StackFrames() stack.Stack
+	// Cause returns a view of the cause of the error, or nil if it has none. A cause which isn't a terror is viewed
+	// as it would be if it were passed to Propagate, but without a cause of its own.
+	Cause() ErrorView
+	Error() string
+}
+
+// View returns a read-only view of err, as it was when View was called. Errors which aren't terrors are viewed as
+// they would be if they were passed to Propagate. A nil error returns nil.
+func View(err error) ErrorView {
+	if err == nil {
+		return nil
+	}
+	terr, ok := err.(*Error)
+	if !ok {
+		terr = untrackedError(causeCode(err), err.Error(), nil)
+		inheritFlags(terr, err)
+		terr.cause = err
+		return errorView{terr}
+	}
+	return errorView{terr.snapshot()}
+}
+
+// View returns a read-only view of the error. See View.
+func (p *Error) View() ErrorView {
+	return errorView{p.snapshot()}
+}
+
+// errorView implements ErrorView over a snapshot of an error, which nothing else refers to.
+type errorView struct {
+	err *Error
+}
+
+func (v errorView) Code() string    { return v.err.Code }
+func (v errorView) Message() string { return v.err.Message }
+func (v errorView) Error() string   { return v.err.Error() }
+
+func (v errorView) Params() map[string]string {
+	return mergeParams(v.err.Params, nil)
+}
+
+func (v errorView) Param(key string) (string, bool) {
+	value, ok := v.err.Params[key]
+	return value, ok
+}
+
+func (v errorView) Retryable() bool   { return v.err.Retryable() }
+func (v errorView) Unexpected() bool  { return v.err.Unexpected() }
+func (v errorView) MarshalCount() int { return v.err.MarshalCount }
+
+func (v errorView) MessageChain() []string {
+	if v.err.MessageChain == nil {
+		return nil
+	}
+	return append([]string(nil), v.err.MessageChain...)
+}
+
+func (v errorView) ContextChain() []ContextEntry {
+	if v.err.ContextChain == nil {
+		return nil
+	}
+	chain := make([]ContextEntry, len(v.err.ContextChain))
+	for i, entry := range v.err.ContextChain {
+		entry.Params = mergeParams(entry.Params, nil)
+		chain[i] = entry
+	}
+	return chain
+}
+
+func (v errorView) StackFrames() stack.Stack {
+	if v.err.StackFrames == nil {
+		return nil
+	}
+	return append(stack.Stack(nil), v.err.StackFrames...)
+}
+
+func (v errorView) Cause() ErrorView {
+	switch cause := v.err.cause.(type) {
+	case nil:
+		return nil
+	case *Error:
+		return errorView{cause.snapshot()}
+	default:
+		// The view of an error which isn't a terror is the end of the chain, so that walking it terminates
+		leaf := untrackedError(causeCode(cause), cause.Error(), nil)
+		inheritFlags(leaf, cause)
+		return errorView{leaf}
+	}
+}
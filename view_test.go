@@ -0,0 +1,53 @@
+package terrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestView(t *testing.T) {
+	cause := NotFound("account", "account not found", map[string]string{"account_id": "acc_123"})
+	err := Augment(cause, "loading account", map[string]string{"attempt": "1"}).(*Error)
+
+	view := View(err)
+	assert.Equal(t, "not_found.account", view.Code())
+	assert.Equal(t, "loading account", view.Message())
+	assert.Equal(t, err.Error(), view.Error())
+	assert.False(t, view.Retryable())
+	assert.False(t, view.Unexpected())
+	assert.Equal(t, 0, view.MarshalCount())
+	assert.Equal(t, []string{"account not found"}, view.MessageChain())
+	value, ok := view.Param("attempt")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+
+	// Modifying what the view returns doesn't modify the error
+	view.Params()["attempt"] = "2"
+	view.MessageChain()[0] = "changed"
+	view.ContextChain()[0].Params["account_id"] = "changed"
+	assert.Equal(t, "1", err.Params["attempt"])
+	assert.Equal(t, "account not found", err.MessageChain[0])
+	assert.Equal(t, "acc_123", err.ContextChain[0].Params["account_id"])
+
+	// The view is a snapshot
+	err.SetIsRetryable(true)
+	assert.False(t, view.Retryable())
+	assert.True(t, err.View().Retryable())
+
+	assert.Equal(t, "account not found", view.Cause().Message())
+	assert.Equal(t, cause.StackFrames, view.Cause().StackFrames())
+	assert.Nil(t, view.Cause().Cause())
+}
+
+func TestViewNonTerror(t *testing.T) {
+	assert.Nil(t, View(nil))
+
+	boom := errors.New("boom")
+	view := View(boom)
+	assert.Equal(t, ErrInternalService, view.Code())
+	assert.Equal(t, "boom", view.Message())
+	assert.Equal(t, "boom", view.Cause().Message())
+	assert.Nil(t, view.Cause().Cause())
+}
@@ -0,0 +1,67 @@
+package terrors
+
+const (
+	// SeverityParam is the param under which the severity of an error is stored, so that it survives marshalling. It is
+	// namespaced so that it doesn't clash with params of the same name set by services.
+	SeverityParam = "terrors_severity"
+	// SeverityWarning is the severity of warnings. See Warning.
+	SeverityWarning = "warning"
+)
+
+// Warning creates a new error with severity warning, representing an operation which succeeded in a degraded way
+// (e.g. a response served from a stale cache, or with optional parts missing). It satisfies error, so that partial
+// degradation can flow through the same plumbing as errors, but it is excluded from ShouldPage and CountsTowardsSLO.
+// Warnings are not retryable, as the operation succeeded, and are not unexpected.
+func Warning(code, message string, params map[string]string) *Error {
+	err := errorFactory(code, message, mergeParams(params, map[string]string{SeverityParam: SeverityWarning}))
+	err.SetIsRetryable(false)
+	err.SetIsUnexpected(false)
+	return err
+}
+
+// AsWarning returns a copy of err with severity warning. If err is not a terror, it is propagated first so that the
+// severity can be attached. A nil error returns nil.
+func AsWarning(err error) error {
+	if err == nil {
+		return nil
+	}
+	return addParams(Propagate(err).(*Error), map[string]string{SeverityParam: SeverityWarning})
+}
+
+// IsWarning reports whether err has severity warning. Causes with the same code as err are considered too, so a
+// warning is still a warning after it has been augmented, but an error with a new code which was caused by a warning
+// (e.g. a failure built on top of a degraded response) is not.
+func IsWarning(err error) bool {
+	head, ok := err.(*Error)
+	if !ok {
+		return false
+	}
+	maxDepth := CurrentMaxCausalDepth()
+	terr := head
+	for depth := 0; depth < maxDepth; depth++ {
+		if terr.Params[SeverityParam] == SeverityWarning {
+			return true
+		}
+		cause, ok := terr.cause.(*Error)
+		if !ok || cause.Code != head.Code {
+			return false
+		}
+		terr = cause
+	}
+	return false
+}
+
+// CountsTowardsSLO reports whether err should be counted as a failure when measuring service level objectives. Nil
+// errors and warnings are not counted.
+func CountsTowardsSLO(err error) bool {
+	return err != nil && !IsWarning(err)
+}
+
+// ShouldPage reports whether err should page whoever is on call: it must count towards SLOs (so it is not a warning)
+// and be unexpected. Errors which aren't terrors are treated as they would be if they were passed to Propagate.
+func ShouldPage(err error) bool {
+	if !CountsTowardsSLO(err) {
+		return false
+	}
+	return Summary(err).Unexpected
+}
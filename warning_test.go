@@ -0,0 +1,76 @@
+package terrors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarning(t *testing.T) {
+	warn := Warning("stale_cache", "served from stale cache", map[string]string{"age": "5m"})
+	assert.Equal(t, "stale_cache", warn.Code)
+	assert.Equal(t, "5m", warn.Params["age"])
+	assert.True(t, IsWarning(warn))
+	assert.False(t, warn.Retryable())
+	assert.False(t, warn.Unexpected())
+	assert.NotEmpty(t, warn.StackFrames)
+
+	// The caller's params aren't modified, so they can be shared between errors
+	params := map[string]string{"age": "5m"}
+	Warning("stale_cache", "served from stale cache", params)
+	assert.Equal(t, map[string]string{"age": "5m"}, params)
+
+	augmented := Augment(warn, "loading balance", nil)
+	assert.True(t, IsWarning(augmented))
+	assert.True(t, IsWarning(Unmarshal(Marshal(augmented.(*Error)))))
+
+	assert.False(t, IsWarning(nil))
+	assert.False(t, IsWarning(errors.New("boom")))
+	assert.False(t, IsWarning(InternalService("", "boom", nil)))
+
+	// Errors with a new code aren't warnings just because they were caused by one
+	failed := NewInternalWithCause(warn, "db failed", nil, "db")
+	assert.False(t, IsWarning(failed))
+	assert.False(t, IsWarning(Augment(failed, "loading balance", nil)))
+	assert.False(t, CountsTowardsSLO(warn))
+	assert.True(t, CountsTowardsSLO(failed))
+}
+
+func TestAsWarning(t *testing.T) {
+	assert.Nil(t, AsWarning(nil))
+
+	warn := AsWarning(errors.New("partial"))
+	assert.True(t, IsWarning(warn))
+	assert.True(t, Is(warn, ErrInternalService))
+
+	// Errors which aren't terrors are propagated, so keep their code
+	warn = AsWarning(context.DeadlineExceeded)
+	assert.True(t, IsWarning(warn))
+	assert.True(t, Is(warn, ErrTimeout))
+
+	orig := NotFound("optional", "optional part missing", nil)
+	warn = AsWarning(orig)
+	assert.True(t, IsWarning(warn))
+	assert.False(t, IsWarning(orig))
+}
+
+func TestShouldPage(t *testing.T) {
+	unexpected := InternalService("", "boom", nil)
+	unexpected.SetIsUnexpected(true)
+
+	assert.False(t, ShouldPage(nil))
+	assert.False(t, CountsTowardsSLO(nil))
+
+	assert.True(t, ShouldPage(unexpected))
+	assert.True(t, CountsTowardsSLO(unexpected))
+
+	expected := NotFound("foo", "foo not found", nil)
+	assert.False(t, ShouldPage(expected))
+	assert.True(t, CountsTowardsSLO(expected))
+
+	warn := AsWarning(unexpected)
+	assert.False(t, ShouldPage(warn))
+	assert.False(t, CountsTowardsSLO(warn))
+}